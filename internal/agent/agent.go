@@ -0,0 +1,90 @@
+// Package agent 实现缓存密码库密钥的后台进程
+//
+// agent 在锁定的内存中保存由主密码派生的密钥，按密码库的盐值区分不同的密码库，
+// 通过仅当前用户可访问的 Unix 套接字提供服务，空闲超过指定时间后自动清除所有密钥。
+// 命令行和 api.Client 通过 Client 查询密钥，从而避免每次输入主密码和重新派生密钥。
+package agent
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// EnvSocket 指定 agent 套接字路径的环境变量
+const EnvSocket = "CIPHERHUB_AGENT_SOCK"
+
+// DefaultIdleTimeout 是 agent 默认的空闲超时时间
+const DefaultIdleTimeout = 15 * time.Minute
+
+var (
+	// ErrNotRunning 表示 agent 未运行
+	ErrNotRunning = errors.New("agent: not running")
+	// ErrLocked 表示 agent 中没有该密码库的密钥
+	ErrLocked = errors.New("agent: vault is locked")
+	// ErrInsecureSocket 表示套接字目录的所有者或权限不安全
+	ErrInsecureSocket = errors.New("agent: insecure socket directory")
+)
+
+// 请求类型
+const (
+	opGet    = "get"
+	opAdd    = "add"
+	opLock   = "lock"
+	opStatus = "status"
+	opStop   = "stop"
+)
+
+// request 是客户端发送的请求，每行一个 JSON 对象
+type request struct {
+	Op    string `json:"op"`
+	Vault string `json:"vault,omitempty"` // 密码库盐值（base64）
+	Key   string `json:"key,omitempty"`   // 密钥（base64），仅用于 add
+}
+
+// response 是 agent 返回的响应
+type response struct {
+	Key    string  `json:"key,omitempty"`
+	Status *Status `json:"status,omitempty"`
+	Error  string  `json:"error,omitempty"`
+	Locked bool    `json:"locked,omitempty"` // 错误是否为 ErrLocked
+}
+
+// Status 描述 agent 的运行状态
+type Status struct {
+	PID         int           `json:"pid"`
+	Vaults      int           `json:"vaults"`       // 已解锁的密码库数量
+	IdleTimeout time.Duration `json:"idle_timeout"` // 空闲超时时间
+	LockAt      time.Time     `json:"lock_at"`      // 预计自动锁定的时间，未解锁时为零值
+}
+
+// vaultID 将盐值编码为密码库标识
+func vaultID(salt []byte) string {
+	return base64.StdEncoding.EncodeToString(salt)
+}
+
+// SocketPath 返回 agent 套接字的路径
+//
+// 优先使用 CIPHERHUB_AGENT_SOCK；否则位于 $XDG_RUNTIME_DIR/cipherhub，
+// 没有 XDG_RUNTIME_DIR 时位于系统临时目录下的 cipherhub-<uid>。
+func SocketPath() string {
+	if p := os.Getenv(EnvSocket); p != "" {
+		return p
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "cipherhub", "agent.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("cipherhub-%d", os.Getuid()), "agent.sock")
+}
+
+// ensureSocketDir 创建仅当前用户可访问的套接字目录，并检查已有目录的权限
+func ensureSocketDir(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return checkSocketDir(dir)
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net"
+	"path/filepath"
+	"time"
+)
+
+// Client 连接到 agent 查询和管理密钥
+type Client struct {
+	path    string
+	timeout time.Duration
+}
+
+// NewClient 创建连接到 path 上 agent 的客户端，path 为空时使用 SocketPath()
+func NewClient(path string) *Client {
+	if path == "" {
+		path = SocketPath()
+	}
+	return &Client{path: path, timeout: 5 * time.Second}
+}
+
+// call 发送一个请求并读取响应
+//
+// 连接前检查套接字目录属于当前用户且其他用户不可访问，避免把密钥交给其他用户伪造的 agent；
+// 目录不安全时返回 ErrInsecureSocket。
+func (c *Client) call(req *request) (*response, error) {
+	if err := checkSocketDir(filepath.Dir(c.path)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotRunning
+		}
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("unix", c.path, time.Second)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Locked {
+		return nil, ErrLocked
+	}
+	if resp.Error != "" {
+		return nil, errors.New("agent: " + resp.Error)
+	}
+	return &resp, nil
+}
+
+// Running 检查 agent 是否正在运行
+func (c *Client) Running() bool {
+	_, err := c.Status()
+	return err == nil
+}
+
+// Key 返回盐值为 salt 的密码库的密钥
+//
+// 签名与 vault.Manager.OpenWithKey 的参数一致，可以直接传入。
+// agent 未运行返回 ErrNotRunning，没有该密码库的密钥返回 ErrLocked。
+func (c *Client) Key(salt []byte) ([]byte, error) {
+	resp, err := c.call(&request{Op: opGet, Vault: vaultID(salt)})
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Key)
+}
+
+// Add 将密钥交给 agent 缓存
+func (c *Client) Add(salt, key []byte) error {
+	_, err := c.call(&request{Op: opAdd, Vault: vaultID(salt), Key: base64.StdEncoding.EncodeToString(key)})
+	return err
+}
+
+// Lock 清除 agent 中的所有密钥
+func (c *Client) Lock() error {
+	_, err := c.call(&request{Op: opLock})
+	return err
+}
+
+// Status 返回 agent 的运行状态
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.call(&request{Op: opStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// Stop 清除所有密钥并停止 agent
+func (c *Client) Stop() error {
+	_, err := c.call(&request{Op: opStop})
+	return err
+}
+
+// WaitRunning 等待 agent 开始监听，最长等待 timeout
+func (c *Client) WaitRunning(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if c.Running() {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrNotRunning
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build !windows
+
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// startTestAgent 在 dir 中启动 agent，测试结束时停止
+func startTestAgent(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "agent.sock")
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(0)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return path
+}
+
+func TestClientAddAndKey(t *testing.T) {
+	client := NewClient(startTestAgent(t, filepath.Join(t.TempDir(), "cipherhub")))
+	salt, key := []byte("salt"), []byte("0123456789abcdef0123456789abcdef")
+
+	if _, err := client.Key(salt); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Key before Add error = %v, want ErrLocked", err)
+	}
+	if err := client.Add(salt, key); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.Key(salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(key) {
+		t.Fatalf("Key = %q, want %q", got, key)
+	}
+}
+
+func TestClientChecksSocketDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    os.FileMode
+		wantErr error
+	}{
+		{"private", 0700, nil},
+		{"group readable", 0750, ErrInsecureSocket},
+		{"world writable", 0777, ErrInsecureSocket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "cipherhub")
+			client := NewClient(startTestAgent(t, dir))
+			if err := os.Chmod(dir, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			err := client.Add([]byte("salt"), []byte("0123456789abcdef0123456789abcdef"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Add error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientMissingSocketDir(t *testing.T) {
+	client := NewClient(filepath.Join(t.TempDir(), "missing", "agent.sock"))
+	if _, err := client.Status(); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Status error = %v, want ErrNotRunning", err)
+	}
+}
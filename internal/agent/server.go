@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Server 是 agent 的服务端，在锁定的内存中保存密钥
+type Server struct {
+	idle time.Duration
+
+	mu     sync.Mutex
+	keys   map[string][]byte // 密码库标识 -> 密钥
+	timer  *time.Timer
+	lockAt time.Time
+
+	listener net.Listener
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewServer 创建一个 agent 服务端
+//
+// idle 为空闲超时时间，超过该时间没有使用密钥时清除所有密钥；不大于 0 时使用 DefaultIdleTimeout。
+func NewServer(idle time.Duration) *Server {
+	if idle <= 0 {
+		idle = DefaultIdleTimeout
+	}
+	return &Server{
+		idle: idle,
+		keys: make(map[string][]byte),
+		done: make(chan struct{}),
+	}
+}
+
+// Listen 在 path 上创建 Unix 套接字
+//
+// 套接字所在目录仅当前用户可访问；已有 agent 在运行时返回错误，
+// 残留的套接字文件会被删除。
+func Listen(path string) (net.Listener, error) {
+	if err := ensureSocketDir(path); err != nil {
+		return nil, err
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, errors.New("agent: already running")
+	}
+	os.Remove(path)
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve 处理 l 上的连接，直到收到 stop 请求或 Stop 被调用
+//
+// 启动前会禁止生成 core dump，避免密钥被写入磁盘。
+func (s *Server) Serve(l net.Listener) error {
+	disableCoreDumps()
+	s.listener = l
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go s.handle(conn)
+	}
+}
+
+// Stop 清除所有密钥并停止服务
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() {
+		s.Lock()
+		close(s.done)
+		if s.listener != nil {
+			s.listener.Close()
+		}
+	})
+}
+
+// Lock 清除所有密钥
+func (s *Server) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockLocked()
+}
+
+// lockLocked 清除所有密钥，调用方需持有锁
+func (s *Server) lockLocked() {
+	for id, key := range s.keys {
+		wipe(key)
+		unlockMemory(key)
+		delete(s.keys, id)
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.lockAt = time.Time{}
+}
+
+// touch 重置空闲计时器，调用方需持有锁
+func (s *Server) touch() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.lockAt = time.Now().Add(s.idle)
+	s.timer = time.AfterFunc(s.idle, s.expire)
+}
+
+// expire 在空闲超时后清除所有密钥
+//
+// 计时器触发时如果密钥刚被使用过（lockAt 已被推迟），则不做任何操作。
+func (s *Server) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.lockAt.IsZero() && time.Now().Before(s.lockAt) {
+		return
+	}
+	s.lockLocked()
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var req request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		return
+	}
+	resp := s.dispatch(&req)
+	json.NewEncoder(conn).Encode(resp)
+
+	if req.Op == opStop {
+		s.Stop()
+	}
+}
+
+func (s *Server) dispatch(req *request) *response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Op {
+	case opGet:
+		key, ok := s.keys[req.Vault]
+		if !ok {
+			return &response{Error: ErrLocked.Error(), Locked: true}
+		}
+		s.touch()
+		return &response{Key: base64.StdEncoding.EncodeToString(key)}
+
+	case opAdd:
+		key, err := base64.StdEncoding.DecodeString(req.Key)
+		if err != nil || req.Vault == "" {
+			return &response{Error: "invalid request"}
+		}
+		if old, ok := s.keys[req.Vault]; ok {
+			wipe(old)
+			unlockMemory(old)
+		}
+		lockMemory(key)
+		s.keys[req.Vault] = key
+		s.touch()
+		return &response{}
+
+	case opLock:
+		s.lockLocked()
+		return &response{}
+
+	case opStatus:
+		return &response{Status: &Status{
+			PID:         os.Getpid(),
+			Vaults:      len(s.keys),
+			IdleTimeout: s.idle,
+			LockAt:      s.lockAt,
+		}}
+
+	case opStop:
+		return &response{}
+
+	default:
+		return &response{Error: "unknown operation " + req.Op}
+	}
+}
+
+// wipe 将密钥清零
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockMemory 锁定密钥所在的内存页，防止被换出到磁盘
+//
+// 超出 RLIMIT_MEMLOCK 等原因导致失败时忽略错误，密钥仍然可用。
+func lockMemory(b []byte) {
+	if len(b) > 0 {
+		unix.Mlock(b)
+	}
+}
+
+// unlockMemory 解除内存锁定
+func unlockMemory(b []byte) {
+	if len(b) > 0 {
+		unix.Munlock(b)
+	}
+}
+
+// disableCoreDumps 禁止进程生成 core dump
+func disableCoreDumps() {
+	unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{Cur: 0, Max: 0})
+}
+
+// checkSocketDir 检查套接字目录属于当前用户且其他用户不可访问
+func checkSocketDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%w: %s is owned by uid %d", ErrInsecureSocket, dir, st.Uid)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%w: %s has mode %o, want 0700", ErrInsecureSocket, dir, info.Mode().Perm())
+	}
+	return nil
+}
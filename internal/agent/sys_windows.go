@@ -0,0 +1,17 @@
+//go:build windows
+
+package agent
+
+// lockMemory 在 Windows 上不做处理
+func lockMemory(b []byte) {}
+
+// unlockMemory 在 Windows 上不做处理
+func unlockMemory(b []byte) {}
+
+// disableCoreDumps 在 Windows 上不做处理
+func disableCoreDumps() {}
+
+// checkSocketDir 在 Windows 上依赖用户目录的默认访问控制
+func checkSocketDir(dir string) error {
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/imerr0rlog/CipherHub/internal/totp"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +19,12 @@ var (
 	addURL      string
 	addNotes    string
 	addTags     string
+	addTOTP     string
+	addFields   []string
+	addFiles    []string
+	addType     string
+	addRotation string
+	addExpires  string
 )
 
 var addCmd = &cobra.Command{
@@ -25,36 +33,62 @@ var addCmd = &cobra.Command{
 	Long: `Add a new password entry to the vault.
 
 You will be prompted for the master password and any fields not provided
-via flags. The password will be encrypted before storage.`,
+via flags. The password will be encrypted before storage.
+
+Custom fields are added with --field name[:type]=value, where type is
+text (default), hidden, url, email or date. Hidden fields are encrypted;
+omit =value to be prompted for the value without echo. Multi-line values
+such as private keys can be read from a file with --field-file name=path.
+
+--type selects a template that prompts for the fields of that kind of
+entry: login (default), note, card, identity, ssh-key, database, api or
+wifi. Fields given with --field are not prompted for again.
+
+--rotation N requires the password to be changed every N days and
+--expires YYYY-MM-DD sets an expiry date; 'cipherhub due' lists the
+entries that are due.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
-		masterPassword, err := promptPassword("Enter master password: ")
+		entryType, err := types.ParseEntryType(addType)
 		if err != nil {
 			return err
 		}
+		tmpl := types.TemplateFor(entryType)
 
-		mgr, err := openVault(masterPassword)
+		mgr, err := unlockVault()
 		if err != nil {
 			return fmt.Errorf("failed to open vault: %w", err)
 		}
 		defer mgr.Close()
 
-		if addUsername == "" {
+		if addUsername == "" && tmpl.UsesBuiltin("username") {
 			addUsername, err = promptInput("Username: ")
 			if err != nil {
 				return err
 			}
 		}
 
-		if addPassword == "" {
+		if addPassword == "" && tmpl.UsesBuiltin("password") {
 			addPassword, err = promptPassword("Password: ")
 			if err != nil {
 				return err
 			}
 		}
 
+		// login 条目保持原有行为，不提示输入 URL
+		if addURL == "" && tmpl.UsesBuiltin("url") && entryType != types.EntryTypeLogin {
+			if addURL, err = promptInput("URL: "); err != nil {
+				return err
+			}
+		}
+		if addNotes == "" && tmpl.UsesBuiltin("notes") {
+			if addNotes, err = promptInput("Notes: "); err != nil {
+				return err
+			}
+		}
+
 		var tags []string
 		if addTags != "" {
 			tags = strings.Split(addTags, ",")
@@ -63,13 +97,65 @@ via flags. The password will be encrypted before storage.`,
 			}
 		}
 
+		// 先检查 TOTP、到期策略和自定义字段，避免添加条目后才失败
+		if addTOTP != "" {
+			if _, err := totp.Parse(addTOTP); err != nil {
+				return err
+			}
+		}
+		if _, err := types.ParseRotationDays(addRotation); err != nil {
+			return err
+		}
+		if _, err := types.ParseExpiry(addExpires); err != nil {
+			return err
+		}
+		fields, err := readFieldFlags(addFields, false, tmpl)
+		if err != nil {
+			return err
+		}
+		fileFields, err := readFieldFileFlags(addFiles, tmpl)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, fileFields...)
+		prompted, err := promptTemplateFields(tmpl, fields)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, prompted...)
+
 		entry, err := mgr.AddEntry(name, addUsername, addPassword, addURL, addNotes, tags)
 		if err != nil {
 			return fmt.Errorf("failed to add entry: %w", err)
 		}
+		updates := make(map[string]string)
+		if entryType != types.EntryTypeLogin {
+			updates["type"] = string(entryType)
+		}
+		if addTOTP != "" {
+			updates["totp"] = addTOTP
+		}
+		if addRotation != "" {
+			updates["rotation"] = addRotation
+		}
+		if addExpires != "" {
+			updates["expires"] = addExpires
+		}
+		if len(updates) > 0 {
+			if entry, err = mgr.UpdateEntry(name, updates); err != nil {
+				return fmt.Errorf("failed to set entry type, TOTP secret or expiry: %w", err)
+			}
+		}
+		if len(fields) > 0 {
+			if entry, err = mgr.SetFields(name, fields); err != nil {
+				return fmt.Errorf("failed to add fields: %w", err)
+			}
+		}
 
-		fmt.Printf("✓ Entry '%s' added successfully (ID: %s)\n", entry.Name, entry.ID)
-		return nil
+		return render(newEntryOutput(entry), func() error {
+			fmt.Printf("✓ Entry '%s' added successfully (ID: %s)\n", entry.Path(), entry.ID)
+			return nil
+		})
 	},
 }
 
@@ -79,4 +165,10 @@ func init() {
 	addCmd.Flags().StringVarP(&addURL, "url", "U", "", "URL for the entry")
 	addCmd.Flags().StringVarP(&addNotes, "notes", "n", "", "notes for the entry")
 	addCmd.Flags().StringVarP(&addTags, "tags", "t", "", "comma-separated tags")
+	addCmd.Flags().StringVar(&addTOTP, "totp", "", "TOTP secret as an otpauth:// URI or base32 key")
+	addCmd.Flags().StringArrayVar(&addFields, "field", nil, "custom field as name[:type]=value, type is text, hidden, url, email or date (repeatable)")
+	addCmd.Flags().StringArrayVar(&addFiles, "field-file", nil, "custom field read from a file as name[:type]=path (repeatable)")
+	addCmd.Flags().StringVar(&addType, "type", "login", "entry type: login, note, card, identity, ssh-key, database, api or wifi")
+	addCmd.Flags().StringVar(&addRotation, "rotation", "", "require a password change every N days")
+	addCmd.Flags().StringVar(&addExpires, "expires", "", "expiry date as YYYY-MM-DD")
 }
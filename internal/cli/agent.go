@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/agent"
+	"github.com/imerr0rlog/CipherHub/internal/proc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentForeground bool
+	agentStop       bool
+	agentStatus     bool
+	agentTimeout    time.Duration
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run the background agent that caches the unlocked vault key",
+	Long: `Run the background agent that caches the unlocked vault key.
+
+While the agent is running, the first command that asks for the master
+password hands the derived key to the agent; later commands use the cached
+key instead of prompting again. The key is kept in locked memory and is
+wiped after agent_timeout seconds of inactivity (default 900) or by
+'cipherhub lock'.
+
+The agent listens on a Unix socket in a directory only the current user can
+access ($XDG_RUNTIME_DIR/cipherhub, or set CIPHERHUB_AGENT_SOCK).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := agent.NewClient("")
+
+		switch {
+		case agentStop:
+			if err := client.Stop(); err != nil {
+				return err
+			}
+			fmt.Println("✓ Agent stopped")
+			return nil
+
+		case agentStatus:
+			status, err := client.Status()
+			if err != nil {
+				return err
+			}
+			return render(newAgentStatusOutput(status), func() error {
+				fmt.Printf("Agent running (pid %d), idle timeout %s\n", status.PID, status.IdleTimeout)
+				if status.Vaults == 0 {
+					fmt.Println("No vault unlocked")
+				} else {
+					fmt.Printf("%d vault(s) unlocked, locks at %s\n", status.Vaults, status.LockAt.Format("15:04:05"))
+				}
+				return nil
+			})
+
+		case agentForeground:
+			return runAgent(idleTimeout(cmd))
+
+		default:
+			if client.Running() {
+				fmt.Println("Agent is already running")
+				return nil
+			}
+			if err := startAgent(idleTimeout(cmd)); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Agent started (locks after %s of inactivity)\n", idleTimeout(cmd))
+			return nil
+		}
+	},
+}
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Wipe all cached keys from the agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := agent.NewClient("").Lock(); err != nil {
+			if errors.Is(err, agent.ErrNotRunning) {
+				fmt.Println("Agent is not running, nothing to lock")
+				return nil
+			}
+			return err
+		}
+		fmt.Println("✓ Vault locked")
+		return nil
+	},
+}
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the vault and cache its key in the agent",
+	Long: `Unlock the vault and cache its key in the agent.
+
+Starts the agent if it is not running yet.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := getVaultManager()
+		if err != nil {
+			return err
+		}
+
+		masterPassword, err := readMasterPassword("Enter master password: ")
+		if err != nil {
+			return err
+		}
+		if err := mgr.Open(masterPassword); err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		client := agent.NewClient("")
+		if !client.Running() {
+			if err := startAgent(cfg.AgentIdleTimeout()); err != nil {
+				return err
+			}
+		}
+		if err := cacheKey(client, mgr); err != nil {
+			return err
+		}
+
+		status, err := client.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Vault unlocked (locks after %s of inactivity)\n", status.IdleTimeout)
+		return nil
+	},
+}
+
+// idleTimeout 返回 agent 的空闲超时时间，命令行参数优先于配置
+func idleTimeout(cmd *cobra.Command) time.Duration {
+	if cmd.Flags().Changed("timeout") {
+		return agentTimeout
+	}
+	return cfg.AgentIdleTimeout()
+}
+
+// startAgent 在后台启动 agent 并等待其开始监听
+func startAgent(timeout time.Duration) error {
+	args := []string{"agent", "--foreground", "--timeout", timeout.String()}
+	if cfgPath != "" {
+		args = append(args, "--config", cfgPath)
+	}
+	if err := proc.StartDetached(nil, args...); err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+	if err := agent.NewClient("").WaitRunning(3 * time.Second); err != nil {
+		return fmt.Errorf("agent did not start: %w", err)
+	}
+	return nil
+}
+
+// runAgent 在前台运行 agent，直到收到 stop 请求或终止信号
+func runAgent(timeout time.Duration) error {
+	path := agent.SocketPath()
+	l, err := agent.Listen(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	server := agent.NewServer(timeout)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		server.Stop()
+	}()
+
+	return server.Serve(l)
+}
+
+func init() {
+	agentCmd.Flags().BoolVar(&agentForeground, "foreground", false, "run the agent in the foreground")
+	agentCmd.Flags().BoolVar(&agentStop, "stop", false, "stop the running agent")
+	agentCmd.Flags().BoolVar(&agentStatus, "status", false, "show the agent status")
+	agentCmd.Flags().DurationVar(&agentTimeout, "timeout", 0, "idle time before the agent wipes its keys (default from agent_timeout)")
+}
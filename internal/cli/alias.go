@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage short names for entries",
+	Long: `Manage aliases: short names that can be used instead of an entry's
+path in every command.
+
+Every command that takes an entry accepts, in this order of precedence,
+its full path, its ID, one of its aliases, or a prefix of its ID (at least
+4 characters, shown by 'cipherhub get'). When a name matches more than one
+entry, for example after merging vaults from two devices, the command fails
+and lists the candidates with their IDs so you can pick one.`,
+}
+
+var aliasAddCmd = &cobra.Command{
+	Use:               "add <name> <alias>",
+	Short:             "Add an alias to an entry",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		entry, err := mgr.AddAlias(args[0], args[1])
+		if err != nil {
+			return fmt.Errorf("failed to add alias: %w", err)
+		}
+		return render(newEntryOutput(entry), func() error {
+			fmt.Printf("✓ '%s' is now an alias of '%s'\n", args[1], entry.Path())
+			return nil
+		})
+	},
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:     "rm <alias>",
+	Aliases: []string{"remove"},
+	Short:   "Remove an alias",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		entry, err := mgr.RemoveAlias(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to remove alias: %w", err)
+		}
+		return render(newEntryOutput(entry), func() error {
+			fmt.Printf("✓ Alias '%s' removed from '%s'\n", args[0], entry.Path())
+			return nil
+		})
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List all aliases",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		aliases, err := mgr.ListAliases()
+		if err != nil {
+			return err
+		}
+		out := make(aliasList, 0, len(aliases))
+		for alias, entry := range aliases {
+			out = append(out, aliasOutput{Alias: alias, ID: entry.ID, Path: entry.Path()})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Alias < out[j].Alias })
+
+		return render(out, func() error {
+			if len(out) == 0 {
+				fmt.Println("No aliases")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ALIAS\tENTRY")
+			for _, o := range out {
+				fmt.Fprintf(w, "%s\t%s\n", o.Alias, o.Path)
+			}
+			w.Flush()
+			return nil
+		})
+	},
+}
+
+func init() {
+	aliasCmd.AddCommand(aliasAddCmd, aliasRemoveCmd, aliasListCmd)
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/imerr0rlog/CipherHub/internal/vault"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <name>",
+	Short: "Hide an entry from list without deleting it",
+	Long: `Archive an entry. Archived entries are hidden from 'cipherhub list'
+(use --archived or --all to see them) and 'cipherhub due', but can still
+be used by name in every other command. Archiving does not change the
+entry's UPDATED date.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE:              setEntryFlag((*vault.Manager).SetArchived, true, "archived"),
+}
+
+var unarchiveCmd = &cobra.Command{
+	Use:               "unarchive <name>",
+	Short:             "Show an archived entry in list again",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE:              setEntryFlag((*vault.Manager).SetArchived, false, "unarchived"),
+}
+
+var favoriteCmd = &cobra.Command{
+	Use:   "favorite <name>",
+	Short: "Mark an entry as a favorite",
+	Long: `Mark an entry as a favorite. Favorites are listed first by
+'cipherhub list' and marked with ★.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE:              setEntryFlag((*vault.Manager).SetFavorite, true, "added to favorites"),
+}
+
+var unfavoriteCmd = &cobra.Command{
+	Use:               "unfavorite <name>",
+	Short:             "Remove an entry from the favorites",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE:              setEntryFlag((*vault.Manager).SetFavorite, false, "removed from favorites"),
+}
+
+// setEntryFlag 返回调用 set 设置条目标记的命令实现，done 描述设置后的状态
+func setEntryFlag(set func(m *vault.Manager, name string, value bool) (*types.Entry, error), value bool, done string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		entry, err := set(mgr, args[0], value)
+		if err != nil {
+			return fmt.Errorf("failed to update entry: %w", err)
+		}
+		return render(newEntryOutput(entry), func() error {
+			fmt.Printf("✓ Entry '%s' %s\n", entry.Path(), done)
+			return nil
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	attachName   string
+	attachOutput string
+	attachForce  bool
+)
+
+var attachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Manage encrypted file attachments of an entry",
+	Long: `Manage file attachments such as TLS private keys, recovery codes or
+license files.
+
+Attachments are encrypted with the vault key in 1 MiB chunks and stored as
+separate blobs in an 'attachments' directory next to the vault (locally or
+on the WebDAV server), so the vault file stays small and files larger than
+memory can be attached. Only the file name and size are kept in the vault.`,
+}
+
+var attachAddCmd = &cobra.Command{
+	Use:   "add <name> <file>",
+	Short: "Attach a file to an entry",
+	Long: `Encrypt a file and attach it to an entry.
+
+The attachment is named after the file unless --name is given. Use '-' as
+the file to read from standard input (--name is then required).`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeAttachFile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, path := args[0], args[1]
+
+		filename := attachName
+		if filename == "" {
+			if path == "-" {
+				return errors.New("--name is required when reading from standard input")
+			}
+			filename = filepath.Base(path)
+		}
+
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open file: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		att, err := mgr.AddAttachment(name, filename, r)
+		if err != nil {
+			return fmt.Errorf("failed to add attachment: %w", err)
+		}
+
+		return render(newAttachmentOutput(att), func() error {
+			fmt.Printf("✓ Attached '%s' (%s) to '%s'\n", att.Name, formatSize(att.Size), name)
+			return nil
+		})
+	},
+}
+
+var attachGetCmd = &cobra.Command{
+	Use:   "get <name> <attachment>",
+	Short: "Decrypt an attachment to a file",
+	Long: `Decrypt an attachment and save it in the current directory under its
+own name, or to the path given with --output-file ('-' writes to
+standard output).
+
+The file is created with mode 0600. Existing files are not overwritten
+unless --force is given. Every chunk is authenticated while decrypting;
+if the attachment was modified or truncated, nothing is written. With
+'-' the whole attachment is decrypted in memory before it is written to
+standard output.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, filename := args[0], args[1]
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		if attachOutput == "-" {
+			// 完整解密并验证后才写到标准输出，避免输出被篡改附件的部分内容
+			var buf bytes.Buffer
+			if _, err := mgr.ReadAttachment(name, filename, &buf); err != nil {
+				return err
+			}
+			_, err := buf.WriteTo(os.Stdout)
+			return err
+		}
+
+		path := attachOutput
+		if path == "" {
+			path = filename
+		}
+		if !attachForce {
+			if _, err := os.Lstat(path); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite", path)
+			}
+		}
+
+		// 先写入同目录下的临时文件，完整解密并验证后再重命名，失败时不留下部分内容
+		tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.part")
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+
+		att, err := mgr.ReadAttachment(name, filename, tmp)
+		if cerr := tmp.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to write file: %w", cerr)
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+
+		return render(newAttachmentOutput(att), func() error {
+			fmt.Printf("✓ Saved '%s' (%s) to %s\n", att.Name, formatSize(att.Size), path)
+			return nil
+		})
+	},
+}
+
+var attachListCmd = &cobra.Command{
+	Use:               "ls <name>",
+	Aliases:           []string{"list"},
+	Short:             "List the attachments of an entry",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		entry, err := mgr.GetEntry(args[0])
+		if err != nil {
+			return err
+		}
+
+		return render(attachmentList(newAttachmentOutputs(entry.Attachments)), func() error {
+			if len(entry.Attachments) == 0 {
+				fmt.Println("No attachments.")
+				return nil
+			}
+			printAttachments(entry.Attachments)
+			return nil
+		})
+	},
+}
+
+var attachRemoveCmd = &cobra.Command{
+	Use:     "rm <name> <attachment>",
+	Aliases: []string{"remove"},
+	Short:   "Remove an attachment from an entry",
+	Long: `Remove an attachment from an entry and delete its encrypted blobs.
+
+This action is irreversible. Use --force to skip confirmation.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, filename := args[0], args[1]
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		if !attachForce {
+			statusf("Are you sure you want to remove '%s' from '%s'? [y/N]: ", filename, name)
+			response, _ := readLine()
+			response = strings.TrimSpace(strings.ToLower(response))
+
+			if response != "y" && response != "yes" {
+				statusf("Cancelled\n")
+				return nil
+			}
+		}
+
+		if err := mgr.RemoveAttachment(name, filename); err != nil {
+			return fmt.Errorf("failed to remove attachment: %w", err)
+		}
+
+		return render(valueOutput{"removed": filename}, func() error {
+			fmt.Printf("✓ Attachment '%s' removed from '%s'\n", filename, name)
+			return nil
+		})
+	},
+}
+
+// completeAttachFile 补全 attach add 的参数：第一个是条目名称，第二个是文件
+func completeAttachFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 1 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return completeEntryNames(cmd, args, toComplete)
+}
+
+// printAttachments 以表格形式输出附件列表
+func printAttachments(atts []types.Attachment) {
+	width := 0
+	for _, a := range atts {
+		width = max(width, len(a.Name))
+	}
+	for _, a := range atts {
+		fmt.Printf("  %-*s  %9s  %s\n", width, a.Name, formatSize(a.Size), a.CreatedAt.Format("2006-01-02 15:04"))
+	}
+}
+
+// formatSize 以 B、KiB、MiB 或 GiB 为单位格式化字节数
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, s := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+func init() {
+	attachAddCmd.Flags().StringVar(&attachName, "name", "", "attachment name (default: the file name)")
+	attachGetCmd.Flags().StringVarP(&attachOutput, "output-file", "o", "", "write to this path instead ('-' for standard output)")
+	attachGetCmd.Flags().BoolVarP(&attachForce, "force", "f", false, "overwrite an existing file")
+	attachRemoveCmd.Flags().BoolVarP(&attachForce, "force", "f", false, "skip confirmation")
+
+	attachCmd.AddCommand(attachAddCmd, attachGetCmd, attachListCmd, attachRemoveCmd)
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/agent"
+	"github.com/imerr0rlog/CipherHub/internal/storage"
+	"github.com/imerr0rlog/CipherHub/internal/vault"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// nameIndexFile 是条目名称索引的文件名，与配置文件位于同一目录
+const nameIndexFile = "completion-index.json"
+
+// completionTimeout 限制补全时读取密码库的时间，避免远程存储拖慢 shell
+const completionTimeout = 3 * time.Second
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a completion script for the given shell.
+
+Entry names for get, copy, update, edit and delete are completed dynamically.
+Names are read from the vault when the agent holds its key, so no
+password is ever prompted for during completion. When the agent is not
+running or is locked, names come from a local name index if you enabled
+it with 'cipherhub config --completion-index=true'. The index contains
+only entry names, never secrets, and is removed again when disabled.
+
+Bash:
+  source <(cipherhub completion bash)
+  # or permanently:
+  cipherhub completion bash > /etc/bash_completion.d/cipherhub
+
+Zsh:
+  cipherhub completion zsh > "${fpath[1]}/_cipherhub"
+
+Fish:
+  cipherhub completion fish > ~/.config/fish/completions/cipherhub.fish
+
+PowerShell:
+  cipherhub completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		default:
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		}
+	},
+}
+
+// completeEntryNames 补全第一个位置参数的条目名称
+//
+// 补全过程中不会提示输入主密码：优先通过 agent 中缓存的密钥读取密码库，
+// 否则使用用户启用的本地名称索引，两者都不可用时不提供补全。
+func completeEntryNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// 补全时不会执行 PersistentPreRunE，需要自行加载配置
+	config, path, err := storage.LoadOrCreateConfigWithPath(flagConfigPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if flagVaultPath != "" {
+		config.VaultPath = flagVaultPath
+	}
+
+	names, err := namesFromAgent(config)
+	if err != nil && config.CompletionIndex {
+		names, err = readNameIndex(config, path)
+	}
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) {
+			matches = append(matches, name)
+		}
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// namesFromAgent 使用 agent 中缓存的密钥打开密码库并返回所有条目名称
+func namesFromAgent(config *types.Config) ([]string, error) {
+	client := agent.NewClient("")
+	if !client.Running() {
+		return nil, errors.New("agent is not running")
+	}
+
+	st, err := storage.NewStorage(config)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	mgr := vault.NewManager(st)
+	mgr.SetContext(ctx)
+	if err := mgr.OpenWithKey(client.Key); err != nil {
+		return nil, err
+	}
+	defer mgr.Close()
+	return entryNames(mgr)
+}
+
+// entryNames 返回已打开密码库中按字典序排序的条目路径和别名
+func entryNames(mgr *vault.Manager) ([]string, error) {
+	entries, err := mgr.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Path())
+		names = append(names, e.Aliases...)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// nameIndex 是保存在本地的条目名称索引，只包含名称，不包含任何秘密
+type nameIndex struct {
+	Vault string   `json:"vault"`
+	Names []string `json:"names"`
+}
+
+// nameIndexPath 返回配置文件 configPath 对应的名称索引路径
+func nameIndexPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), nameIndexFile)
+}
+
+// vaultLocation 返回标识配置中密码库位置的字符串，用于避免使用其他密码库的索引
+func vaultLocation(config *types.Config) string {
+	if config.DefaultStorage == types.StorageTypeWebDAV && config.WebDAV != nil {
+		return config.WebDAV.URL + config.WebDAV.RemotePath
+	}
+	if abs, err := filepath.Abs(config.VaultPath); err == nil {
+		return abs
+	}
+	return config.VaultPath
+}
+
+// readNameIndex 读取名称索引，索引属于其他密码库时返回错误
+func readNameIndex(config *types.Config, configPath string) ([]string, error) {
+	data, err := os.ReadFile(nameIndexPath(configPath))
+	if err != nil {
+		return nil, err
+	}
+	var index nameIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	if index.Vault != vaultLocation(config) {
+		return nil, fmt.Errorf("name index belongs to %s", index.Vault)
+	}
+	return index.Names, nil
+}
+
+// writeNameIndex 将已打开密码库的条目名称写入索引文件
+func writeNameIndex(mgr *vault.Manager) error {
+	names, err := entryNames(mgr)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(&nameIndex{Vault: vaultLocation(cfg), Names: names}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(nameIndexPath(cfgPath), data)
+}
+
+// trackNameIndex 在启用名称索引时刷新索引，并在每次保存密码库后更新
+//
+// 索引只用于补全，写入失败不影响当前命令。
+func trackNameIndex(mgr *vault.Manager) {
+	if cfg == nil || !cfg.CompletionIndex {
+		return
+	}
+	writeNameIndex(mgr)
+	mgr.SetSaveHook(func() { writeNameIndex(mgr) })
+}
+
+// removeNameIndex 删除名称索引文件
+func removeNameIndex() error {
+	err := os.Remove(nameIndexPath(cfgPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -20,6 +20,19 @@ var (
 	configWebDAVPath       string
 	configWebDAVConfigPath string
 	configSetLocal         bool
+	configOfflineCache     bool
+	configWebDAVTimeout    int
+	configWebDAVRetries    int
+	configWebDAVToken      string
+	configWebDAVCAFile     string
+	configWebDAVClientCert string
+	configWebDAVClientKey  string
+	configWebDAVPins       []string
+	configWebDAVProxy      string
+	configClipboardTimeout int
+	configPasswordHistory  int
+	configTrashDays        int
+	configCompletionIndex  bool
 	configShow             bool
 )
 
@@ -31,14 +44,20 @@ var configCmd = &cobra.Command{
 You can configure WebDAV for cloud sync, change the default storage,
 or view current settings.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out, err := newConfigOutput(cfgPath, cfg)
+		if err != nil {
+			return err
+		}
 		if configShow {
-			data, err := json.MarshalIndent(cfg, "", "  ")
-			if err != nil {
-				return err
-			}
-			fmt.Printf("Configuration file: %s\n\n", cfgPath)
-			fmt.Println(string(data))
-			return nil
+			return render(out, func() error {
+				data, err := json.MarshalIndent(out.Config, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Configuration file: %s\n\n", cfgPath)
+				fmt.Println(string(data))
+				return nil
+			})
 		}
 
 		changed := false
@@ -46,7 +65,7 @@ or view current settings.`,
 		if configSetLocal {
 			cfg.DefaultStorage = types.StorageTypeLocal
 			changed = true
-			fmt.Println("✓ Default storage set to local")
+			statusf("✓ Default storage set to local\n")
 		}
 
 		if configWebDAVURL != "" {
@@ -55,7 +74,7 @@ or view current settings.`,
 			}
 			cfg.WebDAV.URL = configWebDAVURL
 			changed = true
-			fmt.Printf("✓ WebDAV URL set to %s\n", configWebDAVURL)
+			statusf("✓ WebDAV URL set to %s\n", configWebDAVURL)
 		}
 
 		if configWebDAVUser != "" {
@@ -64,7 +83,7 @@ or view current settings.`,
 			}
 			cfg.WebDAV.Username = configWebDAVUser
 			changed = true
-			fmt.Printf("✓ WebDAV username set to %s\n", configWebDAVUser)
+			statusf("✓ WebDAV username set to %s\n", configWebDAVUser)
 		}
 
 		if configWebDAVPassword != "" {
@@ -73,7 +92,7 @@ or view current settings.`,
 			}
 			cfg.WebDAV.Password = configWebDAVPassword
 			changed = true
-			fmt.Println("✓ WebDAV password updated")
+			statusf("✓ WebDAV password updated\n")
 		}
 
 		if configWebDAVPath != "" {
@@ -82,7 +101,7 @@ or view current settings.`,
 			}
 			cfg.WebDAV.RemotePath = configWebDAVPath
 			changed = true
-			fmt.Printf("✓ WebDAV vault path set to %s\n", configWebDAVPath)
+			statusf("✓ WebDAV vault path set to %s\n", configWebDAVPath)
 		}
 
 		if configWebDAVConfigPath != "" {
@@ -91,11 +110,149 @@ or view current settings.`,
 			}
 			cfg.WebDAV.ConfigRemotePath = configWebDAVConfigPath
 			changed = true
-			fmt.Printf("✓ WebDAV config path set to %s\n", configWebDAVConfigPath)
+			statusf("✓ WebDAV config path set to %s\n", configWebDAVConfigPath)
+		}
+
+		if cmd.Flags().Changed("webdav-timeout") {
+			if configWebDAVTimeout < 0 {
+				return fmt.Errorf("timeout must not be negative")
+			}
+			if cfg.WebDAV == nil {
+				cfg.WebDAV = &types.WebDAVConfig{}
+			}
+			cfg.WebDAV.Timeout = configWebDAVTimeout
+			changed = true
+			statusf("✓ WebDAV timeout set to %s\n", cfg.WebDAV.TimeoutDuration())
+		}
+
+		if cmd.Flags().Changed("webdav-retries") {
+			if configWebDAVRetries < 0 {
+				return fmt.Errorf("retries must not be negative")
+			}
+			if cfg.WebDAV == nil {
+				cfg.WebDAV = &types.WebDAVConfig{}
+			}
+			cfg.WebDAV.Retries = configWebDAVRetries
+			changed = true
+			statusf("✓ WebDAV retries set to %d\n", configWebDAVRetries)
+		}
+
+		if configWebDAVToken != "" {
+			if cfg.WebDAV == nil {
+				cfg.WebDAV = &types.WebDAVConfig{}
+			}
+			cfg.WebDAV.Token = configWebDAVToken
+			changed = true
+			statusf("✓ WebDAV bearer token updated\n")
+		}
+
+		if cmd.Flags().Changed("webdav-ca-file") {
+			if cfg.WebDAV == nil {
+				cfg.WebDAV = &types.WebDAVConfig{}
+			}
+			cfg.WebDAV.CAFile = configWebDAVCAFile
+			changed = true
+			statusf("✓ WebDAV CA file set to %q\n", configWebDAVCAFile)
+		}
+
+		if cmd.Flags().Changed("webdav-client-cert") || cmd.Flags().Changed("webdav-client-key") {
+			if cfg.WebDAV == nil {
+				cfg.WebDAV = &types.WebDAVConfig{}
+			}
+			if cmd.Flags().Changed("webdav-client-cert") {
+				cfg.WebDAV.ClientCertFile = configWebDAVClientCert
+			}
+			if cmd.Flags().Changed("webdav-client-key") {
+				cfg.WebDAV.ClientKeyFile = configWebDAVClientKey
+			}
+			changed = true
+			statusf("✓ WebDAV client certificate updated\n")
+		}
+
+		if cmd.Flags().Changed("webdav-pin") {
+			if _, err := storage.ParsePinnedPublicKeys(configWebDAVPins); err != nil {
+				return err
+			}
+			if cfg.WebDAV == nil {
+				cfg.WebDAV = &types.WebDAVConfig{}
+			}
+			cfg.WebDAV.PinnedPublicKeys = configWebDAVPins
+			changed = true
+			statusf("✓ WebDAV pinned public keys set (%d)\n", len(configWebDAVPins))
+		}
+
+		if cmd.Flags().Changed("webdav-proxy") {
+			if cfg.WebDAV == nil {
+				cfg.WebDAV = &types.WebDAVConfig{}
+			}
+			cfg.WebDAV.Proxy = configWebDAVProxy
+			changed = true
+			statusf("✓ WebDAV proxy set to %q\n", configWebDAVProxy)
+		}
+
+		if cmd.Flags().Changed("clipboard-timeout") {
+			if configClipboardTimeout < 0 {
+				return fmt.Errorf("clipboard timeout must not be negative")
+			}
+			cfg.ClipboardTimeout = configClipboardTimeout
+			changed = true
+			statusf("✓ Clipboard timeout set to %ds\n", configClipboardTimeout)
+		}
+
+		if cmd.Flags().Changed("password-history") {
+			if configPasswordHistory < 0 {
+				return fmt.Errorf("password history must not be negative")
+			}
+			cfg.PasswordHistory = &configPasswordHistory
+			changed = true
+			statusf("✓ Password history set to %d version(s) per entry\n", configPasswordHistory)
+		}
+
+		if cmd.Flags().Changed("trash-days") {
+			if configTrashDays < 0 {
+				return fmt.Errorf("trash days must not be negative")
+			}
+			cfg.TrashDays = &configTrashDays
+			changed = true
+			if configTrashDays == 0 {
+				statusf("✓ Trash disabled, deleted entries are removed permanently (entries already in the trash are kept until 'cipherhub trash empty')\n")
+			} else {
+				statusf("✓ Deleted entries are kept in the trash for %d day(s)\n", configTrashDays)
+			}
+		}
+
+		if cmd.Flags().Changed("completion-index") {
+			cfg.CompletionIndex = configCompletionIndex
+			changed = true
+			if configCompletionIndex {
+				statusf("✓ Entry name index for shell completion enabled (written the next time the vault is unlocked)\n")
+			} else {
+				if err := removeNameIndex(); err != nil {
+					return fmt.Errorf("failed to remove name index: %w", err)
+				}
+				statusf("✓ Entry name index for shell completion disabled and removed\n")
+			}
+		}
+
+		if cmd.Flags().Changed("offline-cache") {
+			if cfg.OfflineCache == nil {
+				cfg.OfflineCache = &types.OfflineCacheConfig{}
+			}
+			cfg.OfflineCache.Enabled = configOfflineCache
+			changed = true
+			if configOfflineCache {
+				statusf("✓ Offline cache for remote vault enabled\n")
+			} else {
+				statusf("✓ Offline cache for remote vault disabled\n")
+			}
+		}
+
+		if !changed && machineOutput() {
+			return render(out, nil)
 		}
 
 		if !changed {
-			data, err := json.MarshalIndent(cfg, "", "  ")
+			data, err := json.MarshalIndent(out.Config, "", "  ")
 			if err != nil {
 				return err
 			}
@@ -108,16 +265,42 @@ or view current settings.`,
 			fmt.Println("  --webdav-pass PASS       Set WebDAV password")
 			fmt.Println("  --webdav-path PATH       Set remote vault path")
 			fmt.Println("  --webdav-config-path PATH Set remote config path")
+			fmt.Println("  --webdav-timeout SEC     Set per-request WebDAV timeout")
+			fmt.Println("  --webdav-retries N       Retry failed WebDAV requests N times")
+			fmt.Println("  --webdav-token TOKEN     Use a bearer token instead of username/password")
+			fmt.Println("  --webdav-ca-file FILE    Trust an additional CA certificate (PEM)")
+			fmt.Println("  --webdav-client-cert FILE Client certificate for mutual TLS (PEM)")
+			fmt.Println("  --webdav-client-key FILE Client private key for mutual TLS (PEM)")
+			fmt.Println("  --webdav-pin sha256/HASH Pin the server public key (repeatable)")
+			fmt.Println("  --webdav-proxy URL       Proxy URL, or \"direct\" to bypass proxies")
+			fmt.Println("  --clipboard-timeout SEC  Clear copied secrets after SEC seconds (0 = never)")
+			fmt.Println("  --password-history N     Keep N previous passwords per entry (0 = none)")
+			fmt.Println("  --trash-days N           Keep deleted entries in the trash for N days (0 = no trash)")
 			fmt.Println("  --local                  Set local as default storage")
+			fmt.Println("  --offline-cache=BOOL     Keep a local copy of the remote vault")
+			fmt.Println("  --completion-index=BOOL  Keep a local index of entry names for shell completion")
 			fmt.Println("  --show                   Show current configuration")
 			return nil
 		}
 
+		// 在保存前检查证书、代理等设置能否正常加载
+		if cfg.WebDAV != nil {
+			if _, err := storage.NewWebDAVStorage(cfg.WebDAV); err != nil {
+				return err
+			}
+		}
+
 		if err := storage.SaveConfig(cfgPath, cfg); err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
 		}
 
-		fmt.Printf("\nConfiguration saved to %s\n", cfgPath)
+		statusf("\nConfiguration saved to %s\n", cfgPath)
+		if machineOutput() {
+			if out, err = newConfigOutput(cfgPath, cfg); err != nil {
+				return err
+			}
+			return render(out, nil)
+		}
 		return nil
 	},
 }
@@ -128,6 +311,19 @@ func init() {
 	configCmd.Flags().StringVar(&configWebDAVPassword, "webdav-pass", "", "WebDAV password")
 	configCmd.Flags().StringVar(&configWebDAVPath, "webdav-path", "", "remote vault path on WebDAV")
 	configCmd.Flags().StringVar(&configWebDAVConfigPath, "webdav-config-path", "", "remote config path on WebDAV")
+	configCmd.Flags().IntVar(&configWebDAVTimeout, "webdav-timeout", 0, "WebDAV request timeout in seconds (0 = default 30)")
+	configCmd.Flags().IntVar(&configWebDAVRetries, "webdav-retries", 0, "number of retries for failed WebDAV requests")
+	configCmd.Flags().StringVar(&configWebDAVToken, "webdav-token", "", "WebDAV bearer token (replaces username/password)")
+	configCmd.Flags().StringVar(&configWebDAVCAFile, "webdav-ca-file", "", "additional CA certificate file (PEM) for WebDAV")
+	configCmd.Flags().StringVar(&configWebDAVClientCert, "webdav-client-cert", "", "client certificate file (PEM) for mutual TLS")
+	configCmd.Flags().StringVar(&configWebDAVClientKey, "webdav-client-key", "", "client private key file (PEM) for mutual TLS")
+	configCmd.Flags().StringSliceVar(&configWebDAVPins, "webdav-pin", nil, "pinned server public key as sha256/<base64> (repeatable, empty to clear)")
+	configCmd.Flags().StringVar(&configWebDAVProxy, "webdav-proxy", "", "proxy URL for WebDAV, \"direct\" to bypass, empty for environment")
+	configCmd.Flags().IntVar(&configClipboardTimeout, "clipboard-timeout", 30, "seconds before copied secrets are cleared from the clipboard (0 = never)")
+	configCmd.Flags().IntVar(&configPasswordHistory, "password-history", types.DefaultPasswordHistory, "number of previous passwords kept per entry (0 = none)")
+	configCmd.Flags().IntVar(&configTrashDays, "trash-days", types.DefaultTrashDays, "days deleted entries are kept in the trash (0 = delete permanently)")
 	configCmd.Flags().BoolVar(&configSetLocal, "local", false, "set local as default storage")
+	configCmd.Flags().BoolVar(&configOfflineCache, "offline-cache", false, "keep a local copy of the remote vault for offline reads")
+	configCmd.Flags().BoolVar(&configCompletionIndex, "completion-index", false, "keep a local, unencrypted index of entry names for shell completion")
 	configCmd.Flags().BoolVarP(&configShow, "show", "s", false, "show current configuration")
 }
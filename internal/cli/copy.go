@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/clipboard"
+	"github.com/spf13/cobra"
+)
+
+var copyField string
+
+var copyCmd = &cobra.Command{
+	Use:   "copy <name>",
+	Short: "Copy a field of an entry to the clipboard",
+	Long: `Copy a field of an entry to the clipboard.
+
+The clipboard is cleared after clipboard_timeout seconds (default 30),
+but only if it still contains the copied value.
+
+Supported clipboards: wl-copy (Wayland), xclip or xsel (X11), pbcopy (macOS),
+clip (Windows) and OSC 52 terminal escapes for SSH sessions.
+Set CIPHERHUB_CLIPBOARD to force a specific backend.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		value, err := mgr.GetField(name, copyField)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return fmt.Errorf("field %s of '%s' is empty", copyField, name)
+		}
+
+		return copyToClipboard(copyField, value)
+	},
+}
+
+// clipboardClearCmd 是由 clipboard.ScheduleClear 启动的隐藏子命令
+var clipboardClearCmd = &cobra.Command{
+	Use:    clipboard.ClearCommand,
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	// 不需要加载配置
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		after, _ := cmd.Flags().GetDuration("after")
+		backend, _ := cmd.Flags().GetString("backend")
+		return clipboard.RunClear(os.Stdin, after, backend)
+	},
+}
+
+// copyToClipboard 复制到剪贴板，并按配置的超时时间安排自动清除
+func copyToClipboard(field, value string) error {
+	return copyToClipboardTo(statusWriter(), field, value)
+}
+
+// copyToClipboardTo 与 copyToClipboard 相同，但将提示信息写入 w
+func copyToClipboardTo(w io.Writer, field, value string) error {
+	timeout := time.Duration(cfg.ClipboardTimeout) * time.Second
+	if _, err := clipboard.Copy(value, timeout); err != nil {
+		return err
+	}
+
+	if timeout > 0 {
+		fmt.Fprintf(w, "✓ %s copied to clipboard (clears in %s)\n", fieldLabel(field), timeout)
+	} else {
+		fmt.Fprintf(w, "✓ %s copied to clipboard\n", fieldLabel(field))
+	}
+	return nil
+}
+
+// fieldLabel 返回字段的显示名称
+func fieldLabel(field string) string {
+	switch field {
+	case "username":
+		return "Username"
+	case "password":
+		return "Password"
+	case "url":
+		return "URL"
+	case "notes":
+		return "Notes"
+	case "totp":
+		return "TOTP code"
+	default:
+		return field
+	}
+}
+
+func init() {
+	copyCmd.Flags().StringVarP(&copyField, "field", "f", "password", "field to copy: username, password, url, notes, totp or a custom field")
+
+	clipboardClearCmd.Flags().Duration("after", 30*time.Second, "delay before clearing")
+	clipboardClearCmd.Flags().String("backend", "", "clipboard backend")
+}
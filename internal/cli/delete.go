@@ -5,9 +5,7 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -20,17 +18,17 @@ var deleteCmd = &cobra.Command{
 	Short: "Delete a password entry",
 	Long: `Delete a password entry from the vault.
 
-This action is irreversible. Use --force to skip confirmation.`,
-	Args: cobra.ExactArgs(1),
+The entry and its attachments are moved to the trash, where they can be
+restored with 'cipherhub trash restore' until they are deleted
+permanently after the configured number of days (see 'cipherhub trash').
+If the trash is disabled with 'cipherhub config --trash-days 0', this
+action is irreversible. Use --force to skip confirmation.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
-		masterPassword, err := promptPassword("Enter master password: ")
-		if err != nil {
-			return err
-		}
-
-		mgr, err := openVault(masterPassword)
+		mgr, err := unlockVault()
 		if err != nil {
 			return fmt.Errorf("failed to open vault: %w", err)
 		}
@@ -42,23 +40,28 @@ This action is irreversible. Use --force to skip confirmation.`,
 		}
 
 		if !deleteForce {
-			fmt.Printf("Are you sure you want to delete '%s' (%s)? [y/N]: ", entry.Name, entry.Username)
-			reader := bufio.NewReader(os.Stdin)
-			response, _ := reader.ReadString('\n')
+			statusf("Are you sure you want to delete '%s' (%s)? [y/N]: ", entry.Path(), entry.Username)
+			response, _ := readLine()
 			response = strings.TrimSpace(strings.ToLower(response))
 
 			if response != "y" && response != "yes" {
-				fmt.Println("Cancelled")
+				statusf("Cancelled\n")
 				return nil
 			}
 		}
 
-		if err := mgr.DeleteEntry(name); err != nil {
+		if err := mgr.DeleteEntry(entry.ID); err != nil {
 			return fmt.Errorf("failed to delete entry: %w", err)
 		}
 
-		fmt.Printf("✓ Entry '%s' deleted\n", name)
-		return nil
+		return render(newEntryOutput(entry), func() error {
+			if mgr.TrashRetention() > 0 {
+				fmt.Printf("✓ Entry '%s' moved to the trash (restore with 'cipherhub trash restore %s')\n", entry.Path(), entry.ShortID())
+				return nil
+			}
+			fmt.Printf("✓ Entry '%s' deleted\n", entry.Path())
+			return nil
+		})
 	},
 }
 
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var dueWithin int
+
+var dueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "List entries whose password is expired or due for rotation",
+	Long: `List the entries whose password has expired or must be rotated within
+the next --within days (default 14), soonest first.
+
+An entry is due when its rotation period has passed since the password
+was last changed, or when its expiry date is reached. Other changes to
+the entry do not reset the rotation period. Set the policy with
+'cipherhub add|update <name> --rotation 90 --expires 2025-12-31'.
+
+The exit status is meant for cron jobs and CI checks:
+  0  nothing is due
+  8  some entries are due within --within days, none has expired
+  9  at least one entry has expired`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dueWithin < 0 {
+			return fmt.Errorf("--within must not be negative")
+		}
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		entries, err := mgr.DueEntries(time.Duration(dueWithin) * 24 * time.Hour)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		out := make(dueList, 0, len(entries))
+		expired := false
+		for _, e := range entries {
+			due, _ := e.DueAt()
+			o := dueOutput{
+				ID:       e.ID,
+				Path:     e.Path(),
+				DueAt:    due,
+				DaysLeft: daysBetween(now, due),
+				Expired:  e.Expired(now),
+				Reason:   "rotation",
+			}
+			if e.ExpiresAt != nil && e.ExpiresAt.Equal(due) {
+				o.Reason = "expiry"
+			}
+			expired = expired || o.Expired
+			out = append(out, o)
+		}
+
+		err = render(out, func() error {
+			if len(out) == 0 {
+				fmt.Printf("No entries are due within %d days\n", dueWithin)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tDUE\tSTATUS\tPOLICY")
+			for i, o := range out {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", o.Path, o.DueAt.Format("2006-01-02"), dueStatus(o), duePolicy(entries[i], o.Reason))
+			}
+			w.Flush()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case expired:
+			return exitStatus(ExitExpired)
+		case len(out) > 0:
+			return exitStatus(ExitDueSoon)
+		}
+		return nil
+	},
+}
+
+// dueStatus 描述到期条目距到期还有多久
+func dueStatus(o dueOutput) string {
+	switch {
+	case o.Expired && o.DaysLeft == 0:
+		return "expired today"
+	case o.Expired:
+		return fmt.Sprintf("expired %s ago", pluralDays(-o.DaysLeft))
+	case o.DaysLeft == 0:
+		return "due today"
+	}
+	return fmt.Sprintf("due in %s", pluralDays(o.DaysLeft))
+}
+
+// duePolicy 描述使条目到期的策略
+func duePolicy(e *types.Entry, reason string) string {
+	if reason == "expiry" {
+		return "expires " + e.ExpiresAt.Format(types.DateLayout)
+	}
+	return fmt.Sprintf("rotate every %s (changed %s)", pluralDays(e.RotationDays), e.PasswordSetAt().Format(types.DateLayout))
+}
+
+// daysBetween 返回从 from 到 to 经过的日历天数（本地时间），to 在 from 之前时为负数
+func daysBetween(from, to time.Time) int {
+	day := func(t time.Time) time.Time {
+		y, m, d := t.Local().Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return int(day(to).Sub(day(from)).Hours() / 24)
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+func init() {
+	dueCmd.Flags().IntVar(&dueWithin, "within", 14, "also list entries due within this many days")
+}
@@ -0,0 +1,475 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/imerr0rlog/CipherHub/internal/totp"
+	"github.com/imerr0rlog/CipherHub/internal/vault"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Edit an entry in your editor",
+	Long: `Edit an entry in your editor.
+
+The entry is decrypted into a YAML document and opened with $VISUAL or
+$EDITOR (vi if neither is set). Save and close the editor to apply the
+changes; all fields can be changed, including the name, tags, aliases
+and custom fields, and a field is cleared by setting it to "". Leaving
+the file unchanged cancels.
+
+The file is written to a private directory on tmpfs (/dev/shm) where
+available, so the decrypted entry never touches the disk, and it is
+overwritten and removed as soon as the editor exits.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		orig, err := newEditDoc(mgr, name)
+		if err != nil {
+			return err
+		}
+
+		edited, err := editInEditor(orig, func(doc *editDoc) error { return doc.validate(mgr, name) })
+		if err != nil {
+			return err
+		}
+
+		updates := orig.diff(edited)
+		fieldsChanged := !equalFields(orig.Fields, edited.Fields)
+		if len(updates) == 0 && !fieldsChanged {
+			statusf("No changes made\n")
+			return nil
+		}
+
+		var fields []types.Field
+		if fieldsChanged {
+			fields = edited.customFields()
+		}
+		entry, err := mgr.EditEntry(name, updates, fields)
+		if err != nil {
+			return fmt.Errorf("failed to update entry: %w", err)
+		}
+
+		changed := make([]string, 0, len(updates)+1)
+		for field := range updates {
+			changed = append(changed, field)
+		}
+		if fieldsChanged {
+			changed = append(changed, "fields")
+		}
+		sort.Strings(changed)
+		return render(newEntryOutput(entry), func() error {
+			fmt.Printf("✓ Entry '%s' updated (%s)\n", entry.Path(), strings.Join(changed, ", "))
+			return nil
+		})
+	},
+}
+
+// editDoc 是编辑器中显示的条目，字段顺序即文档中的顺序
+type editDoc struct {
+	Name     string      `yaml:"name"`
+	Type     string      `yaml:"type"`
+	Username string      `yaml:"username"`
+	Password string      `yaml:"password"`
+	URL      string      `yaml:"url"`
+	Notes    string      `yaml:"notes"`
+	Tags     []string    `yaml:"tags"`
+	Aliases  []string    `yaml:"aliases"`
+	Rotation int         `yaml:"rotation_days"` // 密码轮换周期（天），0 表示不轮换
+	Expires  string      `yaml:"expires"`       // 过期日期 YYYY-MM-DD，空表示不过期
+	TOTP     string      `yaml:"totp"`          // otpauth:// URI 或 base32 密钥
+	Fields   []editField `yaml:"fields"`
+}
+
+// editField 是编辑器中显示的自定义字段
+type editField struct {
+	Name  string `yaml:"name"`
+	Type  string `yaml:"type"`
+	Value string `yaml:"value"`
+}
+
+// customFields 返回编辑后的自定义字段，类型为空时为 text
+func (d *editDoc) customFields() []types.Field {
+	fields := make([]types.Field, 0, len(d.Fields))
+	for _, f := range d.Fields {
+		t := types.FieldTypeText
+		if f.Type != "" {
+			// 已在 validate 中检查过
+			t, _ = types.ParseFieldType(f.Type)
+		}
+		fields = append(fields, types.Field{Name: f.Name, Type: t, Value: f.Value})
+	}
+	return fields
+}
+
+// equalFields 比较两组自定义字段，nil 与空列表视为相同
+func equalFields(a, b []editField) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newEditDoc 读取并解密条目
+func newEditDoc(mgr *vault.Manager, name string) (*editDoc, error) {
+	entry, err := mgr.GetEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	password, err := mgr.GetDecryptedPassword(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	notes, err := mgr.GetDecryptedNotes(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt notes: %w", err)
+	}
+	doc := &editDoc{
+		Name:     entry.Path(),
+		Type:     string(entry.Kind()),
+		Username: entry.Username,
+		Password: password,
+		URL:      entry.URL,
+		Notes:    notes,
+		Tags:     append([]string{}, entry.Tags...),
+		Aliases:  append([]string{}, entry.Aliases...),
+		Rotation: entry.RotationDays,
+	}
+	if entry.ExpiresAt != nil {
+		doc.Expires = entry.ExpiresAt.Format(types.DateLayout)
+	}
+	if entry.TOTP != "" {
+		config, err := mgr.GetTOTP(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+		}
+		doc.TOTP = config.URI()
+	}
+
+	fields, err := mgr.GetFields(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt fields: %w", err)
+	}
+	for _, f := range fields {
+		doc.Fields = append(doc.Fields, editField{Name: f.Name, Type: string(f.Type), Value: f.Value})
+	}
+	return doc, nil
+}
+
+// validate 检查编辑后的条目，name 为条目的原路径
+func (d *editDoc) validate(mgr *vault.Manager, name string) error {
+	if strings.TrimSpace(d.Name) == "" {
+		return errors.New("name must not be empty")
+	}
+	folder, base, err := types.ParseEntryPath(d.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := types.ParseEntryType(d.Type); err != nil {
+		return err
+	}
+	// 比较完整路径和别名（与 ID 前缀相同并不冲突），路径与别名相同时别名将无法使用
+	current, err := mgr.GetEntry(name)
+	if err != nil {
+		return err
+	}
+	entries, err := mgr.ListEntries()
+	if err != nil {
+		return err
+	}
+	path := types.JoinPath(folder, base)
+	for _, e := range entries {
+		if e.ID == current.ID {
+			continue
+		}
+		if e.Path() == path {
+			return fmt.Errorf("an entry named %q already exists", path)
+		}
+		if slices.Contains(e.Aliases, path) {
+			return fmt.Errorf("name %q is an alias of %s", path, e.Path())
+		}
+	}
+	if slices.Contains(d.Aliases, path) {
+		return fmt.Errorf("name %q is also listed in aliases", path)
+	}
+	for _, tag := range d.Tags {
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("tag %q must not contain a comma", tag)
+		}
+	}
+	for _, alias := range d.Aliases {
+		if strings.ContainsAny(alias, " \t,"+types.PathSeparator) {
+			return fmt.Errorf("alias %q must not contain spaces, commas or %q", alias, types.PathSeparator)
+		}
+	}
+	if d.Rotation < 0 {
+		return errors.New("rotation_days must not be negative")
+	}
+	if _, err := types.ParseExpiry(d.Expires); err != nil {
+		return err
+	}
+	if d.TOTP != "" {
+		if _, err := totp.Parse(d.TOTP); err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]bool, len(d.Fields))
+	for _, f := range d.Fields {
+		if f.Type != "" {
+			if _, err := types.ParseFieldType(f.Type); err != nil {
+				return fmt.Errorf("field %s: %w", f.Name, err)
+			}
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("duplicate field %q", f.Name)
+		}
+		seen[f.Name] = true
+	}
+	for _, f := range d.customFields() {
+		if err := types.ValidateField(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diff 返回从 d 到 edited 有变化的字段，键与 vault.Manager.UpdateEntry 一致
+func (d *editDoc) diff(edited *editDoc) map[string]string {
+	updates := make(map[string]string)
+	if edited.Name != d.Name {
+		updates["name"] = edited.Name
+	}
+	if edited.Type != d.Type {
+		updates["type"] = edited.Type
+	}
+	if edited.Username != d.Username {
+		updates["username"] = edited.Username
+	}
+	if edited.Password != d.Password {
+		updates["password"] = edited.Password
+	}
+	if edited.URL != d.URL {
+		updates["url"] = edited.URL
+	}
+	if edited.Notes != d.Notes {
+		updates["notes"] = edited.Notes
+	}
+	if edited.TOTP != d.TOTP {
+		updates["totp"] = edited.TOTP
+	}
+	tags := strings.Join(edited.Tags, ",")
+	if !reflect.DeepEqual(types.ParseTags(tags), types.ParseTags(strings.Join(d.Tags, ","))) {
+		updates["tags"] = tags
+	}
+	if aliases := strings.Join(edited.Aliases, ","); aliases != strings.Join(d.Aliases, ",") {
+		updates["aliases"] = aliases
+	}
+	if edited.Rotation != d.Rotation {
+		updates["rotation"] = strconv.Itoa(edited.Rotation)
+	}
+	if edited.Expires != d.Expires {
+		updates["expires"] = edited.Expires
+	}
+	return updates
+}
+
+// editHeader 是编辑文件开头的说明，以 # 开头的行在解析时被忽略
+const editHeader = `# Editing entry %q. Lines starting with '#' are ignored.
+# Save and close the editor to apply the changes, or leave the file
+# unchanged to cancel. Clear a field by setting it to "".
+# The name is the entry's path; change the folder part to move it.
+# Entry types: login, note, card, identity, ssh-key, database, api, wifi.
+# Custom field types: text, hidden, url, email, date (YYYY-MM-DD).
+`
+
+// editErrorPrefix 标记上一次校验失败的错误行，重新编辑前会被替换
+const editErrorPrefix = "# ERROR: "
+
+// editInEditor 在编辑器中编辑 doc，返回校验通过的结果
+//
+// 校验失败时，如果标准输入是终端则询问是否重新编辑（保留已做的修改），
+// 否则直接返回错误。
+func editInEditor(doc *editDoc, validate func(*editDoc) error) (*editDoc, error) {
+	body, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	content := append([]byte(fmt.Sprintf(editHeader, doc.Name)+"\n"), body...)
+	wipe(body)
+
+	dir, err := privateTempDir()
+	if err != nil {
+		return nil, err
+	}
+	defer wipeDir(dir)
+	path := filepath.Join(dir, "entry.yaml")
+
+	for {
+		err := os.WriteFile(path, content, 0600)
+		wipe(content)
+		if err != nil {
+			return nil, err
+		}
+		if err := runEditor(path); err != nil {
+			return nil, err
+		}
+		if content, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+
+		edited, err := parseEditDoc(content)
+		if err == nil {
+			err = validate(edited)
+		}
+		if err == nil {
+			wipe(content)
+			return edited, nil
+		}
+
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			wipe(content)
+			return nil, fmt.Errorf("invalid entry: %w", err)
+		}
+		statusf("Invalid entry: %v\n", err)
+		answer, perr := promptInput("Edit again? [Y/n]: ")
+		if perr != nil || strings.HasPrefix(strings.ToLower(answer), "n") {
+			wipe(content)
+			return nil, errors.New("edit cancelled, no changes made")
+		}
+		content = markEditError(content, err)
+	}
+}
+
+// parseEditDoc 解析编辑后的文件，不允许出现未知字段
+func parseEditDoc(content []byte) (*editDoc, error) {
+	if len(bytes.TrimSpace(stripComments(content))) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	var doc editDoc
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// stripComments 返回去掉注释行后的内容
+func stripComments(content []byte) []byte {
+	var out []byte
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			out = append(out, line...)
+		}
+	}
+	return out
+}
+
+// markEditError 将错误信息写在文件开头，替换上一次的错误行
+func markEditError(content []byte, err error) []byte {
+	var out bytes.Buffer
+	for _, line := range strings.Split(err.Error(), "\n") {
+		out.WriteString(editErrorPrefix + line + "\n")
+	}
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte(editErrorPrefix)) {
+			out.Write(line)
+		}
+	}
+	wipe(content)
+	return out.Bytes()
+}
+
+// runEditor 使用 $VISUAL 或 $EDITOR 打开文件，编辑器可以带参数（如 "code --wait"）
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		if editor == "" {
+			editor = "notepad"
+		}
+		cmd = exec.Command("cmd", "/C", editor+` "`+path+`"`)
+	} else {
+		if editor == "" {
+			editor = "vi"
+		}
+		cmd = exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
+
+// privateTempDir 创建只有当前用户可以访问的临时目录
+//
+// 优先使用基于内存的 /dev/shm，使解密后的条目不会写入磁盘；
+// 不可用时退回系统临时目录并给出警告。编辑器的交换文件也会写在该目录中。
+func privateTempDir() (string, error) {
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		if dir, err := os.MkdirTemp("/dev/shm", "cipherhub-edit-"); err == nil {
+			return dir, nil
+		}
+	}
+	warnf("no tmpfs available; the decrypted entry is written to %s while you edit it", os.TempDir())
+	return os.MkdirTemp("", "cipherhub-edit-")
+}
+
+// wipeDir 用零覆盖目录中的所有文件后删除整个目录
+func wipeDir(dir string) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			overwriteFile(path, info.Size())
+		}
+		return nil
+	})
+	os.RemoveAll(dir)
+}
+
+// overwriteFile 用零覆盖文件内容并同步到存储
+func overwriteFile(path string, size int64) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(make([]byte, size))
+	f.Sync()
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/imerr0rlog/CipherHub/internal/agent"
+	"github.com/imerr0rlog/CipherHub/internal/clipboard"
+	"github.com/imerr0rlog/CipherHub/internal/crypto"
+	"github.com/imerr0rlog/CipherHub/internal/query"
+	"github.com/imerr0rlog/CipherHub/internal/storage"
+	"github.com/imerr0rlog/CipherHub/internal/vault"
+)
+
+// 命令行程序的退出状态码
+const (
+	ExitGeneral      = 1   // 其他错误
+	ExitNotFound     = 3   // 密码库、条目或远程文件不存在
+	ExitBadPassword  = 4   // 主密码错误
+	ExitConflict     = 5   // 远程密码库已被其他设备修改
+	ExitConnectivity = 6   // 网络不可达或超时
+	ExitAccess       = 7   // 存储认证失败或权限不足
+	ExitDueSoon      = 8   // due: 有条目即将到期（没有已到期的条目）
+	ExitExpired      = 9   // due: 有条目已经到期
+	ExitCancelled    = 130 // 用户取消（Ctrl-C）
+)
+
+// errorClass 描述一类错误对应的退出码、类别名称和处理建议
+//
+// 类别名称用于 --output json|yaml|csv 的错误输出，属于稳定的接口，不应修改。
+type errorClass struct {
+	target error
+	code   int
+	kind   string
+	hint   string
+}
+
+// errorClasses 按优先级排列，使用 errors.Is 依次匹配
+var errorClasses = []errorClass{
+	{context.Canceled, ExitCancelled, "cancelled", ""},
+	{vault.ErrInvalidPassword, ExitBadPassword, "bad_password", "Check your master password and try again."},
+	{crypto.ErrDecryptionFailed, ExitBadPassword, "bad_password", "Check your master password and try again."},
+	{storage.ErrStorageConflict, ExitConflict, "conflict", "The remote vault was changed on another device. Run 'cipherhub sync --pull' and retry."},
+	{storage.ErrStorageUnauthorized, ExitAccess, "unauthorized", "Check the WebDAV credentials with 'cipherhub config --show'."},
+	{storage.ErrStoragePermission, ExitAccess, "permission", "Check the file permissions or WebDAV account access rights."},
+	{storage.ErrPinMismatch, ExitConnectivity, "pin_mismatch", "The server certificate does not match the pinned public key. If it was renewed, update it with 'cipherhub config --webdav-pin'."},
+	{storage.ErrStorageCertificate, ExitConnectivity, "certificate", "The server certificate could not be verified. Check the WebDAV URL, or trust a private CA with 'cipherhub config --webdav-ca-file'."},
+	{storage.ErrStorageTimeout, ExitConnectivity, "timeout", "The server did not respond in time. Increase it with 'cipherhub config --webdav-timeout <seconds>'."},
+	{context.DeadlineExceeded, ExitConnectivity, "timeout", "The server did not respond in time. Increase it with 'cipherhub config --webdav-timeout <seconds>'."},
+	{storage.ErrStorageConnection, ExitConnectivity, "connectivity", "Check your network connection and the WebDAV URL."},
+	{agent.ErrNotRunning, ExitGeneral, "agent_not_running", "Start it with 'cipherhub agent' or 'cipherhub unlock'."},
+	{agent.ErrInsecureSocket, ExitGeneral, "insecure_socket", "Remove the socket directory or set CIPHERHUB_AGENT_SOCK to a private location."},
+	{clipboard.ErrUnavailable, ExitGeneral, "clipboard_unavailable", "Install wl-clipboard, xclip or xsel, or set CIPHERHUB_CLIPBOARD=osc52 in SSH sessions."},
+	{vault.ErrEntryNotFound, ExitNotFound, "not_found", "Run 'cipherhub list' to see available entries."},
+	{vault.ErrAmbiguousEntry, ExitGeneral, "ambiguous", "Use the ID (or an ID prefix) of one of the entries listed above."},
+	{vault.ErrAliasNotFound, ExitNotFound, "not_found", "Run 'cipherhub alias ls' to see all aliases."},
+	{vault.ErrNoTOTP, ExitNotFound, "no_totp", "Attach a TOTP secret with 'cipherhub update <name> --totp <otpauth-uri>'."},
+	{vault.ErrFolderNotFound, ExitNotFound, "not_found", "Run 'cipherhub folder ls' to see available folders."},
+	{vault.ErrNoPasswordVersion, ExitNotFound, "not_found", "Run 'cipherhub history <name>' to see the previous passwords of an entry."},
+	{vault.ErrAttachmentNotFound, ExitNotFound, "not_found", "Run 'cipherhub attach ls <name>' to see the attachments of an entry."},
+	{vault.ErrAttachmentCorrupted, ExitGeneral, "corrupted", "The attachment blobs are missing or were modified. Remove it with 'cipherhub attach rm' and attach the file again."},
+	{storage.ErrNotEncrypted, ExitGeneral, "not_encrypted", "The vault file is not encrypted with the storage key and may have been replaced. Set the encryption option allow_plaintext to \"true\" only to migrate an existing unencrypted vault."},
+	{query.ErrSyntax, ExitGeneral, "invalid_query", "Run 'cipherhub list --help' for the search query syntax."},
+	{storage.ErrStorageNotFound, ExitNotFound, "not_found", "Run 'cipherhub init' to create a vault, or 'cipherhub sync --pull' to download one."},
+}
+
+// exitStatus 是不表示失败、只设置退出码的结果，例如 due 发现到期的条目
+//
+// Execute 遇到 exitStatus 时直接以该状态码退出，不输出错误信息。
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// classifyError 返回错误对应的类别
+//
+// 未能识别的错误返回退出码 ExitGeneral、类别 error 和空建议。
+func classifyError(err error) errorClass {
+	for _, c := range errorClasses {
+		if errors.Is(err, c.target) {
+			return c
+		}
+	}
+	return errorClass{code: ExitGeneral, kind: "error"}
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// readFieldFlags 解析 --field 参数，格式为 name[:type]=value 或 name[:type]
+//
+// 省略 =value 时交互式提示输入，hidden 类型的输入不回显，
+// 避免秘密出现在命令行历史中。allowEmpty 为 true 时 name= 表示删除该字段。
+// 未指定类型的字段使用 tmpl 中同名字段的类型（tmpl 可以为 nil）。
+func readFieldFlags(specs []string, allowEmpty bool, tmpl *types.EntryTemplate) ([]types.Field, error) {
+	fields := make([]types.Field, 0, len(specs))
+	for _, spec := range specs {
+		key, value, hasValue := strings.Cut(spec, "=")
+		f, err := parseFieldKey(key, tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --field %q: %w", spec, err)
+		}
+		f.Value = value
+
+		if !hasValue {
+			if f.Value, err = promptField(f.Name, f.Type); err != nil {
+				return nil, err
+			}
+		}
+		if f.Value == "" && !allowEmpty {
+			return nil, fmt.Errorf("field %s has no value", f.Name)
+		}
+		if err := validateFieldFlag(f); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// readFieldFileFlags 解析 --field-file 参数，格式为 name[:type]=path，
+// 适用于私钥等多行内容
+func readFieldFileFlags(specs []string, tmpl *types.EntryTemplate) ([]types.Field, error) {
+	fields := make([]types.Field, 0, len(specs))
+	for _, spec := range specs {
+		key, path, ok := strings.Cut(spec, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --field-file %q, expected name[:type]=path", spec)
+		}
+		f, err := parseFieldKey(key, tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --field-file %q: %w", spec, err)
+		}
+		if f.Value, err = readFieldFile(path); err != nil {
+			return nil, err
+		}
+		if err := validateFieldFlag(f); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// parseFieldKey 解析 name[:type]，未指定类型时使用 tmpl 中同名字段的类型
+func parseFieldKey(key string, tmpl *types.EntryTemplate) (types.Field, error) {
+	name, typ, hasType := strings.Cut(key, ":")
+	f := types.Field{Name: strings.TrimSpace(name)}
+	if hasType {
+		t, err := types.ParseFieldType(typ)
+		if err != nil {
+			return f, err
+		}
+		f.Type = t
+	} else if tmpl != nil {
+		if tf := tmpl.Field(f.Name); tf != nil {
+			f.Type = tf.Type
+		}
+	}
+	return f, nil
+}
+
+// validateFieldFlag 检查字段，未指定类型时按 text 检查
+func validateFieldFlag(f types.Field) error {
+	if f.Type == "" {
+		f.Type = types.FieldTypeText
+	}
+	return types.ValidateField(f)
+}
+
+// readFieldFile 读取字段值文件，去掉末尾的换行
+func readFieldFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read field file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// promptField 交互式读取字段值，hidden 类型不回显
+func promptField(label string, t types.FieldType) (string, error) {
+	if t == types.FieldTypeHidden {
+		return promptPassword(label + ": ")
+	}
+	return promptInput(label + ": ")
+}
+
+// promptTemplateFields 提示输入模板中尚未提供的字段
+//
+// 多行字段提示输入文件路径。可选字段留空时跳过，必填字段留空时返回错误。
+func promptTemplateFields(tmpl *types.EntryTemplate, given []types.Field) ([]types.Field, error) {
+	have := make(map[string]bool, len(given))
+	for _, f := range given {
+		have[f.Name] = true
+	}
+
+	var fields []types.Field
+	for _, tf := range tmpl.Fields {
+		if have[tf.Name] {
+			continue
+		}
+
+		label := tf.Label
+		if tf.Hint != "" {
+			label += " (" + tf.Hint + ")"
+		}
+		if !tf.Required {
+			label += " [optional]"
+		}
+
+		var value string
+		var err error
+		if tf.Multiline {
+			var path string
+			if path, err = promptInput(label + ", path to file: "); err == nil && path != "" {
+				value, err = readFieldFile(path)
+			}
+		} else {
+			value, err = promptField(label, tf.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if value == "" {
+			if tf.Required {
+				return nil, fmt.Errorf("%s is required for a %s entry", tf.Label, strings.ToLower(tmpl.Title))
+			}
+			continue
+		}
+		f := types.Field{Name: tf.Name, Type: tf.Type, Value: value}
+		if err := types.ValidateField(f); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// fieldOutput 是自定义字段的输出格式，hidden 字段的值只有在明确要求时才包含
+type fieldOutput struct {
+	Name  string          `json:"name" yaml:"name"`
+	Type  types.FieldType `json:"type" yaml:"type"`
+	Value string          `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// newFieldOutputs 返回条目自定义字段的输出格式，hidden 字段不包含值
+func newFieldOutputs(fields []types.Field) []fieldOutput {
+	out := make([]fieldOutput, 0, len(fields))
+	for _, f := range fields {
+		o := fieldOutput{Name: f.Name, Type: f.Type}
+		if f.Type != types.FieldTypeHidden {
+			o.Value = f.Value
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// printFields 以表格形式输出自定义字段，reveal 为 false 时隐藏 hidden 字段的值
+//
+// 模板中定义的字段使用模板的显示名称，多行的值在后续行中缩进显示。
+func printFields(fields []types.Field, reveal bool, tmpl *types.EntryTemplate) {
+	if len(fields) == 0 {
+		return
+	}
+	labels := make([]string, len(fields))
+	width := 0
+	for i, f := range fields {
+		labels[i] = f.Name
+		if tf := tmpl.Field(f.Name); tf != nil {
+			labels[i] = tf.Label
+		}
+		width = max(width, len(labels[i]))
+	}
+
+	fmt.Println("Fields:")
+	for i, f := range fields {
+		value := f.Value
+		if f.Type == types.FieldTypeHidden && !reveal {
+			value = "******** (hidden, use --password to show)"
+		}
+		lines := strings.Split(value, "\n")
+		fmt.Printf("  %-*s  %s\n", width, labels[i], lines[0])
+		for _, line := range lines[1:] {
+			fmt.Printf("  %-*s  %s\n", width, "", line)
+		}
+	}
+}
+
+// entrySummary 返回 list 中显示的条目摘要，由模板的摘要字段组成
+func entrySummary(e *types.Entry) string {
+	var parts []string
+	for _, name := range types.TemplateFor(e.Kind()).Summary {
+		var value string
+		switch name {
+		case "username":
+			value = e.Username
+		case "url":
+			value = e.URL
+		default:
+			for _, f := range e.Fields {
+				if f.Name == name && f.Type != types.FieldTypeHidden {
+					value = f.Value
+				}
+			}
+		}
+		if value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, "  ")
+}
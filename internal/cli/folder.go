@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var folderCmd = &cobra.Command{
+	Use:   "folder",
+	Short: "Manage the folders that organize entries",
+	Long: `Manage folders. Entries are addressed by their path, such as
+work/aws/prod/root, in every command; 'cipherhub add work/aws/prod/root'
+creates the folders as needed. Use 'cipherhub mv' to move entries and
+folders, and 'cipherhub list --tree' to see the hierarchy.`,
+}
+
+var folderAddCmd = &cobra.Command{
+	Use:   "add <folder>",
+	Short: "Create a folder (and its parent folders)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		folder, err := mgr.AddFolder(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to create folder: %w", err)
+		}
+		return render(valueOutput{"folder": folder}, func() error {
+			fmt.Printf("✓ Folder '%s' created\n", folder)
+			return nil
+		})
+	},
+}
+
+var folderRemoveCmd = &cobra.Command{
+	Use:     "rm <folder>",
+	Aliases: []string{"remove"},
+	Short:   "Remove an empty folder",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		folder, err := types.CleanFolder(args[0])
+		if err != nil {
+			return err
+		}
+		if err := mgr.RemoveFolder(folder); err != nil {
+			return fmt.Errorf("failed to remove folder: %w", err)
+		}
+		return render(valueOutput{"removed": folder}, func() error {
+			fmt.Printf("✓ Folder '%s' removed\n", folder)
+			return nil
+		})
+	},
+}
+
+var folderListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List all folders",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		folders, err := mgr.ListFolders()
+		if err != nil {
+			return err
+		}
+		if folders == nil {
+			folders = []string{}
+		}
+		return render(folders, func() error {
+			if len(folders) == 0 {
+				fmt.Println("No folders")
+				return nil
+			}
+			for _, f := range folders {
+				fmt.Println(f + types.PathSeparator)
+			}
+			return nil
+		})
+	},
+}
+
+// printTree 以树形输出文件夹 root 中的子文件夹和条目
+//
+// folders 中不在 root 之下的文件夹被忽略；条目所在的文件夹总会显示。
+func printTree(root string, folders []string, entries []*types.Entry) {
+	children := make(map[string]map[string]bool)
+	addFolder := func(f string) {
+		for _, a := range types.FolderAncestors(f) {
+			if a == root || !types.InFolder(a, root) {
+				continue
+			}
+			parent, _ := types.SplitPath(a)
+			if children[parent] == nil {
+				children[parent] = make(map[string]bool)
+			}
+			children[parent][a] = true
+		}
+	}
+	for _, f := range folders {
+		addFolder(f)
+	}
+
+	inFolder := make(map[string][]*types.Entry)
+	for _, e := range entries {
+		addFolder(e.Folder)
+		inFolder[e.Folder] = append(inFolder[e.Folder], e)
+	}
+
+	if root == "" {
+		fmt.Println(".")
+	} else {
+		fmt.Println(root + types.PathSeparator)
+	}
+
+	var walk func(folder, indent string)
+	walk = func(folder, indent string) {
+		subs := make([]string, 0, len(children[folder]))
+		for f := range children[folder] {
+			subs = append(subs, f)
+		}
+		sort.Strings(subs)
+		items := inFolder[folder]
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+		total := len(subs) + len(items)
+		n := 0
+		branch := func() (string, string) {
+			n++
+			if n == total {
+				return indent + "└── ", indent + "    "
+			}
+			return indent + "├── ", indent + "│   "
+		}
+		for _, f := range subs {
+			prefix, next := branch()
+			_, name := types.SplitPath(f)
+			fmt.Println(prefix + name + types.PathSeparator)
+			walk(f, next)
+		}
+		for _, e := range items {
+			prefix, _ := branch()
+			line := prefix + e.Name + entryMarks(e)
+			if summary := entrySummary(e); summary != "" {
+				line += "  " + summary
+			}
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+	walk(root, "")
+}
+
+func init() {
+	folderCmd.AddCommand(folderAddCmd, folderRemoveCmd, folderListCmd)
+}
@@ -24,8 +24,10 @@ The password is generated using cryptographically secure random bytes.`,
 		if err != nil {
 			return err
 		}
-		fmt.Println(password)
-		return nil
+		return render(valueOutput{"password": password}, func() error {
+			fmt.Println(password)
+			return nil
+		})
 	},
 }
 
@@ -6,7 +6,11 @@ package cli
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/imerr0rlog/CipherHub/internal/vault"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +18,7 @@ var (
 	getShowPassword bool
 	getShowNotes    bool
 	getCopy         bool
+	getField        string
 )
 
 var getCmd = &cobra.Command{
@@ -21,53 +26,114 @@ var getCmd = &cobra.Command{
 	Short: "Retrieve a password entry",
 	Long: `Retrieve a password entry from the vault.
 
-By default, only the entry details are shown without the password.
-Use --password to display the password, or --copy to copy it to clipboard.`,
-	Args: cobra.ExactArgs(1),
+By default, only the entry details are shown without the password and
+hidden custom fields. Use --password to display them, or --copy to copy
+the password to clipboard. --field prints only the value of a single
+field (a built-in field or a custom field), which is handy in scripts.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
-		masterPassword, err := promptPassword("Enter master password: ")
-		if err != nil {
-			return err
-		}
-
-		mgr, err := openVault(masterPassword)
+		mgr, err := unlockVault()
 		if err != nil {
 			return fmt.Errorf("failed to open vault: %w", err)
 		}
 		defer mgr.Close()
 
+		if getField != "" {
+			return printSingleField(mgr, name, getField, getCopy)
+		}
+
 		entry, err := mgr.GetEntry(name)
 		if err != nil {
 			return err
 		}
-
-		fmt.Println()
-		fmt.Printf("Name:     %s\n", entry.Name)
-		fmt.Printf("Username: %s\n", entry.Username)
-		fmt.Printf("URL:      %s\n", entry.URL)
-		fmt.Printf("Created:  %s\n", entry.CreatedAt.Format("2006-01-02 15:04"))
-		fmt.Printf("Updated:  %s\n", entry.UpdatedAt.Format("2006-01-02 15:04"))
-
-		if len(entry.Tags) > 0 {
-			fmt.Printf("Tags:     %v\n", entry.Tags)
+		fields, err := mgr.GetFields(name)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt fields: %w", err)
+		}
+		tmpl := types.TemplateFor(entry.Kind())
+		// 安全笔记的内容就是备注，默认显示
+		showNotes := getShowNotes || entry.Kind() == types.EntryTypeNote
+		// login 以外的类型只显示模板使用的或非空的内置字段
+		showBuiltin := func(field, value string) bool {
+			return entry.Kind() == types.EntryTypeLogin || tmpl.UsesBuiltin(field) || value != ""
 		}
 
+		out := newEntryOutput(entry)
 		if getShowPassword {
-			password, err := mgr.GetDecryptedPassword(name)
-			if err != nil {
+			if out.Password, err = mgr.GetDecryptedPassword(name); err != nil {
 				return fmt.Errorf("failed to decrypt password: %w", err)
 			}
-			fmt.Printf("Password: %s\n", password)
 		}
-
-		if getShowNotes && entry.Notes != "" {
-			notes, err := mgr.GetDecryptedNotes(name)
-			if err != nil {
+		if getShowPassword {
+			for i, f := range fields {
+				out.Fields[i].Value = f.Value
+			}
+		}
+		if showNotes && entry.Notes != "" {
+			if out.Notes, err = mgr.GetDecryptedNotes(name); err != nil {
 				return fmt.Errorf("failed to decrypt notes: %w", err)
 			}
-			fmt.Printf("Notes:    %s\n", notes)
+		}
+
+		err = render(out, func() error {
+			fmt.Println()
+			fmt.Printf("Name:     %s\n", entry.Name+entryMarks(entry))
+			fmt.Printf("ID:       %s\n", entry.ID)
+			if len(entry.Aliases) > 0 {
+				fmt.Printf("Aliases:  %s\n", strings.Join(entry.Aliases, ", "))
+			}
+			if entry.Folder != "" {
+				fmt.Printf("Folder:   %s\n", entry.Folder)
+			}
+			if entry.Kind() != types.EntryTypeLogin {
+				fmt.Printf("Type:     %s\n", tmpl.Title)
+			}
+			if showBuiltin("username", entry.Username) {
+				fmt.Printf("Username: %s\n", entry.Username)
+			}
+			if showBuiltin("url", entry.URL) {
+				fmt.Printf("URL:      %s\n", entry.URL)
+			}
+			fmt.Printf("Created:  %s\n", entry.CreatedAt.Format("2006-01-02 15:04"))
+			fmt.Printf("Updated:  %s\n", entry.UpdatedAt.Format("2006-01-02 15:04"))
+			if entry.RotationDays > 0 {
+				fmt.Printf("Rotation: every %s, password changed %s\n", pluralDays(entry.RotationDays), entry.PasswordSetAt().Format("2006-01-02"))
+			}
+			if entry.ExpiresAt != nil {
+				fmt.Printf("Expires:  %s\n", entry.ExpiresAt.Format(types.DateLayout))
+			}
+			if due, ok := entry.DueAt(); ok {
+				status := ""
+				if entry.Expired(time.Now()) {
+					status = " (expired)"
+				}
+				fmt.Printf("Due:      %s%s\n", due.Format("2006-01-02"), status)
+			}
+
+			if len(entry.Tags) > 0 {
+				fmt.Printf("Tags:     %v\n", entry.Tags)
+			}
+			printFields(fields, getShowPassword, tmpl)
+			if entry.TOTP != "" {
+				fmt.Printf("TOTP:     configured (cipherhub totp %s)\n", entry.Path())
+			}
+			if len(entry.Attachments) > 0 {
+				fmt.Println("Attachments:")
+				printAttachments(entry.Attachments)
+			}
+			if getShowPassword && showBuiltin("password", out.Password) {
+				fmt.Printf("Password: %s\n", out.Password)
+			}
+			if out.Notes != "" {
+				fmt.Printf("Notes:    %s\n", out.Notes)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 
 		if getCopy {
@@ -75,10 +141,8 @@ Use --password to display the password, or --copy to copy it to clipboard.`,
 			if err != nil {
 				return fmt.Errorf("failed to decrypt password: %w", err)
 			}
-			if err := copyToClipboard(password); err != nil {
-				fmt.Printf("⚠ Failed to copy to clipboard: %v\n", err)
-			} else {
-				fmt.Println("✓ Password copied to clipboard")
+			if err := copyToClipboard("password", password); err != nil {
+				statusf("⚠ Failed to copy to clipboard: %v\n", err)
 			}
 		}
 
@@ -86,12 +150,24 @@ Use --password to display the password, or --copy to copy it to clipboard.`,
 	},
 }
 
+// printSingleField 只输出条目一个字段的值，copy 为 true 时复制到剪贴板
+func printSingleField(mgr *vault.Manager, name, field string, copy bool) error {
+	value, err := mgr.GetField(name, field)
+	if err != nil {
+		return err
+	}
+	if copy {
+		return copyToClipboard(field, value)
+	}
+	return render(valueOutput{field: value}, func() error {
+		fmt.Println(value)
+		return nil
+	})
+}
+
 func init() {
 	getCmd.Flags().BoolVarP(&getShowPassword, "password", "p", false, "show password in plain text")
 	getCmd.Flags().BoolVarP(&getShowNotes, "notes", "n", false, "show the notes")
-	getCmd.Flags().BoolVarP(&getCopy, "copy", "c", false, "copy password to clipboard")
-}
-
-func copyToClipboard(text string) error {
-	return fmt.Errorf("clipboard not supported in this environment")
+	getCmd.Flags().BoolVarP(&getCopy, "copy", "c", false, "copy password (or the --field value) to clipboard")
+	getCmd.Flags().StringVarP(&getField, "field", "f", "", "print only the value of this field")
 }
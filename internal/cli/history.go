@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var historyShowPassword bool
+
+var historyCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "Show the previous passwords of an entry",
+	Long: `Show the previous passwords of an entry, most recent first, with the
+time each one was replaced.
+
+Every password change through 'update' or 'edit' keeps the old password
+encrypted in the entry. The number kept per entry is set with
+'cipherhub config --password-history N' (default 10, 0 keeps none).
+Restore a previous password with 'cipherhub update <name> --restore-version N'.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		history, err := mgr.GetPasswordHistory(name)
+		if err != nil {
+			return err
+		}
+
+		out := make(historyList, 0, len(history))
+		for i, v := range history {
+			o := historyOutput{Version: i + 1, ReplacedAt: v.ReplacedAt}
+			if historyShowPassword {
+				o.Password = v.Password
+			}
+			out = append(out, o)
+		}
+
+		return render(out, func() error {
+			if len(out) == 0 {
+				fmt.Printf("No previous passwords for '%s'\n", name)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tREPLACED\tPASSWORD")
+			for _, o := range out {
+				password := o.Password
+				if !historyShowPassword {
+					password = "********"
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\n", o.Version, o.ReplacedAt.Format("2006-01-02 15:04"), password)
+			}
+			w.Flush()
+
+			if !historyShowPassword {
+				fmt.Println("\nUse --password to show the passwords.")
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	historyCmd.Flags().BoolVarP(&historyShowPassword, "password", "p", false, "show the previous passwords in plain text")
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show information about the vault",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		info := newVaultInfoOutput(mgr.VaultInfo())
+		return render(info, func() error {
+			fmt.Printf("Path:     %s\n", info.Path)
+			fmt.Printf("Storage:  %s\n", info.Storage)
+			fmt.Printf("Version:  %s\n", info.Version)
+			fmt.Printf("Entries:  %d\n", info.Entries)
+			fmt.Printf("Trash:    %d\n", info.Trash)
+			fmt.Printf("Created:  %s\n", info.CreatedAt.Format("2006-01-02 15:04"))
+			fmt.Printf("Updated:  %s\n", info.UpdatedAt.Format("2006-01-02 15:04"))
+			return nil
+		})
+	},
+}
@@ -5,11 +5,14 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/imerr0rlog/CipherHub/internal/storage"
+	"github.com/imerr0rlog/CipherHub/internal/vault"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -23,14 +26,17 @@ You will be prompted to create a master password that will be used
 to encrypt all your credentials. Make sure to remember this password
 as it cannot be recovered.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if storage.NewLocalStorage(cfg.VaultPath).Exists() {
-			return fmt.Errorf("vault already exists at %s", cfg.VaultPath)
+		exists, err := storage.NewLocalStorage(cfg.VaultPath).Exists(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("vault already exists at %s: %w", cfg.VaultPath, vault.ErrVaultExists)
 		}
 
-		fmt.Println("Creating a new CipherHub vault...")
-		fmt.Println()
+		statusf("Creating a new CipherHub vault...\n\n")
 
-		password, err := promptPassword("Enter master password: ")
+		password, err := readMasterPassword("Enter master password: ")
 		if err != nil {
 			return err
 		}
@@ -39,13 +45,16 @@ as it cannot be recovered.`,
 			return fmt.Errorf("master password must be at least 8 characters")
 		}
 
-		confirm, err := promptPassword("Confirm master password: ")
-		if err != nil {
-			return err
-		}
+		// 非交互来源无法输错，不需要确认
+		if !nonInteractivePassword() {
+			confirm, err := promptPassword("Confirm master password: ")
+			if err != nil {
+				return err
+			}
 
-		if password != confirm {
-			return fmt.Errorf("passwords do not match")
+			if password != confirm {
+				return fmt.Errorf("passwords do not match")
+			}
 		}
 
 		mgr, err := getVaultManager()
@@ -56,40 +65,50 @@ as it cannot be recovered.`,
 		if err := mgr.Init(password); err != nil {
 			return fmt.Errorf("failed to initialize vault: %w", err)
 		}
-
-		fmt.Println()
-		fmt.Printf("✓ Vault created successfully at %s\n", cfg.VaultPath)
-		fmt.Println()
-		fmt.Println("You can now add entries with: cipherhub add <name>")
-
-		return nil
+		trackNameIndex(mgr)
+
+		return render(newVaultInfoOutput(mgr.VaultInfo()), func() error {
+			fmt.Println()
+			fmt.Printf("✓ Vault created successfully at %s\n", cfg.VaultPath)
+			fmt.Println()
+			fmt.Println("You can now add entries with: cipherhub add <name>")
+			return nil
+		})
 	},
 }
 
 func promptPassword(prompt string) (string, error) {
-	fmt.Print(prompt)
+	statusf("%s", prompt)
 
 	// 尝试使用 term.ReadPassword 隐藏输入（终端模式）
 	if term.IsTerminal(int(os.Stdin.Fd())) {
 		password, err := term.ReadPassword(int(os.Stdin.Fd()))
-		fmt.Println() // ReadPassword 不会输出换行
+		statusf("\n") // ReadPassword 不会输出换行
 		return string(password), err
 	}
 
-	// 非 terminal 模式（如管道输入），回退到普通读取
-	var input string
-	if _, err := fmt.Scanln(&input); err != nil {
+	// 非 terminal 模式（如管道输入），读取一整行，保留密码中的空格
+	return readLine()
+}
+
+func promptInput(prompt string) (string, error) {
+	statusf("%s", prompt)
+
+	input, err := readLine()
+	if err != nil {
 		return "", err
 	}
 	return strings.TrimSpace(input), nil
 }
 
-func promptInput(prompt string) (string, error) {
-	fmt.Print(prompt)
+// stdinReader 是所有交互式输入共用的标准输入缓冲，避免多次提示之间丢失已缓冲的数据
+var stdinReader = bufio.NewReader(os.Stdin)
 
-	var input string
-	if _, err := fmt.Scanln(&input); err != nil {
+// readLine 从标准输入读取一行，去掉行尾的换行符
+func readLine() (string, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
 		return "", err
 	}
-	return strings.TrimSpace(input), nil
+	return strings.TrimRight(line, "\r\n"), nil
 }
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/imerr0rlog/CipherHub/internal/inject"
+	"github.com/spf13/cobra"
+)
+
+var (
+	injectInput  string
+	injectOutput string
+)
+
+var injectCmd = &cobra.Command{
+	Use:   "inject [-i template] [-o output]",
+	Short: "Render a template with secrets from the vault",
+	Long: `Render a template with secrets from the vault.
+
+Templates use Go text/template syntax and can reference secrets in two ways:
+
+  {{ cipherhub "prod-db" "password" }}
+  chub://prod-db/password
+
+In chub:// references the last segment is the field and the segments
+before it are the entry path, so entries in folders are referenced as
+chub://work/db/password. The field may be omitted only for entries at the
+top level (chub://prod-db uses the password). Paths and fields may be
+percent-encoded (chub://my%20site/username).
+
+All references are resolved before anything is written; if any of them
+cannot be resolved the command fails and lists every bad reference.
+The output file is created with 0600 permissions. Without -i the template
+is read from standard input, without -o the result goes to standard output.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := "stdin"
+		var src []byte
+		var err error
+		if injectInput != "" && injectInput != "-" {
+			name = filepath.Base(injectInput)
+			src, err = os.ReadFile(injectInput)
+		} else {
+			src, err = io.ReadAll(os.Stdin)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		var out bytes.Buffer
+		if err := inject.Render(&out, name, string(src), mgr.GetField); err != nil {
+			return fmt.Errorf("failed to render %s:\n%w", name, err)
+		}
+
+		if injectOutput == "" || injectOutput == "-" {
+			_, err := os.Stdout.Write(out.Bytes())
+			return err
+		}
+		if err := writePrivateFile(injectOutput, out.Bytes()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", injectOutput, err)
+		}
+		statusf("✓ Rendered %s to %s\n", name, injectOutput)
+		return nil
+	},
+}
+
+// writePrivateFile 以 0600 权限原子地写入文件
+//
+// 先写入同目录下的临时文件再重命名，已存在的文件也会被替换为 0600 权限，
+// 写入失败时不会留下只写了一半的文件。
+func writePrivateFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func init() {
+	injectCmd.Flags().StringVarP(&injectInput, "in-file", "i", "", "template file (default: standard input)")
+	injectCmd.Flags().StringVarP(&injectOutput, "out-file", "o", "", "output file, created with 0600 permissions (default: standard output)")
+}
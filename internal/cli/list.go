@@ -7,8 +7,12 @@ package cli
 import (
 	"fmt"
 	"os"
+	"sort"
 	"text/tabwriter"
+	"time"
 
+	"github.com/imerr0rlog/CipherHub/internal/query"
+	"github.com/imerr0rlog/CipherHub/internal/vault"
 	"github.com/imerr0rlog/CipherHub/pkg/types"
 	"github.com/spf13/cobra"
 )
@@ -16,21 +20,54 @@ import (
 var (
 	listShowPasswords bool
 	listSearch        string
+	listFuzzy         bool
+	listType          string
+	listTree          bool
+	listExpired       bool
+	listArchived      bool
+	listAll           bool
 )
 
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [folder]",
 	Short: "List all password entries",
-	Long: `List all password entries in the vault.
+	Long: `List all password entries in the vault, or only those in a folder
+(including its subfolders).
 
-Use --search to filter entries by name, username, or URL.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		masterPassword, err := promptPassword("Enter master password: ")
-		if err != nil {
-			return err
-		}
+Use --search to filter entries with a query, and --type to show only
+entries of one type; --expired shows only entries whose password has
+expired or is overdue for rotation (see 'cipherhub due'). The DETAILS
+column shows the most useful non-secret fields for each entry type.
+--tree shows the folders and entries as a tree.
+
+Favorites (see 'cipherhub favorite') are listed first and marked with ★.
+Archived entries (see 'cipherhub archive') are hidden; --archived shows
+only them and --all shows them along with the others.
+
+Search queries:
+  github                    path, username, URL, a tag or an alias contains "github"
+  tag:prod user:admin       terms separated by spaces must all match
+  url:*.example.com         * and ? are wildcards (url: also matches the host)
+  name:/^db-[0-9]+$/        /.../ is a case-insensitive regular expression
+  "two words"               quotes keep spaces and disable wildcards
+  tag:prod OR tag:staging   OR, AND, NOT (or -term) and parentheses
+  updated:<90d              changed in the last 90 days (h, d, w, m, y)
+  created:>=2024-01-01      created on or after a date (<, <=, >, >=, =)
 
-		mgr, err := openVault(masterPassword)
+Fields: name, path, folder, user, url, tag, alias, id, type, field (custom
+field names and non-hidden values), has (url, username, notes, tags, totp,
+fields, attachment, history), is (favorite, archived), created and updated.
+
+With --fuzzy, plain terms match as subsequences ("ghb" matches "github")
+and the results are ranked by how well they match.
+
+Examples:
+  cipherhub list --search 'tag:prod -type:note'
+  cipherhub list --search 'folder:work updated:>1y'
+  cipherhub list --search gthb --fuzzy`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
 		if err != nil {
 			return fmt.Errorf("failed to open vault: %w", err)
 		}
@@ -38,7 +75,7 @@ Use --search to filter entries by name, username, or URL.`,
 
 		var entries []*types.Entry
 		if listSearch != "" {
-			entries, err = mgr.SearchEntries(listSearch)
+			entries, err = mgr.Query(listSearch, query.Options{Fuzzy: listFuzzy})
 			if err != nil {
 				return err
 			}
@@ -49,31 +86,125 @@ Use --search to filter entries by name, username, or URL.`,
 			}
 		}
 
-		if len(entries) == 0 {
-			fmt.Println("No entries found")
-			return nil
+		var folder string
+		if len(args) == 1 {
+			if folder, err = types.CleanFolder(args[0]); err != nil {
+				return err
+			}
+			if folder != "" && !mgr.IsFolder(folder) {
+				return fmt.Errorf("folder %q: %w", folder, vault.ErrFolderNotFound)
+			}
+			filtered := entries[:0:0]
+			for _, entry := range entries {
+				if types.InFolder(entry.Folder, folder) {
+					filtered = append(filtered, entry)
+				}
+			}
+			entries = filtered
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "NAME\tUSERNAME\tURL\tUPDATED")
+		if listExpired {
+			now := time.Now()
+			filtered := entries[:0:0]
+			for _, entry := range entries {
+				if entry.Expired(now) {
+					filtered = append(filtered, entry)
+				}
+			}
+			entries = filtered
+		}
 
+		if listType != "" {
+			entryType, err := types.ParseEntryType(listType)
+			if err != nil {
+				return err
+			}
+			filtered := entries[:0:0]
+			for _, entry := range entries {
+				if entry.Kind() == entryType {
+					filtered = append(filtered, entry)
+				}
+			}
+			entries = filtered
+		}
+
+		filtered := entries[:0:0]
 		for _, entry := range entries {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-				entry.Name,
-				entry.Username,
-				entry.URL,
-				entry.UpdatedAt.Format("2006-01-02"),
-			)
+			if listAll || entry.Archived == listArchived {
+				filtered = append(filtered, entry)
+			}
 		}
+		entries = filtered
+		// 收藏的条目排在前面，两组内部保持原来的顺序（如模糊搜索的得分）
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Favorite && !entries[j].Favorite })
 
-		w.Flush()
-		fmt.Printf("\nTotal: %d entries\n", len(entries))
+		out := make(entryList, 0, len(entries))
+		for _, entry := range entries {
+			o := newEntryOutput(entry)
+			if listShowPasswords && machineOutput() {
+				if o.Password, err = mgr.GetDecryptedPassword(entry.ID); err != nil {
+					return fmt.Errorf("failed to decrypt password: %w", err)
+				}
+			}
+			out = append(out, o)
+		}
+
+		return render(out, func() error {
+			if listTree {
+				var folders []string
+				// 没有过滤条件时也显示空文件夹
+				if listSearch == "" && listType == "" && !listExpired && !listArchived {
+					if folders, err = mgr.ListFolders(); err != nil {
+						return err
+					}
+				}
+				printTree(folder, folders, entries)
+				return nil
+			}
 
-		return nil
+			if len(entries) == 0 {
+				fmt.Println("No entries found")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tTYPE\tDETAILS\tUPDATED")
+
+			for _, entry := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+					entry.Path()+entryMarks(entry),
+					entry.Kind(),
+					entrySummary(entry),
+					entry.UpdatedAt.Format("2006-01-02"),
+				)
+			}
+
+			w.Flush()
+			fmt.Printf("\nTotal: %d entries\n", len(entries))
+			return nil
+		})
 	},
 }
 
+// entryMarks 返回列表中条目名称后的标记：收藏的条目为 ★，已归档的条目为 (archived)
+func entryMarks(e *types.Entry) string {
+	var marks string
+	if e.Favorite {
+		marks += " ★"
+	}
+	if e.Archived {
+		marks += " (archived)"
+	}
+	return marks
+}
+
 func init() {
 	listCmd.Flags().BoolVarP(&listShowPasswords, "passwords", "p", false, "show passwords (WARNING: insecure)")
-	listCmd.Flags().StringVarP(&listSearch, "search", "s", "", "search entries by name, username, or URL")
+	listCmd.Flags().StringVarP(&listSearch, "search", "s", "", "show only entries matching a search query")
+	listCmd.Flags().BoolVar(&listFuzzy, "fuzzy", false, "match search terms fuzzily and rank the results")
+	listCmd.Flags().StringVar(&listType, "type", "", "show only entries of this type")
+	listCmd.Flags().BoolVar(&listTree, "tree", false, "show folders and entries as a tree")
+	listCmd.Flags().BoolVar(&listArchived, "archived", false, "show only archived entries")
+	listCmd.Flags().BoolVarP(&listAll, "all", "a", false, "show archived entries too")
+	listCmd.Flags().BoolVar(&listExpired, "expired", false, "show only entries whose password has expired or is overdue for rotation")
 }
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <source> <destination>",
+	Short: "Move or rename an entry or folder",
+	Long: `Move or rename an entry or a folder.
+
+If the destination ends with '/' or is an existing folder, the source is
+moved into it and keeps its name ('/' alone is the top level); otherwise
+the destination is the new path. Moving a folder moves all entries and
+subfolders in it.
+
+Examples:
+  cipherhub mv github work/github         # rename to a path (creates 'work')
+  cipherhub mv work/github personal/      # move into a folder
+  cipherhub mv work/aws work/cloud        # rename a folder`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, dest := args[0], args[1]
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		if _, err := mgr.GetEntry(src); err != nil {
+			if !mgr.IsFolder(src) {
+				return err
+			}
+			folder, moved, err := mgr.MoveFolder(src, dest)
+			if err != nil {
+				return fmt.Errorf("failed to move folder: %w", err)
+			}
+			return render(valueOutput{"folder": folder}, func() error {
+				fmt.Printf("✓ Folder moved to '%s' (%d entries)\n", folder, moved)
+				return nil
+			})
+		}
+
+		entry, err := mgr.MoveEntry(src, dest)
+		if err != nil {
+			return fmt.Errorf("failed to move entry: %w", err)
+		}
+		return render(newEntryOutput(entry), func() error {
+			fmt.Printf("✓ Entry moved to '%s'\n", entry.Path())
+			return nil
+		})
+	},
+}
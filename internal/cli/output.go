@@ -0,0 +1,503 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/agent"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// --output 支持的输出格式
+const (
+	OutputTable = "table" // 面向用户的文本（默认）
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputCSV   = "csv"
+)
+
+var outputFormat string
+
+// validateOutput 检查 --output 的取值
+func validateOutput() error {
+	switch outputFormat {
+	case OutputTable, OutputJSON, OutputYAML, OutputCSV:
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q (use table, json, yaml or csv)", outputFormat)
+}
+
+// machineOutput 返回是否输出机器可读格式
+func machineOutput() bool {
+	return outputFormat != "" && outputFormat != OutputTable
+}
+
+// statusWriter 返回提示信息的输出位置
+//
+// 机器可读模式下提示和交互式问题写到标准错误，标准输出只包含数据。
+func statusWriter() io.Writer {
+	if machineOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// statusf 输出面向用户的提示信息
+func statusf(format string, a ...interface{}) {
+	fmt.Fprintf(statusWriter(), format, a...)
+}
+
+// tabular 是可以输出为 CSV 的数据
+type tabular interface {
+	csvHeader() []string
+	csvRows() [][]string
+}
+
+// render 按 --output 指定的格式将 v 写到标准输出
+//
+// table 格式调用 table 输出面向用户的文本；csv 格式要求 v 实现 tabular。
+func render(v interface{}, table func() error) error {
+	return renderTo(os.Stdout, v, table)
+}
+
+func renderTo(w io.Writer, v interface{}, table func() error) error {
+	switch outputFormat {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputCSV:
+		t, ok := v.(tabular)
+		if !ok {
+			return fmt.Errorf("csv output is not supported by this command")
+		}
+		cw := csv.NewWriter(w)
+		cw.Write(t.csvHeader())
+		cw.WriteAll(t.csvRows())
+		return cw.Error()
+	default:
+		return table()
+	}
+}
+
+// entryOutput 是条目的输出格式
+//
+// 密码、备注和 hidden 自定义字段的值只有在明确要求时才包含。
+type entryOutput struct {
+	ID                string             `json:"id" yaml:"id"`
+	Name              string             `json:"name" yaml:"name"`
+	Folder            string             `json:"folder" yaml:"folder"`
+	Path              string             `json:"path" yaml:"path"` // 文件夹与名称组成的完整路径
+	Aliases           []string           `json:"aliases" yaml:"aliases"`
+	Type              types.EntryType    `json:"type" yaml:"type"`
+	Username          string             `json:"username" yaml:"username"`
+	URL               string             `json:"url" yaml:"url"`
+	Tags              []string           `json:"tags" yaml:"tags"`
+	Password          string             `json:"password,omitempty" yaml:"password,omitempty"`
+	Notes             string             `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Fields            []fieldOutput      `json:"fields" yaml:"fields"`
+	Attachments       []attachmentOutput `json:"attachments" yaml:"attachments"`
+	CreatedAt         time.Time          `json:"created_at" yaml:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at" yaml:"updated_at"`
+	PasswordChangedAt time.Time          `json:"password_changed_at" yaml:"password_changed_at"`
+	RotationDays      int                `json:"rotation_days,omitempty" yaml:"rotation_days,omitempty"`
+	ExpiresAt         *time.Time         `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	DueAt             *time.Time         `json:"due_at,omitempty" yaml:"due_at,omitempty"` // 轮换周期到期和过期时间中较早的一个
+	Favorite          bool               `json:"favorite" yaml:"favorite"`
+	Archived          bool               `json:"archived" yaml:"archived"`
+}
+
+func newEntryOutput(e *types.Entry) *entryOutput {
+	tags := e.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	aliases := e.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return &entryOutput{
+		ID:                e.ID,
+		Name:              e.Name,
+		Folder:            e.Folder,
+		Path:              e.Path(),
+		Aliases:           aliases,
+		Type:              e.Kind(),
+		Username:          e.Username,
+		URL:               e.URL,
+		Tags:              tags,
+		Fields:            newFieldOutputs(e.Fields),
+		Attachments:       newAttachmentOutputs(e.Attachments),
+		CreatedAt:         e.CreatedAt,
+		UpdatedAt:         e.UpdatedAt,
+		PasswordChangedAt: e.PasswordSetAt(),
+		RotationDays:      e.RotationDays,
+		ExpiresAt:         e.ExpiresAt,
+		DueAt:             dueAt(e),
+		Favorite:          e.Favorite,
+		Archived:          e.Archived,
+	}
+}
+
+// dueAt 返回条目的到期时间，不会到期时返回 nil
+func dueAt(e *types.Entry) *time.Time {
+	if due, ok := e.DueAt(); ok {
+		return &due
+	}
+	return nil
+}
+
+func (e *entryOutput) csvHeader() []string {
+	return entryList{}.csvHeader()
+}
+
+func (e *entryOutput) csvRows() [][]string {
+	return entryList{e}.csvRows()
+}
+
+// entryList 是条目列表的输出格式
+type entryList []*entryOutput
+
+func (l entryList) csvHeader() []string {
+	return []string{"id", "name", "username", "url", "tags", "password", "notes", "created_at", "updated_at", "folder"}
+}
+
+func (l entryList) csvRows() [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, e := range l {
+		rows = append(rows, []string{
+			e.ID, e.Name, e.Username, e.URL, strings.Join(e.Tags, ";"), e.Password, e.Notes,
+			e.CreatedAt.Format(time.RFC3339), e.UpdatedAt.Format(time.RFC3339), e.Folder,
+		})
+	}
+	return rows
+}
+
+// vaultInfoOutput 是密码库信息的输出格式，对应 vault.Manager.VaultInfo
+type vaultInfoOutput struct {
+	Path      string    `json:"path" yaml:"path"`
+	Storage   string    `json:"storage" yaml:"storage"`
+	Version   string    `json:"version" yaml:"version"`
+	Entries   int       `json:"entries" yaml:"entries"`
+	Trash     int       `json:"trash" yaml:"trash"` // 回收站中的条目数量
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
+}
+
+func newVaultInfoOutput(info map[string]interface{}) *vaultInfoOutput {
+	out := &vaultInfoOutput{
+		Path:    cfg.VaultPath,
+		Storage: string(cfg.DefaultStorage),
+	}
+	out.Version, _ = info["version"].(string)
+	out.Entries, _ = info["entries"].(int)
+	out.Trash, _ = info["trash"].(int)
+	out.CreatedAt, _ = info["created_at"].(time.Time)
+	out.UpdatedAt, _ = info["updated_at"].(time.Time)
+	return out
+}
+
+func (v *vaultInfoOutput) csvHeader() []string {
+	return []string{"path", "storage", "version", "entries", "trash", "created_at", "updated_at"}
+}
+
+func (v *vaultInfoOutput) csvRows() [][]string {
+	return [][]string{{
+		v.Path, v.Storage, v.Version, fmt.Sprint(v.Entries), fmt.Sprint(v.Trash),
+		v.CreatedAt.Format(time.RFC3339), v.UpdatedAt.Format(time.RFC3339),
+	}}
+}
+
+// syncItem 是一个文件的同步结果
+type syncItem struct {
+	File   string `json:"file" yaml:"file"`                         // vault、attachments 或 config
+	Status string `json:"status" yaml:"status"`                     // pushed、pulled、skipped 或 incomplete（附件缺少数据块）
+	Remote string `json:"remote,omitempty" yaml:"remote,omitempty"` // 远程路径
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"` // 跳过或不完整的原因
+}
+
+// syncResult 是 sync 命令的输出格式
+type syncResult struct {
+	Direction string      `json:"direction" yaml:"direction"` // push 或 pull
+	Files     []*syncItem `json:"files" yaml:"files"`
+}
+
+func (r *syncResult) csvHeader() []string {
+	return []string{"direction", "file", "status", "remote", "reason"}
+}
+
+func (r *syncResult) csvRows() [][]string {
+	rows := make([][]string, 0, len(r.Files))
+	for _, f := range r.Files {
+		rows = append(rows, []string{r.Direction, f.File, f.Status, f.Remote, f.Reason})
+	}
+	return rows
+}
+
+// agentStatusOutput 是 agent 状态的输出格式
+type agentStatusOutput struct {
+	PID         int        `json:"pid" yaml:"pid"`
+	Vaults      int        `json:"vaults" yaml:"vaults"`
+	IdleTimeout int        `json:"idle_timeout" yaml:"idle_timeout"` // 秒
+	LockAt      *time.Time `json:"lock_at,omitempty" yaml:"lock_at,omitempty"`
+}
+
+func newAgentStatusOutput(s *agent.Status) *agentStatusOutput {
+	out := &agentStatusOutput{
+		PID:         s.PID,
+		Vaults:      s.Vaults,
+		IdleTimeout: int(s.IdleTimeout / time.Second),
+	}
+	if !s.LockAt.IsZero() {
+		out.LockAt = &s.LockAt
+	}
+	return out
+}
+
+func (s *agentStatusOutput) csvHeader() []string {
+	return []string{"pid", "vaults", "idle_timeout", "lock_at"}
+}
+
+func (s *agentStatusOutput) csvRows() [][]string {
+	lockAt := ""
+	if s.LockAt != nil {
+		lockAt = s.LockAt.Format(time.RFC3339)
+	}
+	return [][]string{{fmt.Sprint(s.PID), fmt.Sprint(s.Vaults), fmt.Sprint(s.IdleTimeout), lockAt}}
+}
+
+// attachmentOutput 是附件元数据的输出格式
+type attachmentOutput struct {
+	Name      string    `json:"name" yaml:"name"`
+	Size      int64     `json:"size" yaml:"size"` // 字节数
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+}
+
+func newAttachmentOutput(a *types.Attachment) *attachmentOutput {
+	return &attachmentOutput{Name: a.Name, Size: a.Size, CreatedAt: a.CreatedAt}
+}
+
+func newAttachmentOutputs(atts []types.Attachment) []attachmentOutput {
+	out := make([]attachmentOutput, 0, len(atts))
+	for i := range atts {
+		out = append(out, *newAttachmentOutput(&atts[i]))
+	}
+	return out
+}
+
+func (a *attachmentOutput) csvHeader() []string {
+	return attachmentList{}.csvHeader()
+}
+
+func (a *attachmentOutput) csvRows() [][]string {
+	return attachmentList{*a}.csvRows()
+}
+
+// attachmentList 是附件列表的输出格式
+type attachmentList []attachmentOutput
+
+func (l attachmentList) csvHeader() []string {
+	return []string{"name", "size", "created_at"}
+}
+
+func (l attachmentList) csvRows() [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, a := range l {
+		rows = append(rows, []string{a.Name, fmt.Sprint(a.Size), a.CreatedAt.Format(time.RFC3339)})
+	}
+	return rows
+}
+
+// historyOutput 是一个历史密码的输出格式，密码只有在明确要求时才包含
+type historyOutput struct {
+	Version    int       `json:"version" yaml:"version"`
+	ReplacedAt time.Time `json:"replaced_at" yaml:"replaced_at"`
+	Password   string    `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// historyList 是 history 命令的输出格式
+type historyList []historyOutput
+
+func (l historyList) csvHeader() []string {
+	return []string{"version", "replaced_at", "password"}
+}
+
+func (l historyList) csvRows() [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, v := range l {
+		rows = append(rows, []string{fmt.Sprint(v.Version), v.ReplacedAt.Format(time.RFC3339), v.Password})
+	}
+	return rows
+}
+
+// dueOutput 是 due 命令中一个到期条目的输出格式
+type dueOutput struct {
+	ID       string    `json:"id" yaml:"id"`
+	Path     string    `json:"path" yaml:"path"`
+	DueAt    time.Time `json:"due_at" yaml:"due_at"`
+	DaysLeft int       `json:"days_left" yaml:"days_left"` // 距到期日的日历天数，已到期时为 0 或负数
+	Expired  bool      `json:"expired" yaml:"expired"`
+	Reason   string    `json:"reason" yaml:"reason"` // rotation 或 expiry
+}
+
+// dueList 是 due 命令的输出格式
+type dueList []dueOutput
+
+func (l dueList) csvHeader() []string {
+	return []string{"id", "path", "due_at", "days_left", "expired", "reason"}
+}
+
+func (l dueList) csvRows() [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, d := range l {
+		rows = append(rows, []string{d.ID, d.Path, d.DueAt.Format(time.RFC3339), fmt.Sprint(d.DaysLeft), fmt.Sprint(d.Expired), d.Reason})
+	}
+	return rows
+}
+
+// trashOutput 是回收站中一个条目的输出格式
+type trashOutput struct {
+	ID        string     `json:"id" yaml:"id"`
+	Path      string     `json:"path" yaml:"path"` // 删除前的路径
+	Username  string     `json:"username" yaml:"username"`
+	DeletedAt time.Time  `json:"deleted_at" yaml:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty" yaml:"purge_at,omitempty"` // 永久删除的时间，回收站停用时为空
+}
+
+// trashList 是 trash list 命令的输出格式
+type trashList []trashOutput
+
+func (l trashList) csvHeader() []string {
+	return []string{"id", "path", "username", "deleted_at", "purge_at"}
+}
+
+func (l trashList) csvRows() [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, t := range l {
+		var purgeAt string
+		if t.PurgeAt != nil {
+			purgeAt = t.PurgeAt.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{t.ID, t.Path, t.Username, t.DeletedAt.Format(time.RFC3339), purgeAt})
+	}
+	return rows
+}
+
+// aliasOutput 是一个别名的输出格式
+type aliasOutput struct {
+	Alias string `json:"alias" yaml:"alias"`
+	ID    string `json:"id" yaml:"id"`
+	Path  string `json:"path" yaml:"path"`
+}
+
+// aliasList 是 alias ls 命令的输出格式
+type aliasList []aliasOutput
+
+func (l aliasList) csvHeader() []string {
+	return []string{"alias", "id", "path"}
+}
+
+func (l aliasList) csvRows() [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, a := range l {
+		rows = append(rows, []string{a.Alias, a.ID, a.Path})
+	}
+	return rows
+}
+
+// totpOutput 是 totp 命令的输出格式
+type totpOutput struct {
+	Code      string `json:"code" yaml:"code"`
+	Remaining int    `json:"remaining" yaml:"remaining"` // 验证码剩余有效秒数
+	Period    int    `json:"period" yaml:"period"`
+}
+
+func (t *totpOutput) csvHeader() []string {
+	return []string{"code", "remaining", "period"}
+}
+
+func (t *totpOutput) csvRows() [][]string {
+	return [][]string{{t.Code, fmt.Sprint(t.Remaining), fmt.Sprint(t.Period)}}
+}
+
+// configOutput 是配置的输出格式
+type configOutput struct {
+	Path   string         `json:"path" yaml:"path"`
+	Config map[string]any `json:"config" yaml:"config"` // 与 config.json 字段相同，但不包含凭据
+}
+
+// newConfigOutput 返回配置的输出格式
+//
+// WebDAV 的 password 和 token 替换为 password_set 和 token_set，表示是否已设置，
+// 避免凭据出现在终端、日志或脚本的输出中。
+func newConfigOutput(path string, cfg *types.Config) (*configOutput, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if webdav, ok := fields["webdav"].(map[string]any); ok {
+		delete(webdav, "password")
+		delete(webdav, "token")
+		webdav["password_set"] = cfg.WebDAV.Password != ""
+		webdav["token_set"] = cfg.WebDAV.Token != ""
+	}
+	return &configOutput{Path: path, Config: fields}, nil
+}
+
+// errorOutput 是命令失败时的输出格式
+type errorOutput struct {
+	Error errorDetail `json:"error" yaml:"error"`
+}
+
+type errorDetail struct {
+	Code    int    `json:"code" yaml:"code"`
+	Kind    string `json:"kind" yaml:"kind"`
+	Message string `json:"message" yaml:"message"`
+	Hint    string `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+func (e *errorOutput) csvHeader() []string {
+	return []string{"code", "kind", "message", "hint"}
+}
+
+func (e *errorOutput) csvRows() [][]string {
+	return [][]string{{fmt.Sprint(e.Error.Code), e.Error.Kind, e.Error.Message, e.Error.Hint}}
+}
+
+// valueOutput 是只有一个值的结果（如生成的密码、版本号）
+type valueOutput map[string]string
+
+func (v valueOutput) csvHeader() []string {
+	header := make([]string, 0, len(v))
+	for k := range v {
+		header = append(header, k)
+	}
+	sort.Strings(header)
+	return header
+}
+
+func (v valueOutput) csvRows() [][]string {
+	row := make([]string, 0, len(v))
+	for _, k := range v.csvHeader() {
+		row = append(row, v[k])
+	}
+	return [][]string{row}
+}
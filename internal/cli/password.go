@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// EnvPassword 是 --password-env 未指定变量名时读取的环境变量
+const EnvPassword = "CIPHERHUB_PASSWORD"
+
+// maxPasswordSize 限制从文件、文件描述符或外部命令读取的长度
+const maxPasswordSize = 64 * 1024
+
+var (
+	flagPasswordFile    string
+	flagPasswordFD      int
+	flagPasswordEnv     string
+	flagPasswordCommand string
+
+	// cachedMasterPassword 保存从非交互来源读取的主密码，同一进程内只读取一次
+	// （文件描述符和外部命令无法重复读取）
+	cachedMasterPassword *string
+)
+
+// readMasterPassword 读取主密码
+//
+// 指定了 --password-file、--password-fd、--password-env 或 --password-command
+// 时从对应来源读取，否则通过 prompt 交互式提示输入。
+func readMasterPassword(prompt string) (string, error) {
+	if cachedMasterPassword != nil {
+		return *cachedMasterPassword, nil
+	}
+
+	source, err := masterPasswordSource()
+	if err != nil {
+		return "", err
+	}
+	if source == nil {
+		return promptPassword(prompt)
+	}
+
+	password, err := source()
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		return "", errors.New("master password from the configured source is empty")
+	}
+	cachedMasterPassword = &password
+	return password, nil
+}
+
+// nonInteractivePassword 返回是否配置了非交互的主密码来源
+func nonInteractivePassword() bool {
+	return flagPasswordFile != "" || flagPasswordFD >= 0 || flagPasswordEnv != "" || flagPasswordCommand != ""
+}
+
+// masterPasswordSource 返回配置的主密码来源，没有配置时返回 nil
+func masterPasswordSource() (func() (string, error), error) {
+	var sources []func() (string, error)
+	if flagPasswordFile != "" {
+		sources = append(sources, func() (string, error) { return passwordFromFile(flagPasswordFile) })
+	}
+	if flagPasswordFD >= 0 {
+		sources = append(sources, func() (string, error) { return passwordFromFD(flagPasswordFD) })
+	}
+	if flagPasswordEnv != "" {
+		sources = append(sources, func() (string, error) { return passwordFromEnv(flagPasswordEnv) })
+	}
+	if flagPasswordCommand != "" {
+		sources = append(sources, func() (string, error) { return passwordFromCommand(flagPasswordCommand) })
+	}
+
+	switch len(sources) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sources[0], nil
+	default:
+		return nil, errors.New("only one of --password-file, --password-fd, --password-env and --password-command may be used")
+	}
+}
+
+// passwordFromFile 从文件读取主密码，文件可被其他用户读取时给出警告
+func passwordFromFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open password file: %w", err)
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		warnf("password file %s is accessible by other users (mode %04o); run 'chmod 600 %s'", path, info.Mode().Perm(), path)
+	}
+	return readPasswordFrom(f)
+}
+
+// passwordFromFD 从已打开的文件描述符读取主密码，例如 --password-fd 3 3<secret
+//
+// 不接受标准输入、输出和错误（0–2）：读取会耗尽标准输入，之后关闭它会使后续的提示无法读取输入。
+func passwordFromFD(fd int) (string, error) {
+	if fd <= 2 {
+		return "", fmt.Errorf("--password-fd %d is a standard stream; pass the password on another descriptor, e.g. --password-fd 3 3<file", fd)
+	}
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd))
+	if f == nil {
+		return "", fmt.Errorf("invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+
+	password, err := readPasswordFrom(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password from fd %d: %w", fd, err)
+	}
+	return password, nil
+}
+
+// passwordFromEnv 从环境变量读取主密码
+//
+// 读取后立即从环境中删除该变量，避免传递给子进程。
+func passwordFromEnv(name string) (string, error) {
+	password, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	os.Unsetenv(name)
+	warnf("reading the master password from $%s; environment variables may be visible to other processes and end up in logs", name)
+	return password, nil
+}
+
+// passwordFromCommand 运行外部命令并读取其标准输出的第一行作为主密码
+//
+// 命令通过系统 shell 执行，标准输入和标准错误继承自当前进程，
+// 以便硬件令牌等辅助程序与用户交互。
+func passwordFromCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("password command failed: %w", err)
+	}
+	return readPasswordFrom(&stdout)
+}
+
+// readPasswordFrom 读取第一行作为密码，保留行内空格，只去掉行尾换行
+func readPasswordFrom(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPasswordSize))
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// warnf 在标准错误输出警告
+func warnf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "⚠ Warning: "+format+"\n", a...)
+}
@@ -5,9 +5,13 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/imerr0rlog/CipherHub/internal/agent"
+	"github.com/imerr0rlog/CipherHub/internal/crypto"
 	"github.com/imerr0rlog/CipherHub/internal/storage"
 	"github.com/imerr0rlog/CipherHub/internal/vault"
 	"github.com/imerr0rlog/CipherHub/pkg/types"
@@ -31,6 +35,17 @@ credentials locally or syncs them to WebDAV cloud storage.
 All passwords are encrypted using AES-256-GCM with keys derived from
 your master password using Argon2id.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// 参数已通过校验，之后的错误与用法无关，不再打印帮助信息
+		cmd.SilenceUsage = true
+
+		if err := validateOutput(); err != nil {
+			outputFormat = OutputTable
+			return err
+		}
+		if _, err := masterPasswordSource(); err != nil {
+			return err
+		}
+
 		var err error
 		cfg, cfgPath, err = storage.LoadOrCreateConfigWithPath(flagConfigPath)
 		if err != nil {
@@ -46,28 +61,87 @@ your master password using Argon2id.`,
 // Execute 运行 CipherHub 命令行应用程序
 //
 // 该函数解析命令行参数并执行相应的命令。如果执行过程中发生错误，
-// 会将错误信息输出到标准错误流并以状态码 1 退出程序。
+// 会将错误信息和处理建议输出到标准错误流，并以 errors.go 中定义的
+// 状态码退出程序，便于脚本区分错误类别。使用 --output json|yaml|csv 时
+// 错误以相同格式输出。
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		var status exitStatus
+		if errors.As(err, &status) {
+			os.Exit(int(status))
+		}
+		class := classifyError(err)
+		if machineOutput() {
+			renderTo(os.Stderr, &errorOutput{Error: errorDetail{
+				Code:    class.code,
+				Kind:    class.kind,
+				Message: err.Error(),
+				Hint:    class.hint,
+			}}, nil)
+		} else {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			if class.hint != "" {
+				fmt.Fprintln(os.Stderr, "Hint:", class.hint)
+			}
+		}
+		os.Exit(class.code)
 	}
 }
 
 func init() {
+	rootCmd.SilenceErrors = true
+
 	rootCmd.PersistentFlags().StringVar(&flagConfigPath, "config", "", "config file path (default: ./config.json)")
 	rootCmd.PersistentFlags().StringVar(&flagVaultPath, "vault", "", "vault file path (default: ./vault.json)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", OutputTable, "output format: table, json, yaml or csv")
+	rootCmd.PersistentFlags().StringVar(&flagPasswordFile, "password-file", "", "read the master password from the first line of a file")
+	rootCmd.PersistentFlags().IntVar(&flagPasswordFD, "password-fd", -1, "read the master password from an open file descriptor (3 or higher)")
+	rootCmd.PersistentFlags().StringVar(&flagPasswordEnv, "password-env", "", "read the master password from an environment variable (insecure)")
+	rootCmd.PersistentFlags().Lookup("password-env").NoOptDefVal = EnvPassword
+	rootCmd.PersistentFlags().StringVar(&flagPasswordCommand, "password-command", "", "run a command and use the first line of its output as the master password")
 
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(copyCmd)
+	rootCmd.AddCommand(totpCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(injectCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(attachCmd)
+	rootCmd.AddCommand(mvCmd)
+	rootCmd.AddCommand(folderCmd)
+	rootCmd.AddCommand(aliasCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(dueCmd)
+	rootCmd.AddCommand(favoriteCmd)
+	rootCmd.AddCommand(unfavoriteCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(trashCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(syncCmd)
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(agentCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(unlockCmd)
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(clipboardClearCmd)
+}
+
+// commandContext 返回当前命令使用的 context
+func commandContext() context.Context {
+	if ctx := rootCmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
 }
 
 func getVaultManager() (*vault.Manager, error) {
@@ -76,18 +150,94 @@ func getVaultManager() (*vault.Manager, error) {
 		return nil, err
 	}
 
-	return vault.NewManager(st), nil
+	mgr := vault.NewManager(st)
+	mgr.SetContext(commandContext())
+	mgr.SetPasswordHistory(cfg.PasswordHistoryDepth())
+	mgr.SetTrashRetention(cfg.TrashRetention())
+	mgr.SetBlobStore(func(name string) (storage.Storage, error) {
+		return storage.NewBlobStorage(cfg, name)
+	})
+	return mgr, nil
 }
 
-func openVault(masterPassword string) (*vault.Manager, error) {
+// unlockVault 打开配置的密码库
+//
+// agent 正在运行且已缓存该密码库的密钥时直接使用，否则提示输入主密码。
+func unlockVault() (*vault.Manager, error) {
 	mgr, err := getVaultManager()
 	if err != nil {
 		return nil, err
 	}
 
-	if err := mgr.Open(masterPassword); err != nil {
+	if err := unlock(mgr); err != nil {
 		return nil, err
 	}
 
 	return mgr, nil
 }
+
+// unlock 使用 agent 中的密钥或主密码打开 mgr
+func unlock(mgr *vault.Manager) error {
+	return unlockWith(mgr, readMasterPassword)
+}
+
+// unlockWith 使用 agent 中的密钥或通过 prompt 读取的主密码打开 mgr
+//
+// 密码库只读取一次：agent 没有该密码库的密钥时才提示输入主密码。
+// 通过主密码打开后，如果 agent 正在运行，会把密钥交给 agent 缓存，
+// 之后的命令在空闲超时前无需再次输入主密码。
+func unlockWith(mgr *vault.Manager, prompt func(string) (string, error)) error {
+	client := agent.NewClient("")
+	agentRunning := client.Running()
+	prompted := false
+
+	err := mgr.OpenWithKey(func(salt []byte) ([]byte, error) {
+		if agentRunning {
+			key, err := client.Key(salt)
+			if err == nil {
+				return key, nil
+			}
+			if !errors.Is(err, agent.ErrLocked) {
+				return nil, err
+			}
+		}
+
+		masterPassword, err := prompt("Enter master password: ")
+		if err != nil {
+			return nil, err
+		}
+		prompted = true
+		return crypto.DeriveKey(masterPassword, salt), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if agentRunning && prompted {
+		cacheKey(client, mgr)
+	}
+	trackNameIndex(mgr)
+	return nil
+}
+
+// cacheKey 将已打开密码库的密钥交给 agent 缓存
+func cacheKey(client *agent.Client, mgr *vault.Manager) error {
+	key, err := mgr.Key()
+	if err != nil {
+		return err
+	}
+	defer wipe(key)
+
+	salt, err := mgr.Salt()
+	if err != nil {
+		return err
+	}
+	return client.Add(salt, key)
+}
+
+// wipe 将敏感数据清零
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/imerr0rlog/CipherHub/internal/proc"
+	"github.com/imerr0rlog/CipherHub/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var runEnv []string
+
+var runCmd = &cobra.Command{
+	Use:   "run --env NAME=<entry>[:<field>]... -- <command> [args...]",
+	Short: "Run a command with secrets from the vault as environment variables",
+	Long: `Run a command with secrets from the vault as environment variables.
+
+Each --env NAME=<entry>:<field> resolves a field of an entry (username,
+password, url, notes, totp or a custom field; password if omitted) and
+passes it to the command in the environment variable NAME. The secrets
+are never written to disk, and the vault is closed before the command
+starts.
+
+Example:
+  cipherhub run --env DB_PASS=prod-db:password --env API_KEY=stripe:notes -- ./server`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(runEnv) == 0 {
+			return fmt.Errorf("no secrets requested, use --env NAME=<entry>:<field>")
+		}
+
+		refs := make([]envRef, 0, len(runEnv))
+		for _, spec := range runEnv {
+			ref, err := parseEnvRef(spec)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, ref)
+		}
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		secrets, err := resolveEnvRefs(mgr, refs)
+		// 在启动子进程之前关闭密码库，清除内存中的密钥
+		mgr.Close()
+		if err != nil {
+			return err
+		}
+
+		env := mergeEnv(os.Environ(), secrets)
+		if err := proc.Exec(args[0], args[1:], env); err != nil {
+			return fmt.Errorf("failed to run %s: %w", args[0], err)
+		}
+		return nil
+	},
+}
+
+// envRef 是一个 --env 参数，表示把条目的某个字段放入环境变量
+//
+// field 为空时表示字段需要在打开密码库后确定，见 resolveEnvRefs。
+type envRef struct {
+	name  string
+	entry string
+	field string
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseEnvRef 解析 NAME=<entry>[:<field>]
+//
+// 条目名称中可以包含冒号，以最后一个冒号之后的部分作为字段名；
+// 最后一部分不是内置字段时可能是自定义字段，留到打开密码库后再确定。
+func parseEnvRef(spec string) (envRef, error) {
+	name, ref, ok := strings.Cut(spec, "=")
+	if !ok || ref == "" {
+		return envRef{}, fmt.Errorf("invalid --env %q, expected NAME=<entry>:<field>", spec)
+	}
+	if !envNamePattern.MatchString(name) {
+		return envRef{}, fmt.Errorf("invalid environment variable name %q", name)
+	}
+
+	entry, field := ref, "password"
+	if i := strings.LastIndex(ref, ":"); i > 0 {
+		if isEntryField(ref[i+1:]) {
+			entry, field = ref[:i], ref[i+1:]
+		} else {
+			field = ""
+		}
+	}
+	return envRef{name: name, entry: entry, field: field}, nil
+}
+
+// isEntryField 检查 field 是否为 vault.Manager.GetField 支持的内置字段
+func isEntryField(field string) bool {
+	switch field {
+	case "username", "password", "url", "notes", "totp":
+		return true
+	}
+	return false
+}
+
+// resolveEnvRefs 读取所有引用的字段，返回 NAME=value 列表
+func resolveEnvRefs(mgr *vault.Manager, refs []envRef) ([]string, error) {
+	env := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.field == "" {
+			ref.entry, ref.field = splitCustomFieldRef(mgr, ref.entry)
+		}
+		value, err := mgr.GetField(ref.entry, ref.field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s (%s:%s): %w", ref.name, ref.entry, ref.field, err)
+		}
+		env = append(env, ref.name+"="+value)
+	}
+	return env, nil
+}
+
+// splitCustomFieldRef 将 <entry>:<field> 拆分为条目名称和自定义字段名称
+//
+// 只有冒号之前的条目存在且包含该自定义字段时才拆分，
+// 否则整体视为条目名称，字段为 password。
+func splitCustomFieldRef(mgr *vault.Manager, ref string) (string, string) {
+	i := strings.LastIndex(ref, ":")
+	entry, field := ref[:i], ref[i+1:]
+	if e, err := mgr.GetEntry(entry); err == nil {
+		for _, f := range e.Fields {
+			if f.Name == field {
+				return entry, field
+			}
+		}
+	}
+	return ref, "password"
+}
+
+// mergeEnv 将 extra 合并到 base 中，同名变量以 extra 为准
+func mergeEnv(base, extra []string) []string {
+	override := make(map[string]bool, len(extra))
+	for _, kv := range extra {
+		name, _, _ := strings.Cut(kv, "=")
+		override[name] = true
+	}
+
+	env := make([]string, 0, len(base)+len(extra))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if !override[name] {
+			env = append(env, kv)
+		}
+	}
+	return append(env, extra...)
+}
+
+func init() {
+	runCmd.Flags().StringArrayVarP(&runEnv, "env", "e", nil, "set NAME to a field of an entry: NAME=<entry>:<field> (repeatable)")
+	// 第一个位置参数之后的内容都属于子命令，即使省略了 --
+	runCmd.Flags().SetInterspersed(false)
+}
@@ -0,0 +1,663 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/query"
+	"github.com/imerr0rlog/CipherHub/internal/vault"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var shellLockAfter time.Duration
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Start an interactive shell on the unlocked vault",
+	Long: `Start an interactive shell on the unlocked vault.
+
+The vault is opened once; then entries can be managed with ls, get, add,
+edit, rm, search and gen. Press Tab to complete commands and entry names,
+and the arrow keys to browse the history.
+
+Secrets are never accepted as command arguments and never enter the
+history: passwords are always read with a hidden prompt. The vault is
+locked after --lock-after of inactivity and the master password is asked
+again for the next command.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return fmt.Errorf("shell requires an interactive terminal")
+		}
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, oldState)
+
+		sh := newShell(mgr, struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, shellLockAfter)
+		if w, h, err := term.GetSize(fd); err == nil && w > 0 {
+			sh.term.SetSize(w, h)
+		}
+		return sh.run()
+	},
+}
+
+// shellCommand 描述 shell 中的一个命令
+type shellCommand struct {
+	usage      string
+	help       string
+	entryArg   bool // 第一个参数是否为条目名称（用于补全）
+	needsVault bool
+	run        func(sh *shell, args []string) error
+}
+
+var shellCommands map[string]*shellCommand
+
+// shell 是交互式会话的状态
+type shell struct {
+	term      *term.Terminal
+	rw        io.ReadWriter
+	lockAfter time.Duration
+
+	// mu 保护 mgr、locked 和 busy，自动锁定在计时器的 goroutine 中执行
+	mu     sync.Mutex
+	mgr    *vault.Manager
+	locked bool
+	busy   bool // 正在执行命令时不自动锁定
+	timer  *time.Timer
+}
+
+func newShell(mgr *vault.Manager, rw io.ReadWriter, lockAfter time.Duration) *shell {
+	sh := &shell{
+		term:      term.NewTerminal(rw, "cipherhub> "),
+		rw:        rw,
+		lockAfter: lockAfter,
+		mgr:       mgr,
+	}
+	sh.term.AutoCompleteCallback = sh.complete
+	return sh
+}
+
+// run 循环读取并执行命令，直到 exit 或 Ctrl-D
+func (sh *shell) run() error {
+	sh.println("CipherHub shell. Type 'help' for commands, 'exit' to quit.")
+	sh.resetTimer()
+	defer sh.stopTimer()
+
+	for {
+		line, err := sh.term.ReadLine()
+		if err == io.EOF {
+			sh.println("")
+			return nil
+		}
+		if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+			return err
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			sh.printf("Error: %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+
+		sh.setBusy(true)
+		if err := sh.exec(args); err != nil {
+			sh.printf("Error: %v\n", err)
+		}
+		sh.setBusy(false)
+		sh.resetTimer()
+	}
+}
+
+// exec 执行一条命令，必要时先重新解锁密码库
+func (sh *shell) exec(args []string) error {
+	c, ok := shellCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, type 'help' for a list", args[0])
+	}
+
+	if c.needsVault {
+		sh.mu.Lock()
+		locked := sh.locked
+		sh.mu.Unlock()
+		if locked {
+			if err := unlockWith(sh.mgr, sh.readPassword); err != nil {
+				return fmt.Errorf("failed to unlock vault: %w", err)
+			}
+			sh.mu.Lock()
+			sh.locked = false
+			sh.mu.Unlock()
+		}
+	}
+
+	return c.run(sh, args[1:])
+}
+
+func (sh *shell) setBusy(busy bool) {
+	sh.mu.Lock()
+	sh.busy = busy
+	sh.mu.Unlock()
+}
+
+// resetTimer 重新开始计算空闲时间
+func (sh *shell) resetTimer() {
+	if sh.lockAfter <= 0 {
+		return
+	}
+	sh.stopTimer()
+	sh.timer = time.AfterFunc(sh.lockAfter, sh.autoLock)
+}
+
+func (sh *shell) stopTimer() {
+	if sh.timer != nil {
+		sh.timer.Stop()
+		sh.timer = nil
+	}
+}
+
+// autoLock 在空闲超时后关闭密码库，清除内存中的密钥
+func (sh *shell) autoLock() {
+	sh.mu.Lock()
+	if sh.locked || sh.busy {
+		sh.mu.Unlock()
+		return
+	}
+	sh.mgr.Close()
+	sh.locked = true
+	sh.mu.Unlock()
+
+	sh.printf("\nVault locked after %s of inactivity\n", sh.lockAfter)
+}
+
+func (sh *shell) printf(format string, a ...interface{}) {
+	fmt.Fprintf(sh.term, format, a...)
+}
+
+func (sh *shell) println(s string) {
+	fmt.Fprintln(sh.term, s)
+}
+
+// readPassword 读取不回显、不记录历史的输入
+func (sh *shell) readPassword(prompt string) (string, error) {
+	return sh.term.ReadPassword(prompt)
+}
+
+// readField 读取一行普通输入
+//
+// 使用独立的 Terminal 读取，输入内容不会进入命令历史。
+func (sh *shell) readField(prompt string) (string, error) {
+	line, err := term.NewTerminal(sh.rw, prompt).ReadLine()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// confirm 询问是否继续
+func (sh *shell) confirm(prompt string) bool {
+	answer, err := sh.readField(prompt + " [y/N]: ")
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+// complete 在按下 Tab 时补全命令名称或条目名称
+func (sh *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	head := line[:pos]
+	words := strings.Fields(head)
+	atWordStart := len(words) == 0 || strings.HasSuffix(head, " ")
+
+	var prefix string
+	var candidates []string
+	switch {
+	case len(words) == 0 || (len(words) == 1 && !atWordStart):
+		if len(words) == 1 {
+			prefix = words[0]
+		}
+		for name := range shellCommands {
+			candidates = append(candidates, name)
+		}
+	case (len(words) == 1 && atWordStart) || (len(words) == 2 && !atWordStart):
+		c, ok := shellCommands[words[0]]
+		if !ok || !c.entryArg {
+			return "", 0, false
+		}
+		if !atWordStart {
+			prefix = words[1]
+		}
+		candidates = sh.entryNames()
+	default:
+		return "", 0, false
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefix(matches)
+	if len(matches) == 1 {
+		if strings.ContainsAny(completion, " \t") {
+			completion = strconv.Quote(completion)
+		}
+		completion += " "
+	}
+	if completion == prefix {
+		return "", 0, false
+	}
+
+	start := pos - len(prefix)
+	newLine := line[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
+
+// entryNames 返回当前密码库中的条目名称，密码库锁定时返回空
+func (sh *shell) entryNames() []string {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.locked {
+		return nil
+	}
+	entries, err := sh.mgr.ListEntries()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Path())
+		names = append(names, e.Aliases...)
+	}
+	return names
+}
+
+// commonPrefix 返回字符串列表的最长公共前缀
+func commonPrefix(list []string) string {
+	prefix := list[0]
+	for _, s := range list[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// splitArgs 按空白拆分命令行，支持单引号和双引号
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// parseShellFlags 从参数中分离出布尔开关（如 -p、--copy），其余作为位置参数返回
+func parseShellFlags(args []string, known map[string]string) (map[string]bool, []string, error) {
+	flags := make(map[string]bool)
+	var rest []string
+	for _, a := range args {
+		if strings.HasPrefix(a, "-") {
+			name, ok := known[a]
+			if !ok {
+				return nil, nil, fmt.Errorf("unknown flag %s", a)
+			}
+			flags[name] = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+	return flags, rest, nil
+}
+
+func shellList(sh *shell, args []string) error {
+	entries, err := sh.mgr.ListEntries()
+	if err != nil {
+		return err
+	}
+	var shown []*types.Entry
+	for _, e := range entries {
+		if !e.Archived {
+			shown = append(shown, e)
+		}
+	}
+	sh.printEntries(shown)
+	return nil
+}
+
+func shellSearch(sh *shell, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: search <query>")
+	}
+	entries, err := sh.mgr.Query(strings.Join(args, " "), query.Options{})
+	if err != nil {
+		return err
+	}
+	sh.printEntries(entries)
+	return nil
+}
+
+// printEntries 以表格形式输出条目
+func (sh *shell) printEntries(entries []*types.Entry) {
+	if len(entries) == 0 {
+		sh.println("No entries found")
+		return
+	}
+	sorted := make([]*types.Entry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Favorite != sorted[j].Favorite {
+			return sorted[i].Favorite
+		}
+		return sorted[i].Path() < sorted[j].Path()
+	})
+
+	w := tabwriter.NewWriter(sh.term, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tUSERNAME\tURL\tUPDATED")
+	for _, e := range sorted {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Path()+entryMarks(e), e.Username, e.URL, e.UpdatedAt.Format("2006-01-02"))
+	}
+	w.Flush()
+}
+
+func shellGet(sh *shell, args []string) error {
+	flags, rest, err := parseShellFlags(args, map[string]string{
+		"-p": "password", "--password": "password",
+		"-n": "notes", "--notes": "notes",
+		"-c": "copy", "--copy": "copy",
+	})
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errors.New("usage: get <name> [-p] [-n] [-c]")
+	}
+	name := rest[0]
+
+	entry, err := sh.mgr.GetEntry(name)
+	if err != nil {
+		return err
+	}
+
+	sh.printf("Name:     %s\n", entry.Path())
+	sh.printf("Username: %s\n", entry.Username)
+	sh.printf("URL:      %s\n", entry.URL)
+	sh.printf("Updated:  %s\n", entry.UpdatedAt.Format("2006-01-02 15:04"))
+	if len(entry.Tags) > 0 {
+		sh.printf("Tags:     %s\n", strings.Join(entry.Tags, ", "))
+	}
+
+	if flags["password"] {
+		password, err := sh.mgr.GetDecryptedPassword(name)
+		if err != nil {
+			return err
+		}
+		sh.printf("Password: %s\n", password)
+	}
+	if flags["notes"] && entry.Notes != "" {
+		notes, err := sh.mgr.GetDecryptedNotes(name)
+		if err != nil {
+			return err
+		}
+		sh.printf("Notes:    %s\n", notes)
+	}
+	if flags["copy"] {
+		password, err := sh.mgr.GetDecryptedPassword(name)
+		if err != nil {
+			return err
+		}
+		return copyToClipboardTo(sh.term, "password", password)
+	}
+	return nil
+}
+
+func shellAdd(sh *shell, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: add <name>")
+	}
+	name := args[0]
+
+	username, err := sh.readField("Username: ")
+	if err != nil {
+		return err
+	}
+	password, err := sh.readPassword("Password (leave blank to generate): ")
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		if password, err = types.SecureRandomString(20); err != nil {
+			return err
+		}
+		sh.println("Generated a random 20-character password")
+	}
+	url, err := sh.readField("URL: ")
+	if err != nil {
+		return err
+	}
+	notes, err := sh.readPassword("Notes (hidden, optional): ")
+	if err != nil {
+		return err
+	}
+	tagLine, err := sh.readField("Tags (comma-separated): ")
+	if err != nil {
+		return err
+	}
+
+	entry, err := sh.mgr.AddEntry(name, username, password, url, notes, types.ParseTags(tagLine))
+	if err != nil {
+		return err
+	}
+	sh.printf("✓ Entry '%s' added\n", entry.Path())
+	return nil
+}
+
+func shellEdit(sh *shell, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: edit <name>")
+	}
+	name := args[0]
+
+	entry, err := sh.mgr.GetEntry(name)
+	if err != nil {
+		return err
+	}
+
+	sh.println("Leave a field blank to keep its current value.")
+	updates := make(map[string]string)
+
+	username, err := sh.readField(fmt.Sprintf("Username [%s]: ", entry.Username))
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		updates["username"] = username
+	}
+	password, err := sh.readPassword("New password: ")
+	if err != nil {
+		return err
+	}
+	if password != "" {
+		updates["password"] = password
+	}
+	url, err := sh.readField(fmt.Sprintf("URL [%s]: ", entry.URL))
+	if err != nil {
+		return err
+	}
+	if url != "" {
+		updates["url"] = url
+	}
+	notes, err := sh.readPassword("New notes (hidden): ")
+	if err != nil {
+		return err
+	}
+	if notes != "" {
+		updates["notes"] = notes
+	}
+
+	if len(updates) == 0 {
+		sh.println("Nothing changed")
+		return nil
+	}
+	if entry, err = sh.mgr.UpdateEntry(name, updates); err != nil {
+		return err
+	}
+	sh.printf("✓ Entry '%s' updated\n", entry.Path())
+	return nil
+}
+
+func shellRemove(sh *shell, args []string) error {
+	flags, rest, err := parseShellFlags(args, map[string]string{"-f": "force", "--force": "force"})
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errors.New("usage: rm <name> [-f]")
+	}
+	name := rest[0]
+
+	entry, err := sh.mgr.GetEntry(name)
+	if err != nil {
+		return err
+	}
+	if !flags["force"] && !sh.confirm(fmt.Sprintf("Delete '%s' (%s)?", entry.Path(), entry.Username)) {
+		sh.println("Cancelled")
+		return nil
+	}
+	if err := sh.mgr.DeleteEntry(entry.ID); err != nil {
+		return err
+	}
+	if sh.mgr.TrashRetention() > 0 {
+		sh.printf("✓ Entry '%s' moved to the trash\n", entry.Path())
+		return nil
+	}
+	sh.printf("✓ Entry '%s' deleted\n", entry.Path())
+	return nil
+}
+
+func shellGenerate(sh *shell, args []string) error {
+	length := 16
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return errors.New("usage: gen [length]")
+		}
+		length = n
+	}
+	password, err := types.SecureRandomString(length)
+	if err != nil {
+		return err
+	}
+	sh.println(password)
+	return nil
+}
+
+func shellLock(sh *shell, args []string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if !sh.locked {
+		sh.mgr.Close()
+		sh.locked = true
+	}
+	fmt.Fprintln(sh.term, "✓ Vault locked")
+	return nil
+}
+
+func shellHelp(sh *shell, args []string) error {
+	names := make([]string, 0, len(shellCommands))
+	for name := range shellCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(sh.term, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		c := shellCommands[name]
+		fmt.Fprintf(w, "  %s\t%s\n", c.usage, c.help)
+	}
+	fmt.Fprintf(w, "  exit\tLeave the shell\n")
+	w.Flush()
+	return nil
+}
+
+func init() {
+	shellCmd.Flags().DurationVar(&shellLockAfter, "lock-after", 5*time.Minute, "lock the vault after this much inactivity (0 = never)")
+
+	shellCommands = map[string]*shellCommand{
+		"ls":     {usage: "ls", help: "List all entries except archived ones", needsVault: true, run: shellList},
+		"search": {usage: "search <query>", help: "Search entries (same query syntax as list --search)", needsVault: true, run: shellSearch},
+		"get":    {usage: "get <name> [-p] [-n] [-c]", help: "Show an entry (-p password, -n notes, -c copy password)", entryArg: true, needsVault: true, run: shellGet},
+		"add":    {usage: "add <name>", help: "Add an entry (fields are prompted)", needsVault: true, run: shellAdd},
+		"edit":   {usage: "edit <name>", help: "Edit an entry (fields are prompted)", entryArg: true, needsVault: true, run: shellEdit},
+		"rm":     {usage: "rm <name> [-f]", help: "Delete an entry", entryArg: true, needsVault: true, run: shellRemove},
+		"gen":    {usage: "gen [length]", help: "Generate a random password", run: shellGenerate},
+		"lock":   {usage: "lock", help: "Lock the vault now", run: shellLock},
+		"help":   {usage: "help", help: "Show this help", run: shellHelp},
+	}
+}
@@ -5,9 +5,15 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
 
 	"github.com/imerr0rlog/CipherHub/internal/storage"
 	"github.com/imerr0rlog/CipherHub/internal/vault"
@@ -28,8 +34,11 @@ var syncCmd = &cobra.Command{
 	Long: `Sync vault and config with WebDAV cloud storage.
 
 By default, sync pushes both vault.json and config.json to remote.
-Use --pull to download from remote.
-Use --vault-only or --config-only to sync a single file.`,
+Use --pull to download from remote. Syncing the vault also copies the
+attachment blobs it references that the other side does not have yet.
+Use --vault-only or --config-only to sync a single file.
+
+Press Ctrl-C at any time to cancel the transfer in progress.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if cfg.WebDAV == nil || cfg.WebDAV.URL == "" {
 			return fmt.Errorf("WebDAV not configured. Run 'cipherhub config --webdav-url <url>' first")
@@ -39,194 +48,332 @@ Use --vault-only or --config-only to sync a single file.`,
 			return fmt.Errorf("cannot use --vault-only and --config-only together")
 		}
 
-		webdavStorage := storage.NewWebDAVStorage(cfg.WebDAV)
-		if err := webdavStorage.Connect(); err != nil {
-			return fmt.Errorf("failed to connect to WebDAV: %w", err)
+		syncVault := !syncConfigOnly
+		syncConfig := !syncVaultOnly
+
+		// 在发起任何网络请求之前完成交互式输入，之后的 Ctrl-C 只用于取消传输
+		var localVault *vault.Manager
+		if syncPull {
+			if !syncForce && !confirmPull(syncVault, syncConfig) {
+				statusf("Cancelled\n")
+				return nil
+			}
+		} else if syncVault {
+			exists, err := storage.NewLocalStorage(cfg.VaultPath).Exists(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("local vault not found at %s. Run 'cipherhub init' first: %w", cfg.VaultPath, storage.ErrStorageNotFound)
+			}
+			localVault, err = openLocalVault(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to open vault: %w", err)
+			}
+			defer localVault.Close()
 		}
 
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		result := &syncResult{Direction: "push"}
 		if syncPull {
-			return doPull(webdavStorage)
+			result.Direction = "pull"
+		}
+		err := runSync(ctx, syncVault, syncConfig, localVault, result)
+		if err != nil && ctx.Err() != nil && errors.Is(err, context.Canceled) {
+			return fmt.Errorf("sync cancelled")
 		}
-		return doPush(webdavStorage)
+		if err != nil {
+			return err
+		}
+		// 文本模式下每一步的结果已经输出
+		return render(result, func() error { return nil })
 	},
 }
 
-func doPush(webdavStorage *storage.WebDAVStorage) error {
-	syncVault := !syncConfigOnly
-	syncConfig := !syncVaultOnly
+func runSync(ctx context.Context, syncVault, syncConfig bool, localVault *vault.Manager, result *syncResult) error {
+	webdavStorage, err := storage.NewWebDAVStorage(cfg.WebDAV)
+	if err != nil {
+		return err
+	}
+	if err := webdavStorage.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to WebDAV: %w", err)
+	}
 
+	if syncPull {
+		return doPull(ctx, webdavStorage, syncVault, syncConfig, result)
+	}
+	return doPush(ctx, webdavStorage, syncVault, syncConfig, localVault, result)
+}
+
+func doPush(ctx context.Context, webdavStorage *storage.WebDAVStorage, syncVault, syncConfig bool, localVault *vault.Manager, result *syncResult) error {
 	if syncVault {
-		if err := pushVault(webdavStorage); err != nil {
+		// 先推送附件的数据块，使远程密码库引用的数据块都已存在
+		item, err := pushAttachments(ctx, localVault)
+		if err != nil {
 			return err
 		}
+		result.Files = append(result.Files, item)
+
+		item, err = pushVault(ctx, webdavStorage, localVault)
+		if err != nil {
+			return err
+		}
+		result.Files = append(result.Files, item)
 	}
 
 	if syncConfig {
-		if err := pushConfig(webdavStorage); err != nil {
+		item, err := pushConfig(ctx)
+		if err != nil {
 			return err
 		}
+		result.Files = append(result.Files, item)
 	}
 
 	return nil
 }
 
-func pushVault(webdavStorage *storage.WebDAVStorage) error {
-	localStorage := storage.NewLocalStorage(cfg.VaultPath)
-	if !localStorage.Exists() {
-		return fmt.Errorf("local vault not found at %s. Run 'cipherhub init' first", cfg.VaultPath)
+// openLocalVault 打开本地密码库，用于推送到远程
+func openLocalVault(ctx context.Context) (*vault.Manager, error) {
+	localStorage, err := storage.BuildPipeline(storage.NewLocalStorage(cfg.VaultPath), cfg.StoragePipeline)
+	if err != nil {
+		return nil, err
 	}
 
-	masterPassword, err := promptPassword("Enter master password: ")
+	mgr := vault.NewManager(localStorage)
+	mgr.SetContext(ctx)
+	mgr.SetBlobStore(localBlobStore())
+	if err := unlock(mgr); err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
+func pushVault(ctx context.Context, webdavStorage *storage.WebDAVStorage, localVault *vault.Manager) (*syncItem, error) {
+	remoteStorage, err := storage.BuildPipeline(storage.WithConfiguredRetry(cfg.WebDAV)(webdavStorage), cfg.StoragePipeline)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	mgr := vault.NewManager(localStorage)
-	if err := mgr.Open(masterPassword); err != nil {
-		return fmt.Errorf("failed to open vault: %w", err)
+	if err := localVault.SyncContext(ctx, remoteStorage); err != nil {
+		return nil, fmt.Errorf("failed to sync vault: %w", err)
 	}
-	defer mgr.Close()
 
-	if err := mgr.Sync(webdavStorage); err != nil {
-		return fmt.Errorf("failed to sync vault: %w", err)
+	statusf("✓ Vault pushed to WebDAV\n")
+	return &syncItem{File: "vault", Status: "pushed", Remote: cfg.WebDAV.RemotePath}, nil
+}
+
+// pushAttachments 将本地附件的数据块推送到远程
+func pushAttachments(ctx context.Context, localVault *vault.Manager) (*syncItem, error) {
+	copied, err := localVault.PushAttachments(ctx, remoteBlobStore())
+	return attachmentsItem("pushed", copied, err)
+}
+
+// pullAttachments 将远程密码库 data 引用的附件数据块拉取到本地
+func pullAttachments(ctx context.Context, data []byte) (*syncItem, error) {
+	var remoteVault types.Vault
+	if err := json.Unmarshal(data, &remoteVault); err != nil {
+		return nil, fmt.Errorf("failed to parse remote vault: %w", vault.ErrVaultCorrupted)
 	}
+	copied, err := vault.SyncAttachments(ctx, &remoteVault, remoteBlobStore(), localBlobStore())
+	return attachmentsItem("pulled", copied, err)
+}
 
-	fmt.Println("✓ Vault pushed to WebDAV")
-	return nil
+// attachmentsItem 返回附件同步的结果
+//
+// 源中缺少数据块的附件只输出警告，密码库仍然同步；其他错误原样返回。
+func attachmentsItem(status string, copied int, err error) (*syncItem, error) {
+	item := &syncItem{File: "attachments", Status: status, Remote: path.Join(path.Dir(cfg.WebDAV.RemotePath), storage.AttachmentsDir)}
+	if err != nil && !errors.Is(err, vault.ErrAttachmentCorrupted) {
+		return nil, fmt.Errorf("failed to sync attachments: %w", err)
+	}
+	if copied > 0 {
+		direction := "to"
+		if status == "pulled" {
+			direction = "from"
+		}
+		statusf("✓ %d attachment blob(s) %s %s WebDAV\n", copied, status, direction)
+	}
+	if err != nil {
+		problems := strings.Split(err.Error(), "\n")
+		for _, problem := range problems {
+			statusf("⚠ %s\n", problem)
+		}
+		statusf("  These attachments cannot be read; remove them with 'cipherhub attach rm'\n")
+		item.Status = "incomplete"
+		item.Reason = strings.Join(problems, "; ")
+	}
+	return item, nil
 }
 
-func pushConfig(webdavStorage *storage.WebDAVStorage) error {
+// localBlobStore 返回本地附件数据块的存储
+func localBlobStore() vault.BlobStore {
+	local := *cfg
+	local.DefaultStorage = types.StorageTypeLocal
+	return func(name string) (storage.Storage, error) {
+		return storage.NewBlobStorage(&local, name)
+	}
+}
+
+// remoteBlobStore 返回 WebDAV 上附件数据块的存储
+func remoteBlobStore() vault.BlobStore {
+	remote := *cfg
+	remote.DefaultStorage = types.StorageTypeWebDAV
+	return func(name string) (storage.Storage, error) {
+		return storage.NewBlobStorage(&remote, name)
+	}
+}
+
+func pushConfig(ctx context.Context) (*syncItem, error) {
 	if cfg.WebDAV.ConfigRemotePath == "" {
-		fmt.Println("⚠ Config remote path not set, skipping config sync")
-		fmt.Println("  Run: cipherhub config --webdav-config-path /path/config.json")
-		return nil
+		statusf("⚠ Config remote path not set, skipping config sync\n")
+		statusf("  Run: cipherhub config --webdav-config-path /path/config.json\n")
+		return &syncItem{File: "config", Status: "skipped", Reason: "config remote path not set"}, nil
 	}
 
 	configData, err := os.ReadFile(cfgPath)
 	if err != nil {
-		return fmt.Errorf("failed to read config: %w", err)
+		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	configStorage := storage.NewWebDAVStorage(&types.WebDAVConfig{
-		URL:                cfg.WebDAV.URL,
-		Username:           cfg.WebDAV.Username,
-		Password:           cfg.WebDAV.Password,
-		RemotePath:         cfg.WebDAV.ConfigRemotePath,
-		InsecureSkipVerify: cfg.WebDAV.InsecureSkipVerify,
-	})
-	if err := configStorage.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to WebDAV for config: %w", err)
+	configStorage, err := storage.NewWebDAVStorage(cfg.WebDAV.WithRemotePath(cfg.WebDAV.ConfigRemotePath))
+	if err != nil {
+		return nil, err
+	}
+	if err := configStorage.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to WebDAV for config: %w", err)
 	}
 
-	if err := configStorage.Write(configData); err != nil {
-		return fmt.Errorf("failed to sync config: %w", err)
+	if err := configStorage.Write(ctx, configData); err != nil {
+		return nil, fmt.Errorf("failed to sync config: %w", err)
 	}
 
-	fmt.Println("✓ Config pushed to WebDAV")
-	return nil
+	statusf("✓ Config pushed to WebDAV\n")
+	return &syncItem{File: "config", Status: "pushed", Remote: cfg.WebDAV.ConfigRemotePath}, nil
 }
 
-func doPull(webdavStorage *storage.WebDAVStorage) error {
-	syncVault := !syncConfigOnly
-	syncConfig := !syncVaultOnly
-
-	if !syncForce {
-		targets := []string{}
-		if syncVault {
-			targets = append(targets, "vault")
-		}
-		if syncConfig {
-			targets = append(targets, "config")
-		}
-		if len(targets) == 1 {
-			fmt.Printf("This will overwrite local %s. Continue? [y/N]: ", targets[0])
-		} else {
-			fmt.Printf("This will overwrite local %s and %s. Continue? [y/N]: ", targets[0], targets[1])
-		}
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Cancelled")
-			return nil
-		}
+// confirmPull 在拉取前确认是否覆盖本地文件
+func confirmPull(syncVault, syncConfig bool) bool {
+	targets := []string{}
+	if syncVault {
+		targets = append(targets, "vault")
+	}
+	if syncConfig {
+		targets = append(targets, "config")
+	}
+	if len(targets) == 1 {
+		statusf("This will overwrite local %s. Continue? [y/N]: ", targets[0])
+	} else {
+		statusf("This will overwrite local %s and %s. Continue? [y/N]: ", targets[0], targets[1])
 	}
+	response, _ := readLine()
+	response = strings.TrimSpace(response)
+	return response == "y" || response == "Y"
+}
 
+func doPull(ctx context.Context, webdavStorage *storage.WebDAVStorage, syncVault, syncConfig bool, result *syncResult) error {
 	if syncVault {
-		if err := pullVault(webdavStorage); err != nil {
+		items, err := pullVault(ctx, webdavStorage)
+		if err != nil {
 			return err
 		}
+		result.Files = append(result.Files, items...)
 	}
 
 	if syncConfig {
-		if err := pullConfig(); err != nil {
+		item, err := pullConfig(ctx)
+		if err != nil {
 			return err
 		}
+		result.Files = append(result.Files, item)
 	}
 
 	return nil
 }
 
-func pullVault(webdavStorage *storage.WebDAVStorage) error {
-	if !webdavStorage.Exists() {
-		return fmt.Errorf("no remote vault found at %s", cfg.WebDAV.RemotePath)
+// pullVault 拉取远程密码库及其引用的附件数据块
+//
+// 先拉取数据块再替换本地密码库，使本地密码库引用的数据块都已存在。
+func pullVault(ctx context.Context, webdavStorage *storage.WebDAVStorage) ([]*syncItem, error) {
+	exists, err := webdavStorage.Exists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check remote vault: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("no remote vault found at %s: %w", cfg.WebDAV.RemotePath, storage.ErrStorageNotFound)
 	}
 
-	data, err := webdavStorage.Read()
+	remoteStorage, err := storage.BuildPipeline(storage.WithConfiguredRetry(cfg.WebDAV)(webdavStorage), cfg.StoragePipeline)
 	if err != nil {
-		return fmt.Errorf("failed to read remote vault: %w", err)
+		return nil, err
+	}
+	localStorage, err := storage.BuildPipeline(storage.NewLocalStorage(cfg.VaultPath), cfg.StoragePipeline)
+	if err != nil {
+		return nil, err
 	}
 
-	localStorage := storage.NewLocalStorage(cfg.VaultPath)
-	if err := localStorage.Write(data); err != nil {
-		return fmt.Errorf("failed to save local vault: %w", err)
+	data, err := remoteStorage.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote vault: %w", err)
 	}
 
-	fmt.Println("✓ Vault pulled from WebDAV")
-	return nil
+	attachments, err := pullAttachments(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := localStorage.Write(ctx, data); err != nil {
+		return nil, fmt.Errorf("failed to save local vault: %w", err)
+	}
+
+	statusf("✓ Vault pulled from WebDAV\n")
+	return []*syncItem{attachments, {File: "vault", Status: "pulled", Remote: cfg.WebDAV.RemotePath}}, nil
 }
 
-func pullConfig() error {
+func pullConfig(ctx context.Context) (*syncItem, error) {
 	if cfg.WebDAV.ConfigRemotePath == "" {
-		fmt.Println("⚠ Config remote path not set, skipping config pull")
-		return nil
+		statusf("⚠ Config remote path not set, skipping config pull\n")
+		return &syncItem{File: "config", Status: "skipped", Reason: "config remote path not set"}, nil
 	}
 
-	configStorage := storage.NewWebDAVStorage(&types.WebDAVConfig{
-		URL:                cfg.WebDAV.URL,
-		Username:           cfg.WebDAV.Username,
-		Password:           cfg.WebDAV.Password,
-		RemotePath:         cfg.WebDAV.ConfigRemotePath,
-		InsecureSkipVerify: cfg.WebDAV.InsecureSkipVerify,
-	})
-	if err := configStorage.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to WebDAV for config: %w", err)
+	configStorage, err := storage.NewWebDAVStorage(cfg.WebDAV.WithRemotePath(cfg.WebDAV.ConfigRemotePath))
+	if err != nil {
+		return nil, err
+	}
+	if err := configStorage.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to WebDAV for config: %w", err)
 	}
 
-	if !configStorage.Exists() {
-		return fmt.Errorf("no remote config found at %s", cfg.WebDAV.ConfigRemotePath)
+	exists, err := configStorage.Exists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check remote config: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("no remote config found at %s: %w", cfg.WebDAV.ConfigRemotePath, storage.ErrStorageNotFound)
 	}
 
-	data, err := configStorage.Read()
+	data, err := configStorage.Read(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to read remote config: %w", err)
+		return nil, fmt.Errorf("failed to read remote config: %w", err)
 	}
 
 	var newCfg types.Config
 	if err := json.Unmarshal(data, &newCfg); err != nil {
-		return fmt.Errorf("invalid config format: %w", err)
+		return nil, fmt.Errorf("invalid config format: %w", err)
 	}
 
 	if err := os.WriteFile(cfgPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to save local config: %w", err)
+		return nil, fmt.Errorf("failed to save local config: %w", err)
 	}
 
-	fmt.Println("✓ Config pulled from WebDAV")
-	return nil
+	statusf("✓ Config pulled from WebDAV\n")
+	return &syncItem{File: "config", Status: "pulled", Remote: cfg.WebDAV.ConfigRemotePath}, nil
 }
 
 func init() {
 	syncCmd.Flags().BoolVar(&syncPull, "pull", false, "pull from remote to local")
 	syncCmd.Flags().BoolVarP(&syncForce, "force", "f", false, "force overwrite without confirmation")
-	syncCmd.Flags().BoolVar(&syncVaultOnly, "vault-only", false, "sync only vault.json file")
+	syncCmd.Flags().BoolVar(&syncVaultOnly, "vault-only", false, "sync only vault.json file and its attachments")
 	syncCmd.Flags().BoolVar(&syncConfigOnly, "config-only", false, "sync only config.json file")
 }
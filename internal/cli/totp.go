@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var totpCopy bool
+
+var totpCmd = &cobra.Command{
+	Use:   "totp <name>",
+	Short: "Show the current TOTP code of an entry",
+	Long: `Show the current time-based one-time password (TOTP) of an entry
+and how many seconds it remains valid.
+
+Attach a TOTP secret to an entry with 'cipherhub add --totp' or
+'cipherhub update --totp', using the otpauth:// URI from the QR code or
+the base32 secret shown by the service. The secret is stored encrypted.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		config, err := mgr.GetTOTP(name)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		code, err := config.Code(now)
+		if err != nil {
+			return err
+		}
+		remaining := int(config.Remaining(now).Seconds())
+
+		err = render(&totpOutput{Code: code, Remaining: remaining, Period: config.Period}, func() error {
+			fmt.Printf("%s (%ds remaining)\n", code, remaining)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if totpCopy {
+			return copyToClipboard("totp", code)
+		}
+		return nil
+	},
+}
+
+func init() {
+	totpCmd.Flags().BoolVarP(&totpCopy, "copy", "c", false, "copy the code to the clipboard")
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var trashEmptyForce bool
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore or permanently delete deleted entries",
+	Long: `Manage the trash. 'cipherhub delete' moves entries to the trash, where
+they stay, with their attachments, for the configured number of days
+(30 by default, see 'cipherhub config --trash-days') before they are
+deleted permanently. With --trash-days 0, deleted entries skip the trash;
+entries already in it are kept until 'cipherhub trash empty'.
+
+Entries in the trash are not listed, searched or usable by other commands
+until they are restored. Refer to them by their original path, ID, ID
+prefix or alias.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the entries in the trash",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		entries, err := mgr.ListTrash()
+		if err != nil {
+			return err
+		}
+
+		out := make(trashList, 0, len(entries))
+		for _, e := range entries {
+			o := trashOutput{
+				ID:       e.ID,
+				Path:     e.Path(),
+				Username: e.Username,
+			}
+			if e.DeletedAt != nil {
+				o.DeletedAt = *e.DeletedAt
+				if retention := mgr.TrashRetention(); retention > 0 {
+					purgeAt := e.DeletedAt.Add(retention)
+					o.PurgeAt = &purgeAt
+				}
+			}
+			out = append(out, o)
+		}
+
+		return render(out, func() error {
+			if len(out) == 0 {
+				fmt.Println("Trash is empty")
+				return nil
+			}
+
+			now := time.Now()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tID\tUSERNAME\tDELETED\tPURGED")
+			for i, o := range out {
+				purge := "when emptied"
+				if o.PurgeAt != nil {
+					purge = "today"
+					if days := daysBetween(now, *o.PurgeAt); days > 0 {
+						purge = "in " + pluralDays(days)
+					}
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", o.Path, entries[i].ShortID(), o.Username, o.DeletedAt.Format("2006-01-02"), purge)
+			}
+			w.Flush()
+			return nil
+		})
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <name> [destination]",
+	Short: "Restore an entry from the trash",
+	Long: `Restore an entry from the trash to its original path, or to the
+destination if given. As with 'cipherhub mv', a destination that ends with
+'/' or is an existing folder keeps the entry's name.
+
+Restoring fails if another entry now uses the path; restore it elsewhere
+or move the other entry first. Aliases that another entry took in the
+meantime are dropped.
+
+Examples:
+  cipherhub trash restore github
+  cipherhub trash restore 3f9a2c1e github-old   # by ID prefix, renamed
+  cipherhub trash restore github personal/      # into a folder`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var dest string
+		if len(args) == 2 {
+			dest = args[1]
+		}
+
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		entry, err := mgr.RestoreEntry(args[0], dest)
+		if err != nil {
+			return fmt.Errorf("failed to restore entry: %w", err)
+		}
+		return render(newEntryOutput(entry), func() error {
+			fmt.Printf("✓ Entry '%s' restored\n", entry.Path())
+			return nil
+		})
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete all entries in the trash",
+	Long: `Permanently delete all entries in the trash, including their
+attachments. This action is irreversible. Use --force to skip confirmation.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		entries, err := mgr.ListTrash()
+		if err != nil {
+			return err
+		}
+
+		if !trashEmptyForce && len(entries) > 0 {
+			statusf("Permanently delete %d entries in the trash? [y/N]: ", len(entries))
+			response, _ := readLine()
+			response = strings.TrimSpace(strings.ToLower(response))
+
+			if response != "y" && response != "yes" {
+				statusf("Cancelled\n")
+				return nil
+			}
+		}
+
+		removed, err := mgr.EmptyTrash()
+		if err != nil {
+			return fmt.Errorf("failed to empty trash: %w", err)
+		}
+		return render(valueOutput{"removed": fmt.Sprint(removed)}, func() error {
+			fmt.Printf("✓ %d entries permanently deleted\n", removed)
+			return nil
+		})
+	},
+}
+
+func init() {
+	trashEmptyCmd.Flags().BoolVarP(&trashEmptyForce, "force", "f", false, "skip confirmation")
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd, trashEmptyCmd)
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse and edit entries in a full-screen terminal UI",
+	Long: `Browse and edit entries in a full-screen terminal UI.
+
+The screen shows a tag sidebar, the entry list and the details of the
+selected entry. Passwords and notes stay masked until revealed with 'r'.
+Press '/' to filter entries with fuzzy matching, 'a' and 'e' to add or edit
+an entry, 'c' to copy the password and '?' for all key bindings.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mgr, err := unlockVault()
+		if err != nil {
+			return fmt.Errorf("failed to open vault: %w", err)
+		}
+		defer mgr.Close()
+
+		return tui.Run(mgr, tui.Options{
+			ClipboardTimeout: time.Duration(cfg.ClipboardTimeout) * time.Second,
+		})
+	},
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 
+	"github.com/imerr0rlog/CipherHub/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -11,6 +12,13 @@ var (
 	updatePassword string
 	updateURL      string
 	updateNotes    string
+	updateTOTP     string
+	updateFields   []string
+	updateFiles    []string
+	updateType     string
+	updateRestore  int
+	updateRotation string
+	updateExpires  string
 )
 
 var updateCmd = &cobra.Command{
@@ -18,30 +26,54 @@ var updateCmd = &cobra.Command{
 	Short: "Update an existing password entry",
 	Long: `Update an existing password entry in the vault.
 
-You can update one or more fields: username, password, URL, notes.
+You can update one or more fields: username, password, URL, notes and
+the TOTP secret (--totp "" removes it).
+
+The replaced password is kept in the entry's password history (see
+'cipherhub history'). --restore-version N makes version N of the history
+the current password again.
+
+--rotation N requires the password to be changed every N days and
+--expires YYYY-MM-DD sets an expiry date ("" or 0 removes them); see
+'cipherhub due'.
+
+Custom fields are set with --field name[:type]=value or read from a file
+with --field-file name[:type]=path; an existing field keeps its type
+unless one is given, and --field name= removes it. --type changes the
+entry type without touching its fields.
+
 The master password will be prompted for verification.`,
-	Args: cobra.ExactArgs(1),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEntryNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
-		masterPassword, err := promptPassword("Enter master password: ")
+		mgr, err := unlockVault()
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to open vault: %w", err)
 		}
+		defer mgr.Close()
 
-		mgr, err := openVault(masterPassword)
+		entry, err := mgr.GetEntry(name)
 		if err != nil {
-			return fmt.Errorf("failed to open vault: %w", err)
+			return err
 		}
-		defer mgr.Close()
+		tmpl := types.TemplateFor(entry.Kind())
 
 		updates := make(map[string]string)
 		if updateUsername != "" {
 			updates["username"] = updateUsername
 		}
-		if updatePassword != "" {
+		if cmd.Flags().Changed("restore-version") {
+			if updatePassword != "" {
+				return fmt.Errorf("--password and --restore-version cannot be used together")
+			}
+			if updates["password"], err = mgr.GetPasswordVersion(name, updateRestore); err != nil {
+				return err
+			}
+		} else if updatePassword != "" {
 			updates["password"] = updatePassword
-		} else {
+		} else if tmpl.UsesBuiltin("password") {
 			if updatePassword, err = promptPassword("New password (leave blank to keep existing): "); err != nil {
 				return err
 			}
@@ -55,18 +87,53 @@ The master password will be prompted for verification.`,
 		if updateNotes != "" {
 			updates["notes"] = updateNotes
 		}
-
-		if len(updates) == 0 {
-			return fmt.Errorf("no updates specified")
+		if cmd.Flags().Changed("totp") {
+			updates["totp"] = updateTOTP
+		}
+		if cmd.Flags().Changed("type") {
+			updates["type"] = updateType
+		}
+		if cmd.Flags().Changed("rotation") {
+			updates["rotation"] = updateRotation
+		}
+		if cmd.Flags().Changed("expires") {
+			updates["expires"] = updateExpires
 		}
 
-		entry, err := mgr.UpdateEntry(name, updates)
+		if t, ok := updates["type"]; ok {
+			entryType, err := types.ParseEntryType(t)
+			if err != nil {
+				return err
+			}
+			tmpl = types.TemplateFor(entryType)
+		}
+		fields, err := readFieldFlags(updateFields, true, tmpl)
+		if err != nil {
+			return err
+		}
+		fileFields, err := readFieldFileFlags(updateFiles, tmpl)
 		if err != nil {
+			return err
+		}
+		fields = append(fields, fileFields...)
+
+		if len(updates) == 0 && len(fields) == 0 {
+			return fmt.Errorf("no updates specified")
+		}
+		var merged []types.Field
+		if len(fields) > 0 {
+			if merged, err = mgr.MergeFields(name, fields); err != nil {
+				return fmt.Errorf("failed to update fields: %w", err)
+			}
+		}
+		if entry, err = mgr.EditEntry(name, updates, merged); err != nil {
 			return fmt.Errorf("failed to update entry: %w", err)
 		}
 
-		fmt.Printf("✓ Entry '%s' updated successfully\n", entry.Name)
-		return nil
+		return render(newEntryOutput(entry), func() error {
+			fmt.Printf("✓ Entry '%s' updated successfully\n", entry.Path())
+			return nil
+		})
 	},
 }
 
@@ -75,4 +142,11 @@ func init() {
 	updateCmd.Flags().StringVarP(&updatePassword, "password", "p", "", "new password (leave blank to keep existing)")
 	updateCmd.Flags().StringVarP(&updateURL, "url", "U", "", "new URL")
 	updateCmd.Flags().StringVarP(&updateNotes, "notes", "n", "", "new notes")
+	updateCmd.Flags().StringVar(&updateTOTP, "totp", "", "TOTP secret as an otpauth:// URI or base32 key (empty to remove)")
+	updateCmd.Flags().StringArrayVar(&updateFields, "field", nil, "set custom field name[:type]=value, name= removes it (repeatable)")
+	updateCmd.Flags().StringArrayVar(&updateFiles, "field-file", nil, "set custom field from a file as name[:type]=path (repeatable)")
+	updateCmd.Flags().IntVar(&updateRestore, "restore-version", 0, "restore version N of the password history (see 'cipherhub history')")
+	updateCmd.Flags().StringVar(&updateType, "type", "", "new entry type: login, note, card, identity, ssh-key, database, api or wifi")
+	updateCmd.Flags().StringVar(&updateRotation, "rotation", "", "require a password change every N days (0 to remove)")
+	updateCmd.Flags().StringVar(&updateExpires, "expires", "", "expiry date as YYYY-MM-DD (empty to remove)")
 }
\ No newline at end of file
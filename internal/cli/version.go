@@ -15,7 +15,10 @@ var version = "1.0.0"
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("CipherHub v%s\n", version)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return render(valueOutput{"version": version}, func() error {
+			fmt.Printf("CipherHub v%s\n", version)
+			return nil
+		})
 	},
 }
@@ -0,0 +1,168 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// commandBackend 通过外部命令访问剪贴板
+type commandBackend struct {
+	name  string
+	copy  []string
+	paste []string
+	clear []string // 为空时通过复制空内容清除
+}
+
+// commandBackends 列出支持的外部命令
+var commandBackends = map[string]commandBackend{
+	"wl-copy": {
+		name:  "wl-copy",
+		copy:  []string{"wl-copy"},
+		paste: []string{"wl-paste", "--no-newline"},
+		clear: []string{"wl-copy", "--clear"},
+	},
+	"xclip": {
+		name:  "xclip",
+		copy:  []string{"xclip", "-selection", "clipboard", "-in"},
+		paste: []string{"xclip", "-selection", "clipboard", "-out"},
+	},
+	"xsel": {
+		name:  "xsel",
+		copy:  []string{"xsel", "--clipboard", "--input"},
+		paste: []string{"xsel", "--clipboard", "--output"},
+		clear: []string{"xsel", "--clipboard", "--delete"},
+	},
+	"pbcopy": {
+		name:  "pbcopy",
+		copy:  []string{"pbcopy"},
+		paste: []string{"pbpaste"},
+	},
+	"clip": {
+		name:  "clip",
+		copy:  []string{"clip"},
+		paste: []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard"},
+	},
+}
+
+// lookupCommand 返回指定名称的命令后端，命令不存在时返回 ErrUnavailable
+func lookupCommand(name string) (Backend, error) {
+	b, ok := commandBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("clipboard: unknown backend %q", name)
+	}
+	if _, err := exec.LookPath(b.copy[0]); err != nil {
+		return nil, fmt.Errorf("%w: %s not found in PATH", ErrUnavailable, b.copy[0])
+	}
+	return &b, nil
+}
+
+func (b *commandBackend) Name() string {
+	return b.name
+}
+
+// Copy 通过标准输入将数据传给复制命令
+//
+// xclip、wl-copy 等工具会在后台保留进程以提供剪贴板内容，
+// 因此不连接其标准输出，避免等待后台进程退出。
+func (b *commandBackend) Copy(data []byte) error {
+	cmd := exec.Command(b.copy[0], b.copy[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("clipboard: %s failed: %w", b.name, err)
+	}
+	return nil
+}
+
+func (b *commandBackend) Paste() ([]byte, error) {
+	if _, err := exec.LookPath(b.paste[0]); err != nil {
+		return nil, ErrReadUnsupported
+	}
+	out, err := exec.Command(b.paste[0], b.paste[1:]...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("clipboard: %s failed: %w", b.paste[0], err)
+	}
+	return out, nil
+}
+
+func (b *commandBackend) Clear() error {
+	if len(b.clear) == 0 {
+		return b.Copy(nil)
+	}
+	if err := exec.Command(b.clear[0], b.clear[1:]...).Run(); err != nil {
+		return fmt.Errorf("clipboard: %s failed: %w", b.name, err)
+	}
+	return nil
+}
+
+// osc52Backend 通过 OSC 52 终端转义序列写入终端所在机器的剪贴板
+//
+// 终端通常不允许读取剪贴板，因此 Paste 总是返回 ErrReadUnsupported。
+type osc52Backend struct {
+	tty  string
+	tmux bool
+}
+
+// newOSC52 创建 OSC 52 后端，tty 为空时使用当前进程的终端
+func newOSC52(tty string) (Backend, error) {
+	if tty == "" {
+		tty = terminalPath()
+	}
+	f, err := os.OpenFile(tty, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot open terminal %s", ErrUnavailable, tty)
+	}
+	f.Close()
+	return &osc52Backend{tty: tty, tmux: os.Getenv("TMUX") != ""}, nil
+}
+
+func (b *osc52Backend) Name() string {
+	return "osc52"
+}
+
+// spec 返回包含终端路径的后端名称，供清除进程使用
+func (b *osc52Backend) spec() string {
+	return "osc52:" + b.tty
+}
+
+func (b *osc52Backend) Copy(data []byte) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString(data) + "\a"
+	if b.tmux {
+		// tmux 需要用 DCS 透传序列包裹
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+
+	f, err := os.OpenFile(b.tty, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("clipboard: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(seq); err != nil {
+		return fmt.Errorf("clipboard: %w", err)
+	}
+	return nil
+}
+
+func (b *osc52Backend) Paste() ([]byte, error) {
+	return nil, ErrReadUnsupported
+}
+
+func (b *osc52Backend) Clear() error {
+	return b.Copy(nil)
+}
+
+// terminalPath 返回当前进程终端设备的路径
+//
+// 在 Linux 上解析标准输入输出对应的设备，以便脱离终端的清除进程仍能写入同一终端；
+// 无法解析时返回 /dev/tty。
+func terminalPath() string {
+	for _, fd := range []string{"0", "1", "2"} {
+		if p, err := os.Readlink("/proc/self/fd/" + fd); err == nil && strings.HasPrefix(p, "/dev/pts/") {
+			return p
+		}
+	}
+	return "/dev/tty"
+}
@@ -0,0 +1,184 @@
+// Package clipboard 提供系统剪贴板访问，并支持超时后自动清除
+//
+// 支持的后端包括 Wayland（wl-copy）、X11（xclip、xsel）、macOS（pbcopy）、
+// Windows（clip）以及通过终端转义序列写入本地剪贴板的 OSC 52（适用于 SSH 会话）。
+// 自动清除由一个脱离当前会话的子进程完成，它只在剪贴板内容仍为复制的秘密时才清除，
+// 因此不会覆盖用户之后复制的其他内容。
+package clipboard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/proc"
+)
+
+// EnvBackend 指定剪贴板后端的环境变量，取值为 wl-copy、xclip、xsel、pbcopy、clip 或 osc52
+const EnvBackend = "CIPHERHUB_CLIPBOARD"
+
+// ClearCommand 是执行延迟清除的隐藏子命令名称
+//
+// 命令行程序需要注册该子命令并调用 RunClear。
+const ClearCommand = "__clipboard-clear"
+
+var (
+	// ErrUnavailable 表示当前环境没有可用的剪贴板
+	ErrUnavailable = errors.New("clipboard: no supported clipboard found")
+	// ErrReadUnsupported 表示后端无法读取剪贴板内容
+	ErrReadUnsupported = errors.New("clipboard: backend cannot read the clipboard")
+)
+
+// Backend 定义剪贴板后端
+type Backend interface {
+	// Name 返回后端名称
+	Name() string
+	// Copy 将数据写入剪贴板
+	Copy(data []byte) error
+	// Paste 读取剪贴板内容，无法读取时返回 ErrReadUnsupported
+	Paste() ([]byte, error)
+	// Clear 清空剪贴板
+	Clear() error
+}
+
+// Detect 根据当前环境选择剪贴板后端
+//
+// 优先使用 CIPHERHUB_CLIPBOARD 指定的后端；否则依次尝试系统剪贴板、
+// Wayland、X11，最后在 SSH 或 tmux 会话中使用 OSC 52。
+func Detect() (Backend, error) {
+	if name := os.Getenv(EnvBackend); name != "" {
+		return Lookup(name)
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return lookupCommand("pbcopy")
+	case "windows":
+		return lookupCommand("clip")
+	}
+
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if b, err := lookupCommand("wl-copy"); err == nil {
+			return b, nil
+		}
+	}
+	if os.Getenv("DISPLAY") != "" {
+		for _, name := range []string{"xclip", "xsel"} {
+			if b, err := lookupCommand(name); err == nil {
+				return b, nil
+			}
+		}
+	}
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" || os.Getenv("TMUX") != "" {
+		if b, err := newOSC52(""); err == nil {
+			return b, nil
+		}
+	}
+	return nil, ErrUnavailable
+}
+
+// Lookup 按名称返回剪贴板后端
+//
+// osc52 可以写成 osc52:<终端设备路径>，用于在脱离终端的进程中写入指定终端。
+func Lookup(name string) (Backend, error) {
+	if name == "osc52" || strings.HasPrefix(name, "osc52:") {
+		return newOSC52(strings.TrimPrefix(strings.TrimPrefix(name, "osc52"), ":"))
+	}
+	return lookupCommand(name)
+}
+
+// Copy 将文本复制到剪贴板，并在 timeout 后自动清除
+//
+// timeout 不大于 0 时不自动清除。返回实际使用的后端。
+func Copy(text string, timeout time.Duration) (Backend, error) {
+	b, err := Detect()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Copy([]byte(text)); err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		if err := ScheduleClear(b, []byte(text), timeout); err != nil {
+			return b, fmt.Errorf("clipboard: failed to schedule clearing: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// ScheduleClear 启动一个脱离当前会话的子进程，在 timeout 后清除剪贴板
+//
+// 子进程只接收秘密的 SHA-256 哈希（通过管道传递，不出现在命令行和环境变量中），
+// 清除前会比较剪贴板内容的哈希，内容已被替换时不做任何操作。
+func ScheduleClear(b Backend, secret []byte, timeout time.Duration) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	sum := sha256.Sum256(secret)
+	if _, err := io.WriteString(w, hex.EncodeToString(sum[:])); err != nil {
+		w.Close()
+		return err
+	}
+	w.Close()
+
+	name := b.Name()
+	if s, ok := b.(interface{ spec() string }); ok {
+		name = s.spec()
+	}
+
+	return proc.StartDetached(r, ClearCommand, "--after", timeout.String(), "--backend", name)
+}
+
+// RunClear 等待 after 后，如果剪贴板内容仍与 r 中的哈希一致则清除
+//
+// 由 ClearCommand 子命令调用。无法读取剪贴板的后端（如 OSC 52）会直接清除。
+func RunClear(r io.Reader, after time.Duration, backend string) error {
+	data, err := io.ReadAll(io.LimitReader(r, 2*sha256.Size))
+	if err != nil {
+		return err
+	}
+	want, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(want) != sha256.Size {
+		return errors.New("clipboard: invalid secret hash")
+	}
+
+	b, err := Lookup(backend)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(after)
+
+	current, err := b.Paste()
+	if errors.Is(err, ErrReadUnsupported) {
+		return b.Clear()
+	}
+	if err != nil {
+		return err
+	}
+	if !matches(current, want) {
+		return nil
+	}
+	return b.Clear()
+}
+
+// matches 比较剪贴板内容的哈希，部分工具读取时会追加换行，因此也比较去掉换行后的内容
+func matches(current, want []byte) bool {
+	for _, c := range [][]byte{current, bytes.TrimRight(current, "\r\n")} {
+		sum := sha256.Sum256(c)
+		if bytes.Equal(sum[:], want) {
+			return true
+		}
+	}
+	return false
+}
@@ -40,7 +40,7 @@ type Crypto struct {
 
 // NewCrypto 使用主密码和盐值创建一个新的加密实例
 func NewCrypto(masterPassword string, salt []byte) *Crypto {
-	key := deriveKey(masterPassword, salt)
+	key := DeriveKey(masterPassword, salt)
 	return &Crypto{key: key}
 }
 
@@ -61,8 +61,8 @@ func GenerateSalt() ([]byte, error) {
 	return salt, nil
 }
 
-// deriveKey 使用 Argon2id 算法从主密码派生加密密钥
-func deriveKey(password string, salt []byte) []byte {
+// DeriveKey 使用 Argon2id 算法从主密码派生加密密钥
+func DeriveKey(password string, salt []byte) []byte {
 	return argon2.IDKey(
 		[]byte(password),
 		salt,
@@ -125,6 +125,55 @@ func (c *Crypto) Decrypt(ciphertextB64 string) ([]byte, error) {
 	return plaintext, nil
 }
 
+// Seal 使用 AES-256-GCM 加密字节数据并认证附加数据 aad
+//
+// 返回 nonce 与密文拼接的原始字节，不做 base64 编码，适合保存附件等二进制数据。
+// 解密时必须提供相同的 aad，否则认证失败。
+func (c *Crypto) Seal(plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// Open 解密 Seal 生成的数据，aad 与加密时不一致或数据被篡改时返回 ErrDecryptionFailed
+func (c *Crypto) Open(sealed, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, ErrInvalidNonceLength
+	}
+
+	nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	return plaintext, nil
+}
+
 // EncryptString 加密字符串
 func (c *Crypto) EncryptString(plaintext string) (string, error) {
 	return c.Encrypt([]byte(plaintext))
@@ -150,6 +199,15 @@ func VerifyChecksum(data []byte, expectedChecksum string) bool {
 	return ComputeChecksum(data) == expectedChecksum
 }
 
+// Key 返回密钥的副本
+//
+// 用于将已派生的密钥交给 agent 缓存，调用方使用完毕后应清零。
+func (c *Crypto) Key() []byte {
+	key := make([]byte, len(c.key))
+	copy(key, c.key)
+	return key
+}
+
 // Clear 安全清除内存中的密钥数据
 func (c *Crypto) Clear() {
 	for i := range c.key {
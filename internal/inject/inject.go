@@ -0,0 +1,152 @@
+// Package inject 渲染包含秘密引用的模板
+//
+// 模板使用 text/template 语法，支持两种引用方式：
+//
+//	{{ cipherhub "prod-db" "password" }}
+//	chub://prod-db/password
+//	chub://work/aws/root/password
+//
+// chub:// 引用中有多段时最后一段是字段，之前的部分是条目路径；只有一段时为条目路径，
+// 字段为 password。因此文件夹中的条目必须写出字段。条目路径和字段可以使用百分号编码
+// （如空格写作 %20，路径中的 / 也可以写作 %2F）。
+// 渲染前会解析所有引用，任何一个无法解析时返回全部错误且不产生输出。
+package inject
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Resolver 返回条目指定字段的值
+type Resolver func(entry, field string) (string, error)
+
+// DefaultField 是未指定字段时使用的字段
+const DefaultField = "password"
+
+// refChars 是 chub:// 引用中一段允许的字符
+const refChars = `[^\s/"'<>{}` + "`" + `]+`
+
+// refPattern 匹配 chub://<条目>[/<字段>]，条目可以包含文件夹，如 chub://work/db/password
+var refPattern = regexp.MustCompile(`chub://` + refChars + `(?:/` + refChars + `)*`)
+
+// Ref 是模板中的一个秘密引用
+type Ref struct {
+	Entry string
+	Field string
+}
+
+func (r Ref) String() string {
+	segments := strings.Split(r.Entry, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "chub://" + strings.Join(segments, "/") + "/" + url.PathEscape(r.Field)
+}
+
+// RefError 表示一个引用无法解析
+type RefError struct {
+	Ref Ref
+	Err error
+}
+
+func (e *RefError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Ref, e.Err)
+}
+
+func (e *RefError) Unwrap() error {
+	return e.Err
+}
+
+// Render 渲染模板 src，将结果写入 w
+//
+// 所有引用都解析成功后才写入 w；否则返回由 RefError 组成的错误，
+// 可以用 errors.Is 判断底层原因（如条目不存在）。
+func Render(w io.Writer, name, src string, resolve Resolver) error {
+	src, err := rewriteRefs(src)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	funcs := template.FuncMap{
+		"cipherhub": func(entry string, field ...string) (string, error) {
+			ref := Ref{Entry: entry, Field: DefaultField}
+			switch len(field) {
+			case 0:
+			case 1:
+				ref.Field = field[0]
+			default:
+				return "", fmt.Errorf("cipherhub takes an entry name and an optional field")
+			}
+			value, err := resolve(ref.Entry, ref.Field)
+			if err != nil {
+				// 继续渲染以便一次报告所有无法解析的引用
+				errs = append(errs, &RefError{Ref: ref, Err: err})
+				return "", nil
+			}
+			return value, nil
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// rewriteRefs 将 chub:// 引用改写为 cipherhub 模板函数调用
+func rewriteRefs(src string) (string, error) {
+	var rewriteErr error
+	out := refPattern.ReplaceAllStringFunc(src, func(m string) string {
+		ref, err := parseRef(m)
+		if err != nil {
+			if rewriteErr == nil {
+				rewriteErr = fmt.Errorf("invalid reference %s: %w", m, err)
+			}
+			return m
+		}
+		return "{{ cipherhub " + strconv.Quote(ref.Entry) + " " + strconv.Quote(ref.Field) + " }}"
+	})
+	return out, rewriteErr
+}
+
+// parseRef 解析 chub:// 引用，最后一段为字段，其余为条目路径
+func parseRef(s string) (Ref, error) {
+	segments := strings.Split(strings.TrimPrefix(s, "chub://"), "/")
+	field := DefaultField
+	if len(segments) > 1 {
+		field = segments[len(segments)-1]
+		segments = segments[:len(segments)-1]
+	}
+
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return Ref{}, err
+		}
+		segments[i] = unescaped
+	}
+	field, err := url.PathUnescape(field)
+	if err != nil {
+		return Ref{}, err
+	}
+	return Ref{Entry: strings.Join(segments, "/"), Field: field}, nil
+}
@@ -0,0 +1,94 @@
+package inject
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errNotFound = errors.New("not found")
+
+// testResolver 从 map 中返回 "条目:字段" 的值
+func testResolver(values map[string]string) Resolver {
+	return func(entry, field string) (string, error) {
+		if v, ok := values[entry+":"+field]; ok {
+			return v, nil
+		}
+		return "", errNotFound
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want Ref
+	}{
+		{"chub://prod-db", Ref{"prod-db", "password"}},
+		{"chub://prod-db/username", Ref{"prod-db", "username"}},
+		{"chub://work/db/password", Ref{"work/db", "password"}},
+		{"chub://work/aws/prod/root/totp", Ref{"work/aws/prod/root", "totp"}},
+		{"chub://work%2Fdb/password", Ref{"work/db", "password"}},
+		{"chub://my%20site/api%20key", Ref{"my site", "api key"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := parseRef(tt.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseRef = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	resolve := testResolver(map[string]string{
+		"prod-db:password":   "s3cret",
+		"prod-db:username":   "admin",
+		"work/db:password":   "folder-pass",
+		"my site:password":   "spaced",
+		"work/api:client_id": "id-123",
+	})
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"template function", `u={{ cipherhub "prod-db" "username" }}`, "u=admin"},
+		{"default field", "p=chub://prod-db\n", "p=s3cret\n"},
+		{"folder", "p=chub://work/db/password", "p=folder-pass"},
+		{"custom field", `id="chub://work/api/client_id"`, `id="id-123"`},
+		{"percent encoded", "p=chub://my%20site/password", "p=spaced"},
+		{"no references", "plain text", "plain text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(&buf, "test", tt.src, resolve); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Fatalf("Render = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderReportsAllErrors(t *testing.T) {
+	var buf bytes.Buffer
+	err := Render(&buf, "test", "a=chub://missing/password b=chub://work/gone/username", testResolver(nil))
+	if !errors.Is(err, errNotFound) {
+		t.Fatalf("Render error = %v, want errNotFound", err)
+	}
+	for _, ref := range []string{"chub://missing/password", "chub://work/gone/username"} {
+		if !strings.Contains(err.Error(), ref) {
+			t.Errorf("error %q does not mention %s", err, ref)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Render wrote %q despite errors", buf.String())
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package proc
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Exec 用 name 指定的程序替换当前进程，env 为完整的环境变量列表
+//
+// 成功时不会返回；程序继承当前进程的标准输入输出和进程号，信号直接送达程序。
+func Exec(name string, args []string, env []string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, append([]string{name}, args...), env)
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package proc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// Exec 运行 name 指定的程序并以其退出码退出当前进程，env 为完整的环境变量列表
+//
+// Windows 不支持替换当前进程，因此以子进程方式运行；Ctrl-C 由子进程自行处理。
+// 只有在程序无法启动时才会返回。
+func Exec(name string, args []string, env []string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signal.Ignore(os.Interrupt)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
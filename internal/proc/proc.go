@@ -0,0 +1,27 @@
+// Package proc 提供启动后台子进程和替换当前进程的辅助函数
+package proc
+
+import (
+	"os"
+	"os/exec"
+)
+
+// StartDetached 以脱离当前会话的方式启动当前程序，args 为命令行参数
+//
+// 子进程的标准输出和标准错误被丢弃，stdin 为 nil 时同样丢弃标准输入。
+// 启动后不等待子进程退出。
+func StartDetached(stdin *os.File, args ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
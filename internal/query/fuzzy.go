@@ -0,0 +1,47 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FuzzyScore 以子序列方式匹配 pattern 和 text（不区分大小写）
+//
+// 所有字符按顺序出现时返回 true 和匹配得分：连续匹配、单词开头的匹配得分更高，
+// 第一个匹配字符之前的跳过会扣分。
+func FuzzyScore(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+
+	score := 0
+	pi := 0
+	last := -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score++
+		if ti == last+1 {
+			score += 5
+		}
+		if ti == 0 || isSeparator(t[ti-1]) {
+			score += 8
+		}
+		if pi == 0 {
+			score -= min(ti, 10)
+		}
+		last = ti
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	return score, true
+}
+
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("-_./@:", r)
+}
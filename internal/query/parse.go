@@ -0,0 +1,260 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 是词法单元的类型
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokTerm
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+// token 是查询语句中的一个词法单元
+type token struct {
+	kind   tokenKind
+	pos    int    // 在语句中的字节位置
+	field  string // 条件的限定词，如 tag:prod 中的 tag
+	value  string // 条件的值
+	quoted bool   // 值由双引号包围，不作为通配符
+	regex  bool   // 值由 / 包围，是正则表达式
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokAnd:
+		return "AND"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "NOT"
+	case tokLParen:
+		return "'('"
+	case tokRParen:
+		return "')'"
+	}
+	if t.field != "" {
+		return fmt.Sprintf("%q", t.field+":"+t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+// lexer 将查询语句拆分为词法单元
+type lexer struct {
+	src string
+	pos int
+}
+
+// next 返回下一个词法单元
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && isSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	switch c := l.src[l.pos]; {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, pos: start}, nil
+	case (c == '-' || c == '!') && l.pos+1 < len(l.src) && !isSpace(l.src[l.pos+1]):
+		l.pos++
+		return token{kind: tokNot, pos: start}, nil
+	}
+
+	t := token{kind: tokTerm, pos: start}
+	if i := l.fieldEnd(); i > 0 {
+		t.field = l.src[l.pos:i]
+		l.pos = i + 1
+	}
+
+	var err error
+	switch {
+	case l.pos < len(l.src) && l.src[l.pos] == '"':
+		t.quoted = true
+		t.value, err = l.delimited('"')
+	case l.pos < len(l.src) && l.src[l.pos] == '/':
+		t.regex = true
+		t.value, err = l.delimited('/')
+	default:
+		i := l.pos
+		for i < len(l.src) && !isSpace(l.src[i]) && l.src[i] != '(' && l.src[i] != ')' {
+			i++
+		}
+		t.value = l.src[l.pos:i]
+		l.pos = i
+	}
+	if err != nil {
+		return t, err
+	}
+
+	if t.field == "" && !t.quoted && !t.regex {
+		switch t.value {
+		case "AND":
+			t.kind = tokAnd
+		case "OR":
+			t.kind = tokOr
+		case "NOT":
+			t.kind = tokNot
+		}
+	}
+	if t.kind == tokTerm && t.value == "" && !t.quoted {
+		return t, fmt.Errorf("%s: missing value", t.field)
+	}
+	return t, nil
+}
+
+// fieldEnd 返回当前位置的限定词之后冒号的位置，没有限定词时返回 -1
+func (l *lexer) fieldEnd() int {
+	for i := l.pos; i < len(l.src); i++ {
+		c := rune(l.src[i])
+		if c == ':' && i > l.pos {
+			return i
+		}
+		if !unicode.IsLetter(c) || c > unicode.MaxASCII {
+			return -1
+		}
+	}
+	return -1
+}
+
+// delimited 读取由 delim 包围的值，值中的 \delim 表示 delim 本身
+func (l *lexer) delimited(delim byte) (string, error) {
+	start := l.pos
+	var b strings.Builder
+	for i := l.pos + 1; i < len(l.src); i++ {
+		c := l.src[i]
+		if c == '\\' && i+1 < len(l.src) && l.src[i+1] == delim {
+			b.WriteByte(delim)
+			i++
+			continue
+		}
+		if c == delim {
+			l.pos = i + 1
+			return b.String(), nil
+		}
+		b.WriteByte(c)
+	}
+	l.pos = len(l.src)
+	return "", fmt.Errorf("unterminated %c starting at column %d", delim, start+1)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// parser 按以下语法解析查询语句：
+//
+//	or    = and { "OR" and }
+//	and   = unary { [ "AND" ] unary }
+//	unary = ( "NOT" | "-" | "!" ) unary | "(" or ")" | term
+type parser struct {
+	lex lexer
+	tok token
+}
+
+func (p *parser) next() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at column %d", ErrSyntax, fmt.Sprintf(format, args...), p.tok.pos+1)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOr {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind != tokEOF && p.tok.kind != tokOr && p.tok.kind != tokRParen {
+		if p.tok.kind == tokAnd {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch p.tok.kind {
+	case tokNot:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected ')' but found %s", p.tok)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokTerm:
+		n, err := newTerm(p.tok)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	return nil, p.errorf("unexpected %s", p.tok)
+}
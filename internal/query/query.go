@@ -0,0 +1,459 @@
+// Package query 实现条目搜索的查询语言
+//
+// 查询由空格分隔的条件组成，相邻的条件同时满足（AND）：
+//
+//	github                    路径、用户名、URL、标签或别名包含 github
+//	tag:prod user:admin       标签为 prod 且用户名包含 admin
+//	url:*.example.com         URL 的主机名匹配通配符
+//	name:/^db-[0-9]+$/        名称匹配正则表达式
+//	updated:<90d              最近 90 天内修改过
+//	created:>=2024-01-01      2024 年 1 月 1 日及之后创建
+//	is:favorite               收藏的条目（is:archived 为已归档的条目）
+//	(tag:prod OR tag:staging) NOT type:note
+//
+// 条件的值可以是普通文本（不区分大小写的子串匹配，tag、alias 和 type 为完全匹配）、
+// 含 * 或 ? 的通配符、/.../ 包围的正则表达式（不区分大小写）或双引号包围的文本。
+// 条件前的 - 或 ! 等同于 NOT。
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// ErrSyntax 表示查询语句无效
+var ErrSyntax = errors.New("invalid query")
+
+// Options 控制查询的匹配方式
+type Options struct {
+	// Fuzzy 使普通文本条件按子序列模糊匹配，结果按匹配得分从高到低排序
+	Fuzzy bool
+	// Now 是日期条件使用的当前时间，零值表示 time.Now()
+	Now time.Time
+}
+
+// Query 是解析后的查询
+type Query struct {
+	root node
+}
+
+// Parse 解析查询语句，空语句匹配所有条目
+func Parse(expr string) (*Query, error) {
+	p := &parser{lex: lexer{src: expr}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return &Query{}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return &Query{root: root}, nil
+}
+
+// Match 返回条目是否匹配查询，以及模糊匹配的得分
+func (q *Query) Match(e *types.Entry, opts Options) (int, bool) {
+	if q.root == nil {
+		return 0, true
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	return q.root.match(e, &opts)
+}
+
+// Filter 返回 entries 中匹配查询的条目
+//
+// 模糊匹配时按得分从高到低排序，否则保持原有顺序。
+func (q *Query) Filter(entries []*types.Entry, opts Options) []*types.Entry {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	var results []*types.Entry
+	scores := make(map[*types.Entry]int)
+	for _, e := range entries {
+		if score, ok := q.Match(e, opts); ok {
+			results = append(results, e)
+			scores[e] = score
+		}
+	}
+	if opts.Fuzzy {
+		sort.SliceStable(results, func(i, j int) bool { return scores[results[i]] > scores[results[j]] })
+	}
+	return results
+}
+
+// node 是查询语法树的节点
+type node interface {
+	match(e *types.Entry, opts *Options) (int, bool)
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(e *types.Entry, opts *Options) (int, bool) {
+	l, ok := n.left.match(e, opts)
+	if !ok {
+		return 0, false
+	}
+	r, ok := n.right.match(e, opts)
+	return l + r, ok
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(e *types.Entry, opts *Options) (int, bool) {
+	l, lok := n.left.match(e, opts)
+	r, rok := n.right.match(e, opts)
+	if !lok {
+		return r, rok
+	}
+	if rok && r > l {
+		return r, true
+	}
+	return l, true
+}
+
+type notNode struct{ node node }
+
+func (n notNode) match(e *types.Entry, opts *Options) (int, bool) {
+	_, ok := n.node.match(e, opts)
+	return 0, !ok
+}
+
+// textMode 决定普通文本值的匹配方式
+type textMode int
+
+const (
+	modeContains textMode = iota // 子串匹配，模糊搜索时按子序列匹配
+	modeExact                    // 完全匹配（不区分大小写）
+)
+
+// textNode 匹配条目的文本字段
+type textNode struct {
+	values func(e *types.Entry) []weighted
+	mode   textMode
+	text   string         // 小写的普通文本，re 为 nil 时使用
+	re     *regexp.Regexp // 正则表达式或通配符
+}
+
+// weighted 是参与匹配的文本及其在模糊匹配中的得分倍数
+type weighted struct {
+	text   string
+	weight int
+}
+
+func (n textNode) match(e *types.Entry, opts *Options) (int, bool) {
+	best, found := 0, false
+	for _, v := range n.values(e) {
+		var score int
+		var ok bool
+		switch {
+		case n.re != nil:
+			ok = n.re.MatchString(v.text)
+		case n.mode == modeExact:
+			ok = strings.ToLower(v.text) == n.text
+		case opts.Fuzzy:
+			score, ok = FuzzyScore(n.text, v.text)
+		default:
+			ok = strings.Contains(strings.ToLower(v.text), n.text)
+		}
+		if ok && (!found || score*v.weight > best) {
+			best, found = score*v.weight, true
+		}
+	}
+	return best, found
+}
+
+// folderNode 匹配位于文件夹中（包括子文件夹）的条目
+type folderNode struct{ folder string }
+
+func (n folderNode) match(e *types.Entry, _ *Options) (int, bool) {
+	return 0, types.InFolder(e.Folder, n.folder)
+}
+
+// typeNode 匹配指定类型的条目
+type typeNode struct{ kind types.EntryType }
+
+func (n typeNode) match(e *types.Entry, _ *Options) (int, bool) {
+	return 0, e.Kind() == n.kind
+}
+
+// hasNode 匹配设置了某个可选内容（has:）或处于某种状态（is:）的条目
+type hasNode struct{ test func(e *types.Entry) bool }
+
+func (n hasNode) match(e *types.Entry, _ *Options) (int, bool) {
+	return 0, n.test(e)
+}
+
+// dateNode 比较条目的创建或修改时间
+type dateNode struct {
+	field func(e *types.Entry) time.Time
+	op    string
+	age   time.Duration // 相对时间，如 90d；为 0 时使用 day
+	day   time.Time     // 某一天的零点（本地时间）
+}
+
+func (n dateNode) match(e *types.Entry, opts *Options) (int, bool) {
+	t := n.field(e)
+	if n.age > 0 {
+		age := opts.Now.Sub(t)
+		switch n.op {
+		case "<":
+			return 0, age < n.age
+		case ">":
+			return 0, age > n.age
+		case ">=":
+			return 0, age >= n.age
+		default:
+			return 0, age <= n.age
+		}
+	}
+
+	start, end := n.day, n.day.AddDate(0, 0, 1)
+	switch n.op {
+	case "<":
+		return 0, t.Before(start)
+	case "<=":
+		return 0, t.Before(end)
+	case ">":
+		return 0, !t.Before(end)
+	case ">=":
+		return 0, !t.Before(start)
+	default:
+		return 0, !t.Before(start) && t.Before(end)
+	}
+}
+
+// textFields 是可以用文本条件搜索的字段，空名称表示不带限定词的条件
+var textFields = map[string]func(e *types.Entry) []weighted{
+	"": func(e *types.Entry) []weighted {
+		out := []weighted{{e.Path(), 2}, {e.Username, 1}, {e.URL, 1}}
+		for _, tag := range e.Tags {
+			out = append(out, weighted{tag, 1})
+		}
+		for _, alias := range e.Aliases {
+			out = append(out, weighted{alias, 2})
+		}
+		return out
+	},
+	"id": func(e *types.Entry) []weighted { return []weighted{{e.ID, 1}} },
+	"alias": func(e *types.Entry) []weighted {
+		out := make([]weighted, len(e.Aliases))
+		for i, alias := range e.Aliases {
+			out[i] = weighted{alias, 1}
+		}
+		return out
+	},
+	"name": func(e *types.Entry) []weighted { return []weighted{{e.Name, 1}} },
+	"path": func(e *types.Entry) []weighted { return []weighted{{e.Path(), 1}} },
+	"user": func(e *types.Entry) []weighted { return []weighted{{e.Username, 1}} },
+	"url": func(e *types.Entry) []weighted {
+		out := []weighted{{e.URL, 1}}
+		if u, err := url.Parse(e.URL); err == nil && u.Hostname() != "" {
+			out = append(out, weighted{u.Hostname(), 1})
+		}
+		return out
+	},
+	"tag": func(e *types.Entry) []weighted {
+		out := make([]weighted, len(e.Tags))
+		for i, tag := range e.Tags {
+			out[i] = weighted{tag, 1}
+		}
+		return out
+	},
+	"field": func(e *types.Entry) []weighted {
+		var out []weighted
+		for _, f := range e.Fields {
+			out = append(out, weighted{f.Name, 1})
+			if f.Type != types.FieldTypeHidden {
+				out = append(out, weighted{f.Value, 1})
+			}
+		}
+		return out
+	},
+}
+
+// hasTests 是 has: 条件支持的值
+var hasTests = map[string]func(e *types.Entry) bool{
+	"url":        func(e *types.Entry) bool { return e.URL != "" },
+	"username":   func(e *types.Entry) bool { return e.Username != "" },
+	"notes":      func(e *types.Entry) bool { return e.Notes != "" },
+	"tags":       func(e *types.Entry) bool { return len(e.Tags) > 0 },
+	"totp":       func(e *types.Entry) bool { return e.TOTP != "" },
+	"fields":     func(e *types.Entry) bool { return len(e.Fields) > 0 },
+	"attachment": func(e *types.Entry) bool { return len(e.Attachments) > 0 },
+	"history":    func(e *types.Entry) bool { return len(e.History) > 0 },
+}
+
+// isTests 是 is: 条件支持的值
+var isTests = map[string]func(e *types.Entry) bool{
+	"favorite": func(e *types.Entry) bool { return e.Favorite },
+	"archived": func(e *types.Entry) bool { return e.Archived },
+}
+
+// fieldAliases 将限定词的别名映射为标准名称
+var fieldAliases = map[string]string{
+	"username":    "user",
+	"tags":        "tag",
+	"aliases":     "alias",
+	"in":          "folder",
+	"kind":        "type",
+	"attachments": "attachment",
+	"modified":    "updated",
+}
+
+// datePattern 匹配日期条件的值，如 <90d、>=2024-01-01
+var datePattern = regexp.MustCompile(`^(<=|>=|<|>|=)?(?:(\d+)([hdwmy])|(\d{4}-\d{2}-\d{2}))$`)
+
+// durationUnits 是相对时间的单位，m 和 y 按 30 天和 365 天计算
+var durationUnits = map[string]time.Duration{
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"m": 30 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+// newTerm 根据限定词和值创建条件节点
+func newTerm(t token) (node, error) {
+	field := strings.ToLower(t.field)
+	if alias, ok := fieldAliases[field]; ok {
+		field = alias
+	}
+
+	if values, ok := textFields[field]; ok {
+		n := textNode{values: values}
+		if field == "tag" || field == "alias" {
+			n.mode = modeExact
+		}
+		switch {
+		case t.regex:
+			if _, err := regexp.Compile(t.value); err != nil {
+				return nil, fmt.Errorf("invalid regular expression /%s/: %v", t.value, err)
+			}
+			n.re = regexp.MustCompile("(?i)" + t.value)
+		case !t.quoted && strings.ContainsAny(t.value, "*?"):
+			n.re = globRegexp(t.value)
+		default:
+			n.text = strings.ToLower(t.value)
+		}
+		return n, nil
+	}
+
+	if t.regex {
+		return nil, fmt.Errorf("%s: does not accept a regular expression", field)
+	}
+	switch field {
+	case "folder":
+		folder, err := types.CleanFolder(t.value)
+		if err != nil {
+			return nil, err
+		}
+		return folderNode{folder}, nil
+	case "type":
+		kind, err := types.ParseEntryType(t.value)
+		if err != nil {
+			return nil, err
+		}
+		return typeNode{kind}, nil
+	case "has":
+		test, ok := hasTests[strings.ToLower(t.value)]
+		if !ok {
+			test, ok = hasTests[fieldAliases[strings.ToLower(t.value)]]
+		}
+		if !ok {
+			return nil, fmt.Errorf("has: unknown value %q (expected %s)", t.value, keys(hasTests))
+		}
+		return hasNode{test}, nil
+	case "is":
+		test, ok := isTests[strings.ToLower(t.value)]
+		if !ok {
+			return nil, fmt.Errorf("is: unknown value %q (expected %s)", t.value, keys(isTests))
+		}
+		return hasNode{test}, nil
+	case "created", "updated":
+		return newDateTerm(field, t.value)
+	}
+	return nil, fmt.Errorf("unknown field %q (expected %s)", t.field, fieldNames())
+}
+
+// newDateTerm 解析 created: 和 updated: 条件的值
+func newDateTerm(field, value string) (node, error) {
+	m := datePattern.FindStringSubmatch(value)
+	if m == nil {
+		return nil, fmt.Errorf("%s: invalid date %q (use a date such as >=2024-01-01 or an age such as <90d)", field, value)
+	}
+	n := dateNode{op: m[1]}
+	if field == "created" {
+		n.field = func(e *types.Entry) time.Time { return e.CreatedAt }
+	} else {
+		n.field = func(e *types.Entry) time.Time { return e.UpdatedAt }
+	}
+
+	if m[2] != "" {
+		count, err := strconv.Atoi(m[2])
+		if err != nil || count == 0 {
+			return nil, fmt.Errorf("%s: invalid age %q", field, value)
+		}
+		n.age = time.Duration(count) * durationUnits[m[3]]
+		return n, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", m[4], time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid date %q", field, m[4])
+	}
+	n.day = day
+	return n, nil
+}
+
+// globRegexp 将通配符转换为不区分大小写的正则表达式，* 匹配任意字符串，? 匹配单个字符
+func globRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// fieldNames 返回所有限定词，用于错误信息
+func fieldNames() string {
+	names := []string{"folder", "type", "has", "is", "created", "updated"}
+	for name := range textFields {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func keys(m map[string]func(e *types.Entry) bool) string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,196 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// testNow 是日期条件使用的固定当前时间
+var testNow = time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
+
+// testEntries 返回用于匹配的条目，名称各不相同
+func testEntries() []*types.Entry {
+	return []*types.Entry{
+		{
+			Name:      "github",
+			Folder:    "work",
+			Username:  "alice",
+			URL:       "https://github.com/login",
+			Tags:      []string{"prod", "git"},
+			Aliases:   []string{"gh"},
+			TOTP:      "encrypted",
+			Favorite:  true,
+			CreatedAt: time.Date(2023, 1, 10, 9, 0, 0, 0, time.Local),
+			UpdatedAt: testNow.Add(-24 * time.Hour),
+		},
+		{
+			Name:      "db-01",
+			Folder:    "work/infra",
+			Username:  "admin",
+			URL:       "postgres://db.example.com:5432",
+			Type:      types.EntryTypeDatabase,
+			Tags:      []string{"staging"},
+			Fields:    []types.Field{{Name: "port", Type: types.FieldTypeText, Value: "5432"}, {Name: "pin", Type: types.FieldTypeHidden, Value: "9876"}},
+			CreatedAt: time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local),
+			UpdatedAt: testNow.Add(-100 * 24 * time.Hour),
+		},
+		{
+			Name:      "wifi notes",
+			Type:      types.EntryTypeNote,
+			Notes:     "encrypted",
+			Archived:  true,
+			CreatedAt: time.Date(2024, 6, 1, 20, 0, 0, 0, time.Local),
+			UpdatedAt: testNow.Add(-2 * time.Hour),
+		},
+	}
+}
+
+func names(entries []*types.Entry) []string {
+	out := []string{}
+	for _, e := range entries {
+		out = append(out, e.Name)
+	}
+	return out
+}
+
+func TestParseSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"unbalanced open paren", "(tag:prod"},
+		{"unbalanced close paren", "tag:prod)"},
+		{"empty group", "()"},
+		{"dangling OR", "github OR"},
+		{"leading OR", "OR github"},
+		{"dangling NOT", "github NOT"},
+		{"missing value", "tag:"},
+		{"unterminated quote", `name:"git`},
+		{"unterminated regex", "name:/^git"},
+		{"invalid regex", "name:/[/"},
+		{"unknown field", "color:red"},
+		{"unknown has value", "has:password"},
+		{"unknown is value", "is:deleted"},
+		{"unknown type", "type:spaceship"},
+		{"regex on type", "type:/note/"},
+		{"invalid date", "updated:yesterday"},
+		{"zero age", "updated:<0d"},
+		{"invalid day", "created:2024-13-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.expr); !errors.Is(err, ErrSyntax) {
+				t.Fatalf("Parse(%q) error = %v, want ErrSyntax", tt.expr, err)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{"empty query", "", []string{"github", "db-01", "wifi notes"}},
+		{"plain text in path", "infra", []string{"db-01"}},
+		{"plain text in alias", "gh", []string{"github"}},
+		{"plain text is case insensitive", "GITHUB", []string{"github"}},
+		{"quoted text with space", `"wifi notes"`, []string{"wifi notes"}},
+		{"tag is exact", "tag:pro", []string{}},
+		{"tag", "tag:prod", []string{"github"}},
+		{"alias is exact", "alias:GH", []string{"github"}},
+		{"user contains", "user:adm", []string{"db-01"}},
+		{"field alias", "username:alice", []string{"github"}},
+		{"url host glob", "url:*.example.com", []string{"db-01"}},
+		{"name glob", "name:db-??", []string{"db-01"}},
+		{"quoted glob is literal", `name:"db-??"`, []string{}},
+		{"name regex", "name:/^DB-[0-9]+$/", []string{"db-01"}},
+		{"field value", "field:5432", []string{"db-01"}},
+		{"hidden field value is not searched", "field:9876", []string{}},
+		{"hidden field name", "field:pin", []string{"db-01"}},
+		{"folder includes subfolders", "folder:work", []string{"github", "db-01"}},
+		{"folder is not a prefix match", "in:wor", []string{}},
+		{"type", "type:note", []string{"wifi notes"}},
+		{"type defaults to login", "type:login", []string{"github"}},
+		{"has", "has:totp", []string{"github"}},
+		{"has alias", "has:tags", []string{"github", "db-01"}},
+		{"is favorite", "is:favorite", []string{"github"}},
+		{"is archived", "is:archived", []string{"wifi notes"}},
+		{"updated within", "updated:<2d", []string{"github", "wifi notes"}},
+		{"updated older than", "modified:>90d", []string{"db-01"}},
+		{"created on day", "created:2024-06-01", []string{"wifi notes"}},
+		{"created before day", "created:<2024-01-01", []string{"github"}},
+		{"created on or after day", "created:>=2024-01-01", []string{"db-01", "wifi notes"}},
+		{"implicit AND", "folder:work tag:prod", []string{"github"}},
+		{"explicit AND", "folder:work AND user:admin", []string{"db-01"}},
+		{"OR", "tag:prod OR tag:staging", []string{"github", "db-01"}},
+		{"AND binds tighter than OR", "tag:prod OR tag:staging user:nobody", []string{"github"}},
+		{"group", "(tag:prod OR tag:staging) user:admin", []string{"db-01"}},
+		{"NOT", "NOT folder:work", []string{"wifi notes"}},
+		{"dash negation", "folder:work -tag:prod", []string{"db-01"}},
+		{"bang negation", "!is:archived !is:favorite", []string{"db-01"}},
+		{"negated group", "-(type:note OR type:database)", []string{"github"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			got := names(q.Filter(testEntries(), Options{Now: testNow}))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Filter(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterFuzzy(t *testing.T) {
+	q, err := Parse("gthb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := testEntries()
+	if got := names(q.Filter(entries, Options{Now: testNow})); len(got) != 0 {
+		t.Fatalf("Filter without fuzzy = %v, want none", got)
+	}
+	got := names(q.Filter(entries, Options{Now: testNow, Fuzzy: true}))
+	if !reflect.DeepEqual(got, []string{"github"}) {
+		t.Fatalf("Filter with fuzzy = %v, want [github]", got)
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		want          bool
+	}{
+		{"", "anything", true},
+		{"gh", "github", true},
+		{"GH", "github", true},
+		{"hg", "github", false},
+		{"githubx", "github", false},
+	}
+	for _, tt := range tests {
+		if _, ok := FuzzyScore(tt.pattern, tt.text); ok != tt.want {
+			t.Errorf("FuzzyScore(%q, %q) = %t, want %t", tt.pattern, tt.text, ok, tt.want)
+		}
+	}
+
+	// 连续匹配和单词开头的匹配得分更高
+	better, _ := FuzzyScore("git", "github")
+	worse, _ := FuzzyScore("git", "gxixt")
+	if better <= worse {
+		t.Errorf("contiguous score %d <= scattered score %d", better, worse)
+	}
+	prefix, _ := FuzzyScore("db", "db-01")
+	inner, _ := FuzzyScore("db", "mydb")
+	if prefix <= inner {
+		t.Errorf("word start score %d <= inner score %d", prefix, inner)
+	}
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/studio-b12/gowebdav"
+)
+
+var (
+	// ErrStorageNotFound 表示存储资源不存在
+	ErrStorageNotFound = errors.New("storage: not found")
+	// ErrStorageExists 表示存储资源已存在
+	ErrStorageExists = errors.New("storage: already exists")
+	// ErrStoragePermission 表示没有访问存储资源的权限（HTTP 403 或本地文件权限不足）
+	ErrStoragePermission = errors.New("storage: permission denied")
+	// ErrStorageUnauthorized 表示存储服务拒绝了认证信息（HTTP 401）
+	ErrStorageUnauthorized = errors.New("storage: authentication failed")
+	// ErrStorageConnection 表示连接存储服务失败
+	ErrStorageConnection = errors.New("storage: connection failed")
+	// ErrStorageTimeout 表示存储操作超时
+	ErrStorageTimeout = errors.New("storage: operation timed out")
+	// ErrStorageConflict 表示远程资源在读取后已被其他设备修改
+	ErrStorageConflict = errors.New("storage: remote changed since last read")
+	// ErrStorageCertificate 表示服务器证书未通过校验（包括固定公钥不匹配）
+	ErrStorageCertificate = errors.New("storage: server certificate rejected")
+)
+
+// Error 描述一次失败的存储操作
+//
+// Kind 为上面的哨兵错误之一，可以通过 errors.Is 判断错误类别；
+// Err 为底层错误，同样可以通过 errors.Is/errors.As 检查。
+type Error struct {
+	Op         string            // 操作名称，如 read、write、exists、delete、connect
+	Backend    types.StorageType // 存储类型
+	Path       string            // 资源路径（本地文件路径或远程路径）
+	StatusCode int               // HTTP 状态码，非 HTTP 错误时为 0
+	Kind       error             // 错误类别
+	Err        error             // 底层错误（可选）
+}
+
+// Error 返回错误描述
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("storage: ")
+	b.WriteString(string(e.Backend))
+	b.WriteString(" ")
+	b.WriteString(e.Op)
+	if e.Path != "" {
+		b.WriteString(" ")
+		b.WriteString(e.Path)
+	}
+	b.WriteString(": ")
+	b.WriteString(strings.TrimPrefix(e.Kind.Error(), "storage: "))
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, " (HTTP %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap 返回错误类别和底层错误
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// newError 创建一个没有底层错误的存储错误
+func newError(op string, backend types.StorageType, path string, kind error) *Error {
+	return &Error{Op: op, Backend: backend, Path: path, Kind: kind}
+}
+
+// Kind 返回错误链中 *Error 的错误类别
+//
+// 错误链中没有 *Error 时原样返回 err。
+func Kind(err error) error {
+	var se *Error
+	if errors.As(err, &se) {
+		return se.Kind
+	}
+	return err
+}
+
+// StatusCode 返回错误链中的 HTTP 状态码
+//
+// 错误链中没有 HTTP 状态码时返回 0。
+func StatusCode(err error) int {
+	var se *Error
+	if errors.As(err, &se) && se.StatusCode != 0 {
+		return se.StatusCode
+	}
+	var status gowebdav.StatusError
+	if errors.As(err, &status) {
+		return status.Status
+	}
+	return 0
+}
+
+// webdavError 将 gowebdav 返回的错误转换为 *Error
+func webdavError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var se *Error
+	if errors.As(err, &se) {
+		return err
+	}
+
+	e := &Error{Op: op, Backend: types.StorageTypeWebDAV, Path: path, Err: err}
+
+	var status gowebdav.StatusError
+	switch {
+	case errors.Is(err, context.Canceled):
+		e.Kind = context.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		e.Kind = ErrStorageTimeout
+	case isTimeout(err):
+		e.Kind = ErrStorageTimeout
+	case isCertificateError(err):
+		e.Kind = ErrStorageCertificate
+	case errors.As(err, &status):
+		e.StatusCode = status.Status
+		e.Kind = kindForStatus(status.Status)
+	default:
+		e.Kind = ErrStorageConnection
+	}
+	return e
+}
+
+// kindForStatus 将 HTTP 状态码映射为错误类别
+func kindForStatus(status int) error {
+	switch status {
+	case 401:
+		return ErrStorageUnauthorized
+	case 403:
+		return ErrStoragePermission
+	case 404, 410:
+		return ErrStorageNotFound
+	case 405:
+		return ErrStorageExists
+	case 409, 412:
+		return ErrStorageConflict
+	case 408, 504:
+		return ErrStorageTimeout
+	default:
+		return ErrStorageConnection
+	}
+}
+
+// isTimeout 判断是否为网络超时错误
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// isCertificateError 判断是否为 TLS 证书校验错误
+func isCertificateError(err error) bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		unknownErr   x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordHdrErr tls.RecordHeaderError
+	)
+	return errors.Is(err, ErrPinMismatch) ||
+		errors.As(err, &verifyErr) ||
+		errors.As(err, &unknownErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &recordHdrErr)
+}
+
+// localError 将本地文件系统错误转换为 *Error
+func localError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	e := &Error{Op: op, Backend: types.StorageTypeLocal, Path: path, Err: err}
+	switch {
+	case errors.Is(err, context.Canceled):
+		e.Kind = context.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		e.Kind = ErrStorageTimeout
+	case errors.Is(err, os.ErrNotExist):
+		e.Kind = ErrStorageNotFound
+	case errors.Is(err, os.ErrPermission):
+		e.Kind = ErrStoragePermission
+	case errors.Is(err, os.ErrExist):
+		e.Kind = ErrStorageExists
+	default:
+		// 本地存储没有“连接”的概念，其余 I/O 错误直接作为错误类别
+		e.Kind = err
+		e.Err = nil
+	}
+	return e
+}
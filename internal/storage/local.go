@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
@@ -27,47 +28,74 @@ func NewLocalStorage(path string) *LocalStorage {
 // Read 从本地文件读取密码库数据
 //
 // 返回读取到的字节数据，如果文件不存在则返回 ErrStorageNotFound。
-func (s *LocalStorage) Read() ([]byte, error) {
-	if !s.Exists() {
-		return nil, ErrStorageNotFound
+// 本地文件操作不可中断，只在开始前检查 ctx 是否已取消。
+// 失败时返回 *Error。
+func (s *LocalStorage) Read(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, localError("read", s.path, err)
 	}
-	return os.ReadFile(s.path)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, localError("read", s.path, err)
+	}
+	return data, nil
 }
 
 // Write 将密码库数据写入本地文件
 //
 // data 为要写入的字节数据。
 // 写入时会先创建临时文件，成功后再重命名，以避免写入过程中程序崩溃导致数据损坏。
-func (s *LocalStorage) Write(data []byte) error {
+// 失败时返回 *Error。
+func (s *LocalStorage) Write(ctx context.Context, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return localError("write", s.path, err)
+	}
+
 	dir := filepath.Dir(s.path)
 	if err := os.MkdirAll(dir, 0700); err != nil {
-		return err
+		return localError("mkdir", dir, err)
 	}
 
 	tmpPath := s.path + ".tmp"
 	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
-		return err
+		return localError("write", tmpPath, err)
 	}
 
-	return os.Rename(tmpPath, s.path)
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return localError("write", s.path, err)
+	}
+	return nil
 }
 
 // Exists 检查本地文件是否存在
 //
-// 存在返回 true，否则返回 false。
-func (s *LocalStorage) Exists() bool {
+// 文件存在返回 true；不存在返回 false 且错误为 nil；
+// 其他错误（如权限不足）返回 *Error。
+func (s *LocalStorage) Exists(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, localError("exists", s.path, err)
+	}
 	_, err := os.Stat(s.path)
-	return err == nil
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return false, localError("exists", s.path, err)
 }
 
 // Delete 删除本地文件
 //
-// 如果文件不存在则返回 ErrStorageNotFound。
-func (s *LocalStorage) Delete() error {
-	if !s.Exists() {
-		return ErrStorageNotFound
+// 如果文件不存在则返回 ErrStorageNotFound，失败时返回 *Error。
+func (s *LocalStorage) Delete(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return localError("delete", s.path, err)
+	}
+	if err := os.Remove(s.path); err != nil {
+		return localError("delete", s.path, err)
 	}
-	return os.Remove(s.path)
+	return nil
 }
 
 // Type 返回存储类型
@@ -0,0 +1,541 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/crypto"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// 中间件名称，对应配置文件 storage_pipeline 中的 name 字段
+const (
+	MiddlewareCache       = "cache"
+	MiddlewareCompression = "compression"
+	MiddlewareRetry       = "retry"
+	MiddlewareLogging     = "logging"
+	MiddlewareEncryption  = "encryption"
+)
+
+// encryptionMagic 标识经过静态加密中间件处理的数据
+var encryptionMagic = []byte("CIPHERHUB-ENC1\n")
+
+var (
+	// ErrUnknownMiddleware 表示配置中引用了不存在的中间件
+	ErrUnknownMiddleware = errors.New("storage: unknown middleware")
+	// ErrNotEncrypted 表示启用了静态加密，但读取到的数据没有加密
+	ErrNotEncrypted = errors.New("storage: data is not encrypted")
+)
+
+// Middleware 将一个 Storage 包装为另一个 Storage
+//
+// 中间件实现横切关注点（缓存、压缩、重试、日志、静态加密），
+// 与具体的存储后端无关。
+type Middleware func(Storage) Storage
+
+// Chain 依次将中间件应用到 base 上
+//
+// 第一个中间件位于最外层，即最先接收调用，最后接触底层存储。
+func Chain(base Storage, middlewares ...Middleware) Storage {
+	s := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		s = middlewares[i](s)
+	}
+	return s
+}
+
+// BuildPipeline 根据配置构建中间件管道
+//
+// stages 中第一个中间件位于最外层。
+// 返回包装后的 Storage，如果配置无效则返回错误。
+func BuildPipeline(base Storage, stages []types.MiddlewareConfig) (Storage, error) {
+	middlewares := make([]Middleware, 0, len(stages))
+	for _, stage := range stages {
+		mw, err := newMiddleware(stage)
+		if err != nil {
+			return nil, err
+		}
+		middlewares = append(middlewares, mw)
+	}
+	return Chain(base, middlewares...), nil
+}
+
+func newMiddleware(stage types.MiddlewareConfig) (Middleware, error) {
+	opts := stage.Options
+	switch stage.Name {
+	case MiddlewareCache:
+		return WithCache(), nil
+	case MiddlewareCompression:
+		level := gzip.DefaultCompression
+		if v, ok := opts["level"]; ok {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < gzip.HuffmanOnly || n > gzip.BestCompression {
+				return nil, fmt.Errorf("storage: invalid compression level %q", v)
+			}
+			level = n
+		}
+		return WithCompression(level), nil
+	case MiddlewareRetry:
+		attempts := 3
+		backoff := 500 * time.Millisecond
+		if v, ok := opts["attempts"]; ok {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("storage: invalid retry attempts %q", v)
+			}
+			attempts = n
+		}
+		if v, ok := opts["backoff"]; ok {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("storage: invalid retry backoff %q", v)
+			}
+			backoff = d
+		}
+		return WithRetry(attempts, backoff), nil
+	case MiddlewareLogging:
+		prefix := "cipherhub: "
+		if v, ok := opts["prefix"]; ok {
+			prefix = v
+		}
+		return WithLogging(log.New(os.Stderr, prefix, log.LstdFlags)), nil
+	case MiddlewareEncryption:
+		keyFile, ok := opts["key_file"]
+		if !ok || keyFile == "" {
+			return nil, errors.New("storage: encryption middleware requires key_file option")
+		}
+		key, err := loadKeyFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		allowPlaintext := false
+		if v, ok := opts["allow_plaintext"]; ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("storage: invalid allow_plaintext %q", v)
+			}
+			allowPlaintext = b
+		}
+		return WithEncryption(key, allowPlaintext)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMiddleware, stage.Name)
+	}
+}
+
+// loadKeyFile 从文件加载 32 字节的静态加密密钥
+//
+// 文件内容可以是原始字节、十六进制或 base64 编码。
+func loadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to read key file: %w", err)
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("storage: key file must contain a 32-byte key (raw, hex or base64)")
+}
+
+// cacheStorage 在内存中缓存最近一次读取或写入的数据
+type cacheStorage struct {
+	next  Storage
+	mu    sync.Mutex
+	data  []byte
+	valid bool
+}
+
+// WithCache 返回内存缓存中间件
+//
+// 读取结果会被缓存，后续读取直接返回缓存内容；写入和删除会同步更新缓存。
+// 适用于同一进程内多次访问远程存储的场景。
+func WithCache() Middleware {
+	return func(next Storage) Storage {
+		return &cacheStorage{next: next}
+	}
+}
+
+func (s *cacheStorage) Read(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.valid {
+		return bytes.Clone(s.data), nil
+	}
+
+	data, err := s.next.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.data = bytes.Clone(data)
+	s.valid = true
+	return data, nil
+}
+
+func (s *cacheStorage) Write(ctx context.Context, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.valid = false
+	if err := s.next.Write(ctx, data); err != nil {
+		return err
+	}
+	s.data = bytes.Clone(data)
+	s.valid = true
+	return nil
+}
+
+func (s *cacheStorage) Exists(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.valid {
+		return true, nil
+	}
+	return s.next.Exists(ctx)
+}
+
+func (s *cacheStorage) Delete(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = nil
+	s.valid = false
+	return s.next.Delete(ctx)
+}
+
+func (s *cacheStorage) Type() types.StorageType {
+	return s.next.Type()
+}
+
+func (s *cacheStorage) Unwrap() Storage {
+	return s.next
+}
+
+// compressionStorage 使用 gzip 压缩写入的数据
+type compressionStorage struct {
+	next  Storage
+	level int
+}
+
+// WithCompression 返回 gzip 压缩中间件
+//
+// level 为 gzip 压缩级别。读取时会自动识别未压缩的旧数据并原样返回，
+// 因此可以在已有密码库上直接启用。
+func WithCompression(level int) Middleware {
+	return func(next Storage) Storage {
+		return &compressionStorage{next: next, level: level}
+	}
+}
+
+func (s *compressionStorage) Read(ctx context.Context) ([]byte, error) {
+	data, err := s.next.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("storage: invalid compressed data: %w", err)
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("storage: invalid compressed data: %w", err)
+	}
+	return out, nil
+}
+
+func (s *compressionStorage) Write(ctx context.Context, data []byte) error {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, s.level)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return s.next.Write(ctx, buf.Bytes())
+}
+
+func (s *compressionStorage) Exists(ctx context.Context) (bool, error) {
+	return s.next.Exists(ctx)
+}
+
+func (s *compressionStorage) Delete(ctx context.Context) error {
+	return s.next.Delete(ctx)
+}
+
+func (s *compressionStorage) Type() types.StorageType {
+	return s.next.Type()
+}
+
+func (s *compressionStorage) Unwrap() Storage {
+	return s.next
+}
+
+// retryStorage 在操作失败时按指数退避重试
+type retryStorage struct {
+	next     Storage
+	attempts int
+	backoff  time.Duration
+}
+
+// WithRetry 返回重试中间件
+//
+// attempts 为最大尝试次数（包含首次），backoff 为首次重试前的等待时间，
+// 之后每次重试等待时间翻倍。只有超时、网络连接失败和 HTTP 429/5xx 会触发重试，
+// 等待期间 ctx 取消会立即返回。
+func WithRetry(attempts int, backoff time.Duration) Middleware {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(next Storage) Storage {
+		return &retryStorage{next: next, attempts: attempts, backoff: backoff}
+	}
+}
+
+// WithConfiguredRetry 根据 WebDAV 配置返回重试中间件
+//
+// 未配置重试次数时返回的中间件不做任何包装。
+func WithConfiguredRetry(cfg *types.WebDAVConfig) Middleware {
+	return func(next Storage) Storage {
+		if cfg.Retries <= 0 {
+			return next
+		}
+		return WithRetry(cfg.Retries+1, cfg.RetryBackoffDuration())(next)
+	}
+}
+
+func (s *retryStorage) do(ctx context.Context, op func() error) error {
+	var err error
+	wait := s.backoff
+	for i := 0; i < s.attempts; i++ {
+		if i > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errors.Join(ctx.Err(), err)
+			case <-timer.C:
+			}
+			wait *= 2
+		}
+		if err = op(); err == nil || !retryable(ctx, err) {
+			return err
+		}
+	}
+	return err
+}
+
+// retryable 判断错误是否值得重试
+//
+// 只有临时性的错误类别会重试：超时、网络连接失败，以及 HTTP 429 和 5xx 响应。
+// 认证失败、权限不足、资源不存在、版本冲突、证书校验失败（包括固定公钥不匹配）
+// 以及调用方的 ctx 已取消或超时都不会重试。
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch Kind(err) {
+	case ErrStorageTimeout:
+		return true
+	case ErrStorageConnection:
+		status := StatusCode(err)
+		return status == 0 || status == http.StatusTooManyRequests || status >= 500
+	default:
+		return false
+	}
+}
+
+func (s *retryStorage) Read(ctx context.Context) ([]byte, error) {
+	var data []byte
+	err := s.do(ctx, func() error {
+		var err error
+		data, err = s.next.Read(ctx)
+		return err
+	})
+	return data, err
+}
+
+func (s *retryStorage) Write(ctx context.Context, data []byte) error {
+	return s.do(ctx, func() error {
+		return s.next.Write(ctx, data)
+	})
+}
+
+func (s *retryStorage) Exists(ctx context.Context) (bool, error) {
+	var exists bool
+	err := s.do(ctx, func() error {
+		var err error
+		exists, err = s.next.Exists(ctx)
+		return err
+	})
+	return exists, err
+}
+
+func (s *retryStorage) Delete(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		return s.next.Delete(ctx)
+	})
+}
+
+func (s *retryStorage) Type() types.StorageType {
+	return s.next.Type()
+}
+
+func (s *retryStorage) Unwrap() Storage {
+	return s.next
+}
+
+// loggingStorage 记录每次存储操作的耗时和结果
+type loggingStorage struct {
+	next   Storage
+	logger *log.Logger
+}
+
+// WithLogging 返回日志中间件
+//
+// 只记录操作名称、数据大小、耗时和错误，不会记录数据内容。
+func WithLogging(logger *log.Logger) Middleware {
+	return func(next Storage) Storage {
+		return &loggingStorage{next: next, logger: logger}
+	}
+}
+
+func (s *loggingStorage) log(op string, start time.Time, size int, err error) {
+	if err != nil {
+		s.logger.Printf("%s %s failed after %s: %v", s.next.Type(), op, time.Since(start), err)
+		return
+	}
+	s.logger.Printf("%s %s ok (%d bytes, %s)", s.next.Type(), op, size, time.Since(start))
+}
+
+func (s *loggingStorage) Read(ctx context.Context) ([]byte, error) {
+	start := time.Now()
+	data, err := s.next.Read(ctx)
+	s.log("read", start, len(data), err)
+	return data, err
+}
+
+func (s *loggingStorage) Write(ctx context.Context, data []byte) error {
+	start := time.Now()
+	err := s.next.Write(ctx, data)
+	s.log("write", start, len(data), err)
+	return err
+}
+
+func (s *loggingStorage) Exists(ctx context.Context) (bool, error) {
+	exists, err := s.next.Exists(ctx)
+	if err != nil {
+		s.logger.Printf("%s exists failed: %v", s.next.Type(), err)
+	} else {
+		s.logger.Printf("%s exists: %t", s.next.Type(), exists)
+	}
+	return exists, err
+}
+
+func (s *loggingStorage) Delete(ctx context.Context) error {
+	start := time.Now()
+	err := s.next.Delete(ctx)
+	s.log("delete", start, 0, err)
+	return err
+}
+
+func (s *loggingStorage) Type() types.StorageType {
+	return s.next.Type()
+}
+
+func (s *loggingStorage) Unwrap() Storage {
+	return s.next
+}
+
+// encryptionStorage 对整个密码库文件进行静态加密
+type encryptionStorage struct {
+	next           Storage
+	crypto         *crypto.Crypto
+	allowPlaintext bool
+}
+
+// WithEncryption 返回静态加密中间件
+//
+// key 为 32 字节的 AES-256 密钥，与主密码无关，用于隐藏条目名称、用户名等
+// 未加密的元数据。读取到没有加密标记的数据时返回 ErrNotEncrypted，防止能写入
+// 存储的人用明文替换加密的文件；allowPlaintext 为 true 时原样返回这类数据，
+// 只用于将已有的未加密文件迁移为加密文件（下次写入时加密）。
+func WithEncryption(key []byte, allowPlaintext bool) (Middleware, error) {
+	c, err := crypto.NewCryptoWithKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return func(next Storage) Storage {
+		return &encryptionStorage{next: next, crypto: c, allowPlaintext: allowPlaintext}
+	}, nil
+}
+
+func (s *encryptionStorage) Read(ctx context.Context) ([]byte, error) {
+	data, err := s.next.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, encryptionMagic) {
+		if s.allowPlaintext {
+			return data, nil
+		}
+		return nil, ErrNotEncrypted
+	}
+	return s.crypto.Decrypt(string(bytes.TrimPrefix(data, encryptionMagic)))
+}
+
+func (s *encryptionStorage) Write(ctx context.Context, data []byte) error {
+	ciphertext, err := s.crypto.Encrypt(data)
+	if err != nil {
+		return err
+	}
+	out := make([]byte, 0, len(encryptionMagic)+len(ciphertext))
+	out = append(out, encryptionMagic...)
+	out = append(out, ciphertext...)
+	return s.next.Write(ctx, out)
+}
+
+func (s *encryptionStorage) Exists(ctx context.Context) (bool, error) {
+	return s.next.Exists(ctx)
+}
+
+func (s *encryptionStorage) Delete(ctx context.Context) error {
+	return s.next.Delete(ctx)
+}
+
+func (s *encryptionStorage) Type() types.StorageType {
+	return s.next.Type()
+}
+
+func (s *encryptionStorage) Unwrap() Storage {
+	return s.next
+}
@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/studio-b12/gowebdav"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// memStorage 是测试用的内存存储，记录写入的原始数据
+type memStorage struct {
+	data   []byte
+	exists bool
+}
+
+func (s *memStorage) Read(ctx context.Context) ([]byte, error) {
+	if !s.exists {
+		return nil, newError("read", types.StorageTypeLocal, "mem", ErrStorageNotFound)
+	}
+	return bytes.Clone(s.data), nil
+}
+
+func (s *memStorage) Write(ctx context.Context, data []byte) error {
+	s.data = bytes.Clone(data)
+	s.exists = true
+	return nil
+}
+
+func (s *memStorage) Exists(ctx context.Context) (bool, error) {
+	return s.exists, nil
+}
+
+func (s *memStorage) Delete(ctx context.Context) error {
+	s.data, s.exists = nil, false
+	return nil
+}
+
+func (s *memStorage) Type() types.StorageType {
+	return types.StorageTypeLocal
+}
+
+var testKey = bytes.Repeat([]byte{0x42}, 32)
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	mem := &memStorage{}
+	mw, err := WithEncryption(testKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := mw(mem)
+
+	plain := []byte(`{"entries":[{"name":"github"}]}`)
+	if err := s.Write(context.Background(), plain); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(mem.data, encryptionMagic) || bytes.Contains(mem.data, []byte("github")) {
+		t.Fatalf("stored data is not encrypted: %q", mem.data)
+	}
+	got, err := s.Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("Read = %q, want %q", got, plain)
+	}
+}
+
+func TestEncryptionRejectsPlaintext(t *testing.T) {
+	plain := []byte(`{"entries":[]}`)
+	tests := []struct {
+		name           string
+		allowPlaintext bool
+		wantErr        error
+	}{
+		{"strict", false, ErrNotEncrypted},
+		{"migration", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw, err := WithEncryption(testKey, tt.allowPlaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := mw(&memStorage{data: plain, exists: true})
+			got, err := s.Read(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Read error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !bytes.Equal(got, plain) {
+				t.Fatalf("Read = %q, want %q", got, plain)
+			}
+		})
+	}
+}
+
+func TestEncryptionRejectsWrongKey(t *testing.T) {
+	mem := &memStorage{}
+	mw, _ := WithEncryption(testKey, false)
+	if err := mw(mem).Write(context.Background(), []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	other, _ := WithEncryption(bytes.Repeat([]byte{0x24}, 32), false)
+	if _, err := other(mem).Read(context.Background()); err == nil {
+		t.Fatal("Read with a different key succeeded")
+	}
+}
+
+func TestBuildPipelineEncryptionOptions(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := dir + "/storage.key"
+	if err := os.WriteFile(keyFile, testKey, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options map[string]string
+		wantErr bool
+	}{
+		{"key file", map[string]string{"key_file": keyFile}, false},
+		{"allow plaintext", map[string]string{"key_file": keyFile, "allow_plaintext": "true"}, false},
+		{"missing key file", nil, true},
+		{"invalid allow_plaintext", map[string]string{"key_file": keyFile, "allow_plaintext": "maybe"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildPipeline(&memStorage{}, []types.MiddlewareConfig{{Name: MiddlewareEncryption, Options: tt.options}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildPipeline error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// recordStorage 记录调用经过的中间件名称
+type recordStorage struct {
+	Storage
+	name  string
+	calls *[]string
+}
+
+func (s *recordStorage) Write(ctx context.Context, data []byte) error {
+	*s.calls = append(*s.calls, s.name)
+	return s.Storage.Write(ctx, data)
+}
+
+func record(name string, calls *[]string) Middleware {
+	return func(next Storage) Storage {
+		return &recordStorage{Storage: next, name: name, calls: calls}
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	s := Chain(&memStorage{}, record("outer", &calls), record("middle", &calls), record("inner", &calls))
+	if err := s.Write(context.Background(), []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(calls); got != "[outer middle inner]" {
+		t.Fatalf("call order = %s, want [outer middle inner]", got)
+	}
+}
+
+func TestPipelineCompressionBeforeEncryption(t *testing.T) {
+	keyFile := t.TempDir() + "/storage.key"
+	if err := os.WriteFile(keyFile, testKey, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mem := &memStorage{}
+	s, err := BuildPipeline(mem, []types.MiddlewareConfig{
+		{Name: MiddlewareCompression},
+		{Name: MiddlewareEncryption, Options: map[string]string{"key_file": keyFile}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain := bytes.Repeat([]byte(`{"name":"github"}`), 100)
+	if err := s.Write(context.Background(), plain); err != nil {
+		t.Fatal(err)
+	}
+	// 压缩位于外层，底层收到的是加密后的压缩数据，明显小于原文
+	if !bytes.HasPrefix(mem.data, encryptionMagic) || len(mem.data) >= len(plain) {
+		t.Fatalf("stored %d bytes with prefix %q, want compressed then encrypted data", len(mem.data), mem.data[:min(len(mem.data), 16)])
+	}
+	got, err := s.Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Fatal("Read did not return the written data")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	webdav := func(err error) error { return webdavError("read", "/vault.json", err) }
+	status := func(code int) error { return webdav(gowebdav.StatusError{Status: code}) }
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection", webdav(errors.New("connection refused")), true},
+		{"attempt timeout", webdav(context.DeadlineExceeded), true},
+		{"500", status(500), true},
+		{"503", status(503), true},
+		{"429", status(429), true},
+		{"408", status(408), true},
+		{"400", status(400), false},
+		{"401", status(401), false},
+		{"403", status(403), false},
+		{"404", status(404), false},
+		{"409", status(409), false},
+		{"412", status(412), false},
+		{"canceled", webdav(context.Canceled), false},
+		{"pin mismatch", webdav(fmt.Errorf("tls: %w", ErrPinMismatch)), false},
+		{"certificate", webdav(&tls.CertificateVerificationError{Err: errors.New("expired")}), false},
+		{"local I/O", localError("read", "vault.json", errors.New("disk full")), false},
+		{"unclassified", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(context.Background(), tt.err); got != tt.want {
+				t.Fatalf("retryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebDAVErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"network", errors.New("connection refused"), ErrStorageConnection},
+		{"deadline", context.DeadlineExceeded, ErrStorageTimeout},
+		{"canceled", context.Canceled, context.Canceled},
+		{"pin mismatch", fmt.Errorf("tls: %w", ErrPinMismatch), ErrStorageCertificate},
+		{"certificate", &tls.CertificateVerificationError{Err: errors.New("expired")}, ErrStorageCertificate},
+		{"401", gowebdav.StatusError{Status: 401}, ErrStorageUnauthorized},
+		{"403", gowebdav.StatusError{Status: 403}, ErrStoragePermission},
+		{"404", gowebdav.StatusError{Status: 404}, ErrStorageNotFound},
+		{"412", gowebdav.StatusError{Status: 412}, ErrStorageConflict},
+		{"504", gowebdav.StatusError{Status: 504}, ErrStorageTimeout},
+		{"503", gowebdav.StatusError{Status: 503}, ErrStorageConnection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webdavError("read", "/vault.json", tt.err)
+			if got := Kind(err); got != tt.want {
+				t.Fatalf("Kind = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("errors.Is(%v, %v) = false", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRetryableCallerContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if retryable(ctx, webdavError("read", "/vault.json", context.DeadlineExceeded)) {
+		t.Fatal("retryable after the caller's deadline = true, want false")
+	}
+}
+
+// flakyStorage 在前 failures 次读取时返回 err
+type flakyStorage struct {
+	memStorage
+	err      error
+	failures int
+	reads    int
+}
+
+func (s *flakyStorage) Read(ctx context.Context) ([]byte, error) {
+	s.reads++
+	if s.reads <= s.failures {
+		return nil, s.err
+	}
+	return s.memStorage.Read(ctx)
+}
+
+func TestRetryAttempts(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantReads int
+		wantErr   bool
+	}{
+		{"transient", webdavError("read", "/vault.json", gowebdav.StatusError{Status: 503}), 3, false},
+		{"permanent", webdavError("read", "/vault.json", gowebdav.StatusError{Status: 401}), 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &flakyStorage{memStorage: memStorage{data: []byte("v"), exists: true}, err: tt.err, failures: 2}
+			_, err := WithRetry(3, time.Millisecond)(st).Read(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if st.reads != tt.wantReads {
+				t.Fatalf("reads = %d, want %d", st.reads, tt.wantReads)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// RemoteStorage 是可以作为离线缓存数据源的远程存储
+type RemoteStorage interface {
+	Storage
+	Versioned
+}
+
+// cacheMeta 记录本地副本对应的远程版本
+type cacheMeta struct {
+	ETag      string    `json:"etag"`       // 本地副本对应的远程 ETag
+	FetchedAt time.Time `json:"fetched_at"` // 最近一次与远程确认一致的时间
+}
+
+// OfflineStorage 为远程存储维护一份本地副本
+//
+// 读取时直接返回本地副本，并在后台比较远程 ETag，变化时刷新副本；
+// 远程不可达时继续使用本地副本，并输出“stale since”警告。
+// 写入总是先写远程，成功后再更新本地副本；如果远程在读取之后被其他设备修改，
+// 写入会返回 ErrStorageConflict，避免覆盖远程的更新。
+//
+// 本地副本使用独立的缓存密钥加密保存（与 encryption 中间件的格式相同），
+// 单独复制或备份副本文件不会泄露条目名称等元数据。
+type OfflineStorage struct {
+	remote   RemoteStorage
+	local    Storage
+	metaPath string
+	warn     io.Writer
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	readETag string
+}
+
+// NewOfflineStorage 创建一个带本地副本的远程存储
+//
+// remote 为远程存储，cachePath 为本地副本的路径，key 为加密副本的 32 字节密钥，
+// 版本信息保存在 cachePath + ".meta" 中。警告信息输出到标准错误。
+// 已有的未加密或无法用 key 解密的副本会被忽略，下次读取时从远程重新建立。
+func NewOfflineStorage(remote RemoteStorage, cachePath string, key []byte) (*OfflineStorage, error) {
+	encryption, err := WithEncryption(key, false)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineStorage{
+		remote:   remote,
+		local:    encryption(NewLocalStorage(cachePath)),
+		metaPath: cachePath + ".meta",
+		warn:     os.Stderr,
+	}, nil
+}
+
+// SetWarningOutput 设置警告信息的输出位置
+func (s *OfflineStorage) SetWarningOutput(w io.Writer) {
+	s.warn = w
+}
+
+// Read 读取密码库数据
+//
+// 存在本地副本时立即返回，并在后台检查远程版本；
+// 没有本地副本时从远程读取并建立副本。
+func (s *OfflineStorage) Read(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meta, err := s.loadMeta()
+	if exists, _ := s.local.Exists(ctx); err == nil && exists {
+		data, err := s.local.Read(ctx)
+		if err == nil {
+			s.readETag = meta.ETag
+			s.wg.Add(1)
+			go s.refresh(context.WithoutCancel(ctx), meta)
+			return data, nil
+		}
+	}
+
+	return s.fetch(ctx)
+}
+
+// fetch 从远程读取数据并更新本地副本，调用方需持有锁
+func (s *OfflineStorage) fetch(ctx context.Context) ([]byte, error) {
+	etag, err := s.remote.ETag(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.remote.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store(ctx, data, etag); err != nil {
+		return nil, err
+	}
+	s.readETag = etag
+	return data, nil
+}
+
+// refresh 在后台比较远程版本，变化时刷新本地副本
+//
+// ctx 不会随命令结束而取消，刷新时间受远程存储自身的超时约束。
+// 更新副本时持有锁；如果副本在刷新开始后已被写入更新，不再覆盖它。
+func (s *OfflineStorage) refresh(ctx context.Context, meta *cacheMeta) {
+	defer s.wg.Done()
+
+	etag, err := s.remote.ETag(ctx)
+	if err != nil {
+		if !errors.Is(err, ErrStorageNotFound) {
+			fmt.Fprintf(s.warn, "⚠ Remote storage unavailable (%v), using cached copy (stale since %s)\n",
+				Kind(err), meta.FetchedAt.Local().Format("2006-01-02 15:04"))
+		}
+		return
+	}
+
+	var data []byte
+	if etag != meta.ETag {
+		data, err = s.remote.Read(ctx)
+		if err != nil {
+			fmt.Fprintf(s.warn, "⚠ Failed to refresh cached copy: %v\n", err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, err := s.loadMeta(); err != nil || current.ETag != meta.ETag {
+		return
+	}
+	if etag == meta.ETag {
+		meta.FetchedAt = time.Now()
+		s.saveMeta(meta)
+		return
+	}
+	if err := s.store(ctx, data, etag); err != nil {
+		fmt.Fprintf(s.warn, "⚠ Failed to refresh cached copy: %v\n", err)
+		return
+	}
+	fmt.Fprintln(s.warn, "⚠ Remote vault has changed; the cached copy was refreshed for the next command")
+}
+
+// Write 将数据写入远程存储并更新本地副本
+//
+// 如果远程版本与最近一次读取时不同，返回 ErrStorageConflict。
+func (s *OfflineStorage) Write(ctx context.Context, data []byte) error {
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readETag != "" {
+		etag, err := s.remote.ETag(ctx)
+		if err != nil && !errors.Is(err, ErrStorageNotFound) {
+			return err
+		}
+		if err == nil && etag != s.readETag {
+			return ErrStorageConflict
+		}
+	}
+
+	if err := s.remote.Write(ctx, data); err != nil {
+		return err
+	}
+
+	etag, err := s.remote.ETag(ctx)
+	if err != nil {
+		// 远程写入已成功，只是无法确认版本；清除副本，下次重新读取
+		s.readETag = ""
+		os.Remove(s.metaPath)
+		return nil
+	}
+	s.readETag = etag
+	return s.store(ctx, data, etag)
+}
+
+// Exists 检查密码库是否存在
+//
+// 存在本地副本时直接返回 true，以便离线时仍然可用。
+func (s *OfflineStorage) Exists(ctx context.Context) (bool, error) {
+	if exists, _ := s.local.Exists(ctx); exists {
+		if _, err := s.loadMeta(); err == nil {
+			return true, nil
+		}
+	}
+	return s.remote.Exists(ctx)
+}
+
+// Delete 删除远程资源和本地副本
+func (s *OfflineStorage) Delete(ctx context.Context) error {
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.remote.Delete(ctx); err != nil {
+		return err
+	}
+	s.readETag = ""
+	os.Remove(s.metaPath)
+	if err := s.local.Delete(ctx); err != nil && !errors.Is(err, ErrStorageNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Type 返回远程存储的类型
+func (s *OfflineStorage) Type() types.StorageType {
+	return s.remote.Type()
+}
+
+// Wait 等待后台刷新完成
+//
+// 命令行程序应在退出前调用，以便刷新结果写入本地副本。
+func (s *OfflineStorage) Wait() {
+	s.wg.Wait()
+}
+
+// store 保存本地副本和版本信息
+func (s *OfflineStorage) store(ctx context.Context, data []byte, etag string) error {
+	if err := s.local.Write(ctx, data); err != nil {
+		return err
+	}
+	return s.saveMeta(&cacheMeta{ETag: etag, FetchedAt: time.Now()})
+}
+
+func (s *OfflineStorage) loadMeta() (*cacheMeta, error) {
+	data, err := os.ReadFile(s.metaPath)
+	if err != nil {
+		return nil, err
+	}
+	var meta cacheMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (s *OfflineStorage) saveMeta(meta *cacheMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.metaPath, data, 0600)
+}
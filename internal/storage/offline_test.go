@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// memRemote 是测试用的远程存储，每次写入后 ETag 递增
+type memRemote struct {
+	memStorage
+	version int
+	down    bool
+}
+
+func (s *memRemote) Read(ctx context.Context) ([]byte, error) {
+	if s.down {
+		return nil, newError("read", types.StorageTypeWebDAV, "mem", ErrStorageConnection)
+	}
+	return s.memStorage.Read(ctx)
+}
+
+func (s *memRemote) Write(ctx context.Context, data []byte) error {
+	if s.down {
+		return newError("write", types.StorageTypeWebDAV, "mem", ErrStorageConnection)
+	}
+	s.version++
+	return s.memStorage.Write(ctx, data)
+}
+
+func (s *memRemote) ETag(ctx context.Context) (string, error) {
+	if s.down {
+		return "", newError("etag", types.StorageTypeWebDAV, "mem", ErrStorageConnection)
+	}
+	if !s.exists {
+		return "", newError("etag", types.StorageTypeWebDAV, "mem", ErrStorageNotFound)
+	}
+	return strconv.Itoa(s.version), nil
+}
+
+func newTestOffline(t *testing.T, remote *memRemote) (*OfflineStorage, string) {
+	t.Helper()
+	cachePath := filepath.Join(t.TempDir(), "vault.cache.json")
+	s, err := NewOfflineStorage(remote, cachePath, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetWarningOutput(&bytes.Buffer{})
+	return s, cachePath
+}
+
+func TestOfflineCacheIsEncrypted(t *testing.T) {
+	ctx := context.Background()
+	remote := &memRemote{}
+	remote.Write(ctx, []byte(`{"entries":[{"name":"github"}]}`))
+	s, cachePath := newTestOffline(t, remote)
+
+	if _, err := s.Read(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, encryptionMagic) || bytes.Contains(data, []byte("github")) {
+		t.Fatalf("cached copy is not encrypted: %q", data)
+	}
+}
+
+func TestOfflineReadUsesCacheWhenRemoteDown(t *testing.T) {
+	ctx := context.Background()
+	remote := &memRemote{}
+	remote.Write(ctx, []byte("v1"))
+	s, _ := newTestOffline(t, remote)
+	if _, err := s.Read(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+
+	var warn bytes.Buffer
+	s.SetWarningOutput(&warn)
+	remote.down = true
+	got, err := s.Read(ctx)
+	s.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "v1" {
+		t.Fatalf("Read = %q, want v1", got)
+	}
+	if !strings.Contains(warn.String(), "stale since") {
+		t.Fatalf("warning = %q, want stale since", warn.String())
+	}
+}
+
+func TestOfflineIgnoresPlaintextCache(t *testing.T) {
+	ctx := context.Background()
+	remote := &memRemote{}
+	remote.Write(ctx, []byte("remote"))
+	s, cachePath := newTestOffline(t, remote)
+	os.WriteFile(cachePath, []byte("planted"), 0600)
+	s.saveMeta(&cacheMeta{ETag: "1"})
+
+	got, err := s.Read(ctx)
+	s.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "remote" {
+		t.Fatalf("Read = %q, want remote", got)
+	}
+}
+
+func TestOfflineWriteConflict(t *testing.T) {
+	ctx := context.Background()
+	remote := &memRemote{}
+	remote.Write(ctx, []byte("v1"))
+	s, _ := newTestOffline(t, remote)
+	if _, err := s.Read(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+
+	remote.Write(ctx, []byte("other device"))
+	if err := s.Write(ctx, []byte("v2")); !errors.Is(err, ErrStorageConflict) {
+		t.Fatalf("Write error = %v, want ErrStorageConflict", err)
+	}
+}
+
+func TestOfflineRefreshSkipsNewerCache(t *testing.T) {
+	ctx := context.Background()
+	remote := &memRemote{}
+	remote.Write(ctx, []byte("v1"))
+	s, _ := newTestOffline(t, remote)
+	if _, err := s.Read(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+	stale, err := s.loadMeta()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 刷新开始前副本已由写入更新，之后远程又被其他设备修改
+	if err := s.Write(ctx, []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	remote.Write(ctx, []byte("v3"))
+
+	s.wg.Add(1)
+	s.refresh(ctx, stale)
+
+	got, err := s.local.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "v2" {
+		t.Fatalf("cached copy = %q, want v2", got)
+	}
+}
+
+func TestLoadOrCreateKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.key")
+	key, err := loadOrCreateKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+	again, err := loadOrCreateKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Fatal("loadOrCreateKeyFile generated a new key for an existing file")
+	}
+}
@@ -1,60 +1,282 @@
 // Package storage 提供了密码库存储的抽象接口和多种实现
 //
 // 该包定义了 Storage 接口，用于统一管理密码库的读取、写入、存在性检查和删除操作。
-// 目前支持本地文件系统存储和 WebDAV 远程存储两种实现方式。
+// 目前支持本地文件系统存储和 WebDAV 远程存储两种实现方式，并可以通过中间件
+// （缓存、压缩、重试、日志、静态加密）组合出跨后端的通用行为。
 package storage
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/imerr0rlog/CipherHub/pkg/types"
 )
 
-var (
-	// ErrStorageNotFound 表示存储资源不存在
-	ErrStorageNotFound = errors.New("storage: not found")
-	// ErrStorageExists 表示存储资源已存在
-	ErrStorageExists = errors.New("storage: already exists")
-	// ErrStoragePermission 表示没有访问存储资源的权限
-	ErrStoragePermission = errors.New("storage: permission denied")
-	// ErrStorageConnection 表示连接存储服务失败
-	ErrStorageConnection = errors.New("storage: connection failed")
-)
+// Versioned 由能够提供资源版本标识（如 HTTP ETag）的存储实现
+type Versioned interface {
+	// ETag 返回当前资源的版本标识
+	ETag(ctx context.Context) (string, error)
+}
 
 // Storage 定义了密码库存储的通用接口
 //
 // 所有存储实现都必须实现该接口，以提供统一的操作方式。
+// 每个操作都接受 context.Context，取消或超时后应尽快返回 ctx.Err()。
 type Storage interface {
 	// Read 从存储中读取密码库数据
 	// 返回读取到的字节数据，如果读取失败则返回错误
-	Read() ([]byte, error)
+	Read(ctx context.Context) ([]byte, error)
 	// Write 将密码库数据写入存储
 	// data 为要写入的字节数据，写入失败则返回错误
-	Write(data []byte) error
+	Write(ctx context.Context, data []byte) error
 	// Exists 检查存储资源是否存在
-	// 存在返回 true，否则返回 false
-	Exists() bool
+	// 存在返回 true；确定不存在时返回 false 且错误为 nil；
+	// 无法确定（如认证失败、连接超时）时返回错误
+	Exists(ctx context.Context) (bool, error)
 	// Delete 删除存储资源
 	// 删除失败则返回错误
-	Delete() error
+	Delete(ctx context.Context) error
 	// Type 返回存储类型
 	Type() types.StorageType
 }
 
+// LegacyStorage 是不接受 context 的旧版存储接口
+//
+// 第三方实现可以通过 FromLegacy 继续与 Storage 配合使用。
+type LegacyStorage interface {
+	Read() ([]byte, error)
+	Write(data []byte) error
+	Exists() bool
+	Delete() error
+	Type() types.StorageType
+}
+
+// legacyAdapter 将 LegacyStorage 适配为 Storage
+type legacyAdapter struct {
+	legacy LegacyStorage
+}
+
+// FromLegacy 将旧版存储适配为 Storage
+//
+// 底层操作无法被中断，因此在独立的 goroutine 中执行；
+// ctx 取消时立即返回 ctx.Err()，底层操作在后台自行结束。
+func FromLegacy(s LegacyStorage) Storage {
+	return &legacyAdapter{legacy: s}
+}
+
+func (a *legacyAdapter) Read(ctx context.Context) ([]byte, error) {
+	var data []byte
+	err := runWithContext(ctx, func() error {
+		var err error
+		data, err = a.legacy.Read()
+		return err
+	})
+	return data, err
+}
+
+func (a *legacyAdapter) Write(ctx context.Context, data []byte) error {
+	return runWithContext(ctx, func() error {
+		return a.legacy.Write(data)
+	})
+}
+
+func (a *legacyAdapter) Exists(ctx context.Context) (bool, error) {
+	var exists bool
+	err := runWithContext(ctx, func() error {
+		exists = a.legacy.Exists()
+		return nil
+	})
+	return exists, err
+}
+
+func (a *legacyAdapter) Delete(ctx context.Context) error {
+	return runWithContext(ctx, func() error {
+		return a.legacy.Delete()
+	})
+}
+
+func (a *legacyAdapter) Type() types.StorageType {
+	return a.legacy.Type()
+}
+
+// runWithContext 执行不可中断的操作，ctx 取消时不再等待其完成
+func runWithContext(ctx context.Context, op func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backgroundAdapter 使用固定的 context 将 Storage 适配为 LegacyStorage
+type backgroundAdapter struct {
+	ctx context.Context
+	s   Storage
+}
+
+// ToLegacy 将 Storage 适配为旧版接口
+//
+// 所有操作都使用 ctx 执行，传入 nil 时使用 context.Background()。
+// 旧版 Exists 无法返回错误，出错时视为不存在。
+func ToLegacy(ctx context.Context, s Storage) LegacyStorage {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &backgroundAdapter{ctx: ctx, s: s}
+}
+
+func (a *backgroundAdapter) Read() ([]byte, error)   { return a.s.Read(a.ctx) }
+func (a *backgroundAdapter) Write(data []byte) error { return a.s.Write(a.ctx, data) }
+func (a *backgroundAdapter) Exists() bool {
+	exists, err := a.s.Exists(a.ctx)
+	return err == nil && exists
+}
+func (a *backgroundAdapter) Delete() error           { return a.s.Delete(a.ctx) }
+func (a *backgroundAdapter) Type() types.StorageType { return a.s.Type() }
+
+// Wait 等待存储链中所有后台任务完成
+//
+// 会沿着中间件的 Unwrap 链逐层查找实现了 Wait 方法的存储（如 OfflineStorage）。
+func Wait(s Storage) {
+	for s != nil {
+		if w, ok := s.(interface{ Wait() }); ok {
+			w.Wait()
+		}
+		u, ok := s.(interface{ Unwrap() Storage })
+		if !ok {
+			return
+		}
+		s = u.Unwrap()
+	}
+}
+
 // NewStorage 根据配置创建相应的 Storage 实例
 //
 // cfg 为应用配置，包含存储类型和相关配置信息。
+// 如果配置了 StoragePipeline，返回的实例会按顺序包装相应的中间件。
 // 返回创建的 Storage 实例，如果创建失败则返回错误。
 func NewStorage(cfg *types.Config) (Storage, error) {
+	var st Storage
+	switch cfg.DefaultStorage {
+	case types.StorageTypeLocal:
+		st = NewLocalStorage(cfg.VaultPath)
+	case types.StorageTypeWebDAV:
+		if cfg.WebDAV == nil {
+			return nil, errors.New("webdav configuration required")
+		}
+		webdav, err := NewWebDAVStorage(cfg.WebDAV)
+		if err != nil {
+			return nil, err
+		}
+		st = webdav
+		if cfg.OfflineCache != nil && cfg.OfflineCache.Enabled {
+			cachePath := offlineCachePath(cfg)
+			keyFile := cfg.OfflineCache.KeyFile
+			if keyFile == "" {
+				keyFile = cachePath + ".key"
+			}
+			key, err := loadOrCreateKeyFile(keyFile)
+			if err != nil {
+				return nil, err
+			}
+			if st, err = NewOfflineStorage(webdav, cachePath, key); err != nil {
+				return nil, err
+			}
+		}
+		st = WithConfiguredRetry(cfg.WebDAV)(st)
+	default:
+		return nil, errors.New("unknown storage type")
+	}
+
+	return BuildPipeline(st, cfg.StoragePipeline)
+}
+
+// NewBlobStorage 创建保存单个附件数据块 name 的 Storage 实例
+//
+// 数据块保存在密码库同目录下的 attachments 目录中（本地或 WebDAV 远程目录），
+// 与密码库使用相同的重试设置和 StoragePipeline，但不使用离线副本。
+// name 不能包含路径分隔符。
+func NewBlobStorage(cfg *types.Config, name string) (Storage, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("storage: invalid blob name %q", name)
+	}
+
+	var st Storage
 	switch cfg.DefaultStorage {
 	case types.StorageTypeLocal:
-		return NewLocalStorage(cfg.VaultPath), nil
+		st = NewLocalStorage(filepath.Join(filepath.Dir(cfg.VaultPath), AttachmentsDir, name))
 	case types.StorageTypeWebDAV:
 		if cfg.WebDAV == nil {
 			return nil, errors.New("webdav configuration required")
 		}
-		return NewWebDAVStorage(cfg.WebDAV), nil
+		remote := path.Join(path.Dir(cfg.WebDAV.RemotePath), AttachmentsDir, name)
+		webdav, err := NewWebDAVStorage(cfg.WebDAV.WithRemotePath(remote))
+		if err != nil {
+			return nil, err
+		}
+		st = WithConfiguredRetry(cfg.WebDAV)(webdav)
 	default:
 		return nil, errors.New("unknown storage type")
 	}
+
+	return BuildPipeline(st, cfg.StoragePipeline)
+}
+
+// AttachmentsDir 是附件数据块所在目录的名称，位于密码库文件的同一目录下
+const AttachmentsDir = "attachments"
+
+// offlineCachePath 返回远程密码库本地副本的路径
+//
+// 未配置时默认位于本地密码库同目录下的 vault.cache.json。
+func offlineCachePath(cfg *types.Config) string {
+	if cfg.OfflineCache.Path != "" {
+		return cfg.OfflineCache.Path
+	}
+	return filepath.Join(filepath.Dir(cfg.VaultPath), "vault.cache.json")
+}
+
+// loadOrCreateKeyFile 从 path 加载 32 字节的密钥，文件不存在时生成随机密钥并以十六进制保存（0600）
+func loadOrCreateKeyFile(path string) ([]byte, error) {
+	key, err := loadKeyFile(path)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return key, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		// 其他进程刚刚生成了密钥
+		return loadKeyFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to create key file: %w", err)
+	}
+	_, err = f.WriteString(hex.EncodeToString(key) + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("storage: failed to write key file: %w", err)
+	}
+	return key, nil
 }
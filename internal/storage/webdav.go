@@ -1,9 +1,12 @@
 package storage
 
 import (
-	"crypto/tls"
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/imerr0rlog/CipherHub/pkg/types"
 	"github.com/studio-b12/gowebdav"
@@ -12,45 +15,78 @@ import (
 // WebDAVStorage 实现了基于 WebDAV 协议的远程存储
 //
 // 该类型使用 WebDAV 协议访问远程服务器上的密码库文件。
+// 每次操作都受配置的超时时间约束，并会在 ctx 取消时中断正在进行的请求。
 type WebDAVStorage struct {
-	client *gowebdav.Client
-	config *types.WebDAVConfig
+	client  *gowebdav.Client
+	config  *types.WebDAVConfig
+	timeout time.Duration
+
+	// mu 串行化所有请求，ctx 为当前请求使用的 context
+	mu  sync.Mutex
+	ctx context.Context
 }
 
 // NewWebDAVStorage 创建一个新的 WebDAV 存储实例
 //
-// cfg 为 WebDAV 配置，包含服务器地址、认证信息等。
-// 返回创建的 WebDAVStorage 实例。
-func NewWebDAVStorage(cfg *types.WebDAVConfig) *WebDAVStorage {
-	client := gowebdav.NewClient(cfg.URL, cfg.Username, cfg.Password)
-
-	if cfg.InsecureSkipVerify {
-		transport := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		}
-		client.SetTransport(transport)
+// cfg 为 WebDAV 配置，包含服务器地址、认证信息、TLS 和代理设置等。
+// 返回创建的 WebDAVStorage 实例；CA 证书、客户端证书等无法加载时返回错误。
+func NewWebDAVStorage(cfg *types.WebDAVConfig) (*WebDAVStorage, error) {
+	client, err := newWebDAVClient(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	return &WebDAVStorage{
-		client: client,
-		config: cfg,
+	s := &WebDAVStorage{
+		client:  client,
+		config:  cfg,
+		timeout: cfg.TimeoutDuration(),
 	}
+	client.SetInterceptor(s.intercept)
+	return s, nil
 }
 
-// Read 从 WebDAV 服务器读取密码库数据
-//
-// 返回读取到的字节数据，如果文件不存在则返回 ErrStorageNotFound，
-// 如果连接失败则返回 ErrStorageConnection。
-func (s *WebDAVStorage) Read() ([]byte, error) {
-	if !s.Exists() {
-		return nil, ErrStorageNotFound
+// intercept 将当前操作的 context 绑定到发出的 HTTP 请求上
+func (s *WebDAVStorage) intercept(method string, rq *http.Request) {
+	if s.ctx != nil {
+		*rq = *rq.WithContext(s.ctx)
+	}
+}
+
+// do 在超时和 ctx 约束下执行一次 WebDAV 操作
+func (s *WebDAVStorage) do(ctx context.Context, op func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	data, err := s.client.Read(s.config.RemotePath)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	s.ctx = ctx
+	defer func() { s.ctx = nil }()
+
+	err := op()
+	if err != nil && ctx.Err() != nil {
+		return errors.Join(ctx.Err(), err)
+	}
+	return err
+}
+
+// Read 从 WebDAV 服务器读取密码库数据
+//
+// 返回读取到的字节数据。失败时返回 *Error，其类别可以用 errors.Is 判断，
+// 例如文件不存在为 ErrStorageNotFound，认证失败为 ErrStorageUnauthorized。
+func (s *WebDAVStorage) Read(ctx context.Context) ([]byte, error) {
+	var data []byte
+	err := s.do(ctx, func() error {
+		var err error
+		data, err = s.client.Read(s.config.RemotePath)
+		return err
+	})
 	if err != nil {
-		return nil, errors.Join(ErrStorageConnection, err)
+		return nil, webdavError("read", s.config.RemotePath, err)
 	}
 
 	return data, nil
@@ -59,17 +95,21 @@ func (s *WebDAVStorage) Read() ([]byte, error) {
 // Write 将密码库数据写入 WebDAV 服务器
 //
 // data 为要写入的字节数据。
-// 会自动创建父目录。如果连接失败则返回 ErrStorageConnection。
-func (s *WebDAVStorage) Write(data []byte) error {
+// 会自动创建父目录。失败时返回 *Error。
+func (s *WebDAVStorage) Write(ctx context.Context, data []byte) error {
 	dir := s.getParentPath()
-	if err := s.client.MkdirAll(dir, 0755); err != nil {
-		if !isAlreadyExists(err) {
-			return errors.Join(ErrStorageConnection, err)
-		}
+	err := s.do(ctx, func() error {
+		return s.client.MkdirAll(dir, 0755)
+	})
+	if err != nil && !isAlreadyExists(err) {
+		return webdavError("mkdir", dir, err)
 	}
 
-	if err := s.client.Write(s.config.RemotePath, data, 0644); err != nil {
-		return errors.Join(ErrStorageConnection, err)
+	err = s.do(ctx, func() error {
+		return s.client.Write(s.config.RemotePath, data, 0644)
+	})
+	if err != nil {
+		return webdavError("write", s.config.RemotePath, err)
 	}
 
 	return nil
@@ -77,23 +117,64 @@ func (s *WebDAVStorage) Write(data []byte) error {
 
 // Exists 检查 WebDAV 服务器上的文件是否存在
 //
-// 存在返回 true，否则返回 false。
-func (s *WebDAVStorage) Exists() bool {
-	_, err := s.client.Stat(s.config.RemotePath)
-	return err == nil
+// 文件存在返回 true；服务器明确返回 404 时返回 false 且错误为 nil；
+// 认证失败、超时等其他错误返回 *Error，而不是当作文件不存在。
+func (s *WebDAVStorage) Exists(ctx context.Context) (bool, error) {
+	err := s.do(ctx, func() error {
+		_, err := s.client.Stat(s.config.RemotePath)
+		return err
+	})
+	if err == nil {
+		return true, nil
+	}
+	err = webdavError("exists", s.config.RemotePath, err)
+	if errors.Is(err, ErrStorageNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ETag 返回远程文件的版本标识
+//
+// 优先使用服务器提供的 ETag，如果服务器不支持则由修改时间和大小组合而成。
+// 失败时返回 *Error，文件不存在时其类别为 ErrStorageNotFound。
+func (s *WebDAVStorage) ETag(ctx context.Context) (string, error) {
+	var etag string
+	err := s.do(ctx, func() error {
+		info, err := s.client.Stat(s.config.RemotePath)
+		if err != nil {
+			return err
+		}
+		if f, ok := info.(*gowebdav.File); ok && f.ETag() != "" {
+			etag = f.ETag()
+		} else {
+			etag = fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", webdavError("etag", s.config.RemotePath, err)
+	}
+	return etag, nil
 }
 
 // Delete 从 WebDAV 服务器删除文件
 //
-// 如果文件不存在则返回 ErrStorageNotFound。
-// 如果连接失败则返回 ErrStorageConnection。
-func (s *WebDAVStorage) Delete() error {
-	if !s.Exists() {
-		return ErrStorageNotFound
+// 失败时返回 *Error，文件不存在时其类别为 ErrStorageNotFound。
+func (s *WebDAVStorage) Delete(ctx context.Context) error {
+	exists, err := s.Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return newError("delete", types.StorageTypeWebDAV, s.config.RemotePath, ErrStorageNotFound)
 	}
 
-	if err := s.client.Remove(s.config.RemotePath); err != nil {
-		return errors.Join(ErrStorageConnection, err)
+	err = s.do(ctx, func() error {
+		return s.client.Remove(s.config.RemotePath)
+	})
+	if err != nil {
+		return webdavError("delete", s.config.RemotePath, err)
 	}
 
 	return nil
@@ -125,33 +206,41 @@ func (s *WebDAVStorage) getParentPath() string {
 // isAlreadyExists 检查错误是否表示资源已存在
 //
 // err 为要检查的错误。
-// 如果错误表示资源已存在则返回 true，否则返回 false。
+// 创建目录时服务器返回 405 Method Not Allowed 表示目录已存在。
 func isAlreadyExists(err error) bool {
-	return err != nil && (err.Error() == "405 Method Not Allowed" ||
-		err.Error() == "409 Conflict")
+	return StatusCode(err) == 405
 }
 
 // Connect 测试 WebDAV 连接
 //
 // 尝试连接到 WebDAV 服务器。
 // 返回连接是否成功的错误。
-func (s *WebDAVStorage) Connect() error {
-	return s.client.Connect()
+func (s *WebDAVStorage) Connect(ctx context.Context) error {
+	err := s.do(ctx, func() error {
+		return s.client.Connect()
+	})
+	return webdavError("connect", "/", err)
 }
 
 // ListRemote 列出远程目录下的文件
 //
 // path 为要列出的目录路径。
 // 返回文件名列表，如果操作失败则返回错误。
-func (s *WebDAVStorage) ListRemote(path string) ([]string, error) {
-	files, err := s.client.ReadDir(path)
+func (s *WebDAVStorage) ListRemote(ctx context.Context, path string) ([]string, error) {
+	var names []string
+	err := s.do(ctx, func() error {
+		files, err := s.client.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		names = make([]string, 0, len(files))
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
-	}
-
-	names := make([]string, 0, len(files))
-	for _, f := range files {
-		names = append(names, f.Name())
+		return nil, webdavError("list", path, err)
 	}
 
 	return names, nil
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+	"github.com/studio-b12/gowebdav"
+)
+
+// ProxyDirect 是 WebDAVConfig.Proxy 的特殊取值，表示不使用任何代理
+const ProxyDirect = "direct"
+
+// pinPrefix 是固定公钥的格式前缀，与 curl --pinnedpubkey 保持一致
+const pinPrefix = "sha256/"
+
+// ErrPinMismatch 表示服务器证书的公钥与配置的固定公钥都不匹配
+var ErrPinMismatch = errors.New("server public key does not match any pinned key")
+
+// bearerAuth 使用 Bearer 令牌认证每个 WebDAV 请求
+type bearerAuth struct {
+	token string
+}
+
+// Authorize 为请求添加 Authorization 头
+func (b *bearerAuth) Authorize(c *http.Client, rq *http.Request, path string) error {
+	rq.Header.Set("Authorization", "Bearer "+b.token)
+	return nil
+}
+
+// Verify 检查服务器是否接受了令牌
+func (b *bearerAuth) Verify(c *http.Client, rs *http.Response, path string) (redo bool, err error) {
+	if rs.StatusCode == http.StatusUnauthorized {
+		err = gowebdav.NewPathError("Authorize", path, rs.StatusCode)
+	}
+	return
+}
+
+// Close 释放资源
+func (b *bearerAuth) Close() error {
+	return nil
+}
+
+// Clone 返回自身，令牌只读，无需复制
+func (b *bearerAuth) Clone() gowebdav.Authenticator {
+	return b
+}
+
+// newWebDAVClient 根据配置创建 gowebdav 客户端
+//
+// 设置了 Token 时使用 Bearer 认证，否则使用用户名和密码。
+func newWebDAVClient(cfg *types.WebDAVConfig) (*gowebdav.Client, error) {
+	var client *gowebdav.Client
+	if cfg.Token != "" {
+		client = gowebdav.NewAuthClient(cfg.URL, gowebdav.NewPreemptiveAuth(&bearerAuth{token: cfg.Token}))
+	} else {
+		client = gowebdav.NewClient(cfg.URL, cfg.Username, cfg.Password)
+	}
+
+	transport, err := newWebDAVTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.SetTransport(transport)
+	return client, nil
+}
+
+// newWebDAVTransport 根据配置创建 HTTP 传输层
+//
+// 处理自定义 CA、客户端证书、公钥固定和代理设置。
+func newWebDAVTransport(cfg *types.WebDAVConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := loadCAFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+		if cfg.ClientCertFile == "" || cfg.ClientKeyFile == "" {
+			return nil, errors.New("webdav: client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("webdav: failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(cfg.PinnedPublicKeys) > 0 {
+		pins, err := ParsePinnedPublicKeys(cfg.PinnedPublicKeys)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.VerifyConnection = verifyPinnedPublicKeys(pins)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	switch cfg.Proxy {
+	case "":
+		transport.Proxy = http.ProxyFromEnvironment
+	case ProxyDirect:
+		transport.Proxy = nil
+	default:
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("webdav: invalid proxy URL %q", cfg.Proxy)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("webdav: unsupported proxy scheme %q", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
+}
+
+// loadCAFile 读取 PEM 格式的 CA 证书，并加入系统信任的证书池
+func loadCAFile(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("webdav: failed to read CA file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("webdav: no valid certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// ParsePinnedPublicKeys 解析 sha256/<base64> 格式的固定公钥列表
+//
+// 返回每个公钥的 SHA-256 哈希，格式无效时返回错误。
+func ParsePinnedPublicKeys(pins []string) ([][]byte, error) {
+	hashes := make([][]byte, 0, len(pins))
+	for _, pin := range pins {
+		encoded, ok := strings.CutPrefix(strings.TrimSpace(pin), pinPrefix)
+		if !ok {
+			return nil, fmt.Errorf("webdav: pinned key %q must start with %q", pin, pinPrefix)
+		}
+		hash, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("webdav: pinned key %q is not a base64 SHA-256 hash", pin)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+// PublicKeyPin 返回证书公钥的固定格式 sha256/<base64>
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// verifyPinnedPublicKeys 返回检查服务器证书是否匹配固定公钥的校验函数
+//
+// 在常规证书校验之后执行，只信任校验通过的证书链（VerifiedChains）中的证书，
+// 服务器额外发送的证书不参与匹配。启用了 InsecureSkipVerify 时没有校验过的证书链，
+// 只有服务器证书本身（握手时使用其私钥签名）的公钥可以匹配，因此固定 CA 或中间证书的公钥不再生效。
+func verifyPinnedPublicKeys(pins [][]byte) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		var candidates []*x509.Certificate
+		if len(cs.VerifiedChains) > 0 {
+			for _, chain := range cs.VerifiedChains {
+				candidates = append(candidates, chain...)
+			}
+		} else if len(cs.PeerCertificates) > 0 {
+			candidates = cs.PeerCertificates[:1]
+		}
+		for _, cert := range candidates {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pin := range pins {
+				if bytes.Equal(sum[:], pin) {
+					return nil
+				}
+			}
+		}
+		return ErrPinMismatch
+	}
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// testCert 是测试用的证书及其私钥
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert 创建由 parent 签名的证书，parent 为 nil 时创建自签名的 CA 证书
+func newTestCert(t *testing.T, name string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+func pinOf(c *testCert) []byte {
+	sum := sha256.Sum256(c.cert.RawSubjectPublicKeyInfo)
+	return sum[:]
+}
+
+func TestVerifyPinnedPublicKeys(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	leaf := newTestCert(t, "webdav.example.com", ca)
+	attacker := newTestCert(t, "attacker", nil)
+
+	tests := []struct {
+		name     string
+		pin      *testCert
+		peer     []*testCert
+		verified []*testCert // 为空表示未校验证书链（InsecureSkipVerify）
+		wantErr  error
+	}{
+		{"verified leaf", leaf, []*testCert{leaf, ca}, []*testCert{leaf, ca}, nil},
+		{"verified CA", ca, []*testCert{leaf, ca}, []*testCert{leaf, ca}, nil},
+		{"verified chain without pin", attacker, []*testCert{leaf, ca}, []*testCert{leaf, ca}, ErrPinMismatch},
+		{"pinned cert appended to verified chain", attacker, []*testCert{leaf, ca, attacker}, []*testCert{leaf, ca}, ErrPinMismatch},
+		{"unverified leaf", leaf, []*testCert{leaf}, nil, nil},
+		{"unverified CA is not trusted", ca, []*testCert{leaf, ca}, nil, ErrPinMismatch},
+		{"forged chain", leaf, []*testCert{attacker, leaf, ca}, nil, ErrPinMismatch},
+		{"no certificates", leaf, nil, nil, ErrPinMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cs tls.ConnectionState
+			for _, c := range tt.peer {
+				cs.PeerCertificates = append(cs.PeerCertificates, c.cert)
+			}
+			if len(tt.verified) > 0 {
+				chain := make([]*x509.Certificate, len(tt.verified))
+				for i, c := range tt.verified {
+					chain[i] = c.cert
+				}
+				cs.VerifiedChains = [][]*x509.Certificate{chain}
+			}
+
+			err := verifyPinnedPublicKeys([][]byte{pinOf(tt.pin)})(cs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verify error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestPinnedForgedChainHandshake 模拟中间人：使用自己的证书，并在其后附上公开的被固定证书
+func TestPinnedForgedChainHandshake(t *testing.T) {
+	pinned := newTestCert(t, "webdav.example.com", nil)
+	attacker := newTestCert(t, "attacker", nil)
+
+	tests := []struct {
+		name    string
+		chain   []*testCert // 服务器发送的证书，第一个的私钥用于握手
+		wantErr error
+	}{
+		{"genuine server", []*testCert{pinned}, nil},
+		{"forged chain", []*testCert{attacker, pinned}, ErrPinMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			cert := tls.Certificate{PrivateKey: tt.chain[0].key}
+			for _, c := range tt.chain {
+				cert.Certificate = append(cert.Certificate, c.cert.Raw)
+			}
+			server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+			server.Config.ErrorLog = log.New(io.Discard, "", 0)
+			server.StartTLS()
+			defer server.Close()
+
+			transport, err := newWebDAVTransport(&types.WebDAVConfig{
+				InsecureSkipVerify: true,
+				PinnedPublicKeys:   []string{PublicKeyPin(pinned.cert)},
+				Proxy:              ProxyDirect,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer transport.CloseIdleConnections()
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("request error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// Package totp 实现基于时间的一次性密码（RFC 6238）
+//
+// 配置可以从 otpauth://totp/ URI（通常由二维码解码得到）或 base32 密钥解析，
+// 并可转换回规范的 otpauth URI 保存。
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 默认参数，与大多数身份验证器应用一致
+const (
+	DefaultDigits    = 6
+	DefaultPeriod    = 30
+	DefaultAlgorithm = "SHA1"
+)
+
+// ErrInvalid 表示 TOTP 配置无效
+var ErrInvalid = errors.New("totp: invalid configuration")
+
+// Config 是一个 TOTP 配置
+type Config struct {
+	Secret    []byte // 共享密钥（已解码）
+	Digits    int    // 验证码位数（6 到 8）
+	Period    int    // 时间步长（秒）
+	Algorithm string // SHA1、SHA256 或 SHA512
+	Issuer    string // 发行方（可选）
+	Account   string // 账户名（可选）
+}
+
+// Parse 解析 otpauth://totp/ URI 或 base32 编码的密钥
+//
+// 密钥中的空格、短横线和末尾的填充会被忽略，大小写不敏感。
+// 未指定的参数使用默认值（6 位、30 秒、SHA1）。
+func Parse(s string) (*Config, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalid)
+	}
+	if !strings.HasPrefix(strings.ToLower(s), "otpauth:") {
+		secret, err := decodeSecret(s)
+		if err != nil {
+			return nil, err
+		}
+		return &Config{Secret: secret, Digits: DefaultDigits, Period: DefaultPeriod, Algorithm: DefaultAlgorithm}, nil
+	}
+	return parseURI(s)
+}
+
+// parseURI 解析 otpauth://totp/[发行方:]账户?secret=...&issuer=...&digits=...&period=...&algorithm=...
+func parseURI(s string) (*Config, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if !strings.EqualFold(u.Scheme, "otpauth") {
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalid, u.Scheme)
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return nil, fmt.Errorf("%w: unsupported OTP type %q, only totp is supported", ErrInvalid, u.Host)
+	}
+
+	q := u.Query()
+	secret, err := decodeSecret(q.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{Secret: secret, Digits: DefaultDigits, Period: DefaultPeriod, Algorithm: DefaultAlgorithm}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		c.Issuer, c.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		c.Account = strings.TrimSpace(label)
+	}
+	// issuer 参数优先于标签中的前缀
+	if issuer := q.Get("issuer"); issuer != "" {
+		c.Issuer = issuer
+	}
+
+	if v := q.Get("digits"); v != "" {
+		if c.Digits, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("%w: digits %q", ErrInvalid, v)
+		}
+	}
+	if v := q.Get("period"); v != "" {
+		if c.Period, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("%w: period %q", ErrInvalid, v)
+		}
+	}
+	if v := q.Get("algorithm"); v != "" {
+		c.Algorithm = strings.ToUpper(v)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// decodeSecret 解码 base32 密钥
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "\t", "").Replace(s))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, fmt.Errorf("%w: missing secret", ErrInvalid)
+	}
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret is not valid base32", ErrInvalid)
+	}
+	return secret, nil
+}
+
+// Validate 检查配置的参数是否受支持
+func (c *Config) Validate() error {
+	if len(c.Secret) == 0 {
+		return fmt.Errorf("%w: missing secret", ErrInvalid)
+	}
+	if c.Digits < 6 || c.Digits > 8 {
+		return fmt.Errorf("%w: digits must be between 6 and 8, got %d", ErrInvalid, c.Digits)
+	}
+	if c.Period <= 0 {
+		return fmt.Errorf("%w: period must be positive, got %d", ErrInvalid, c.Period)
+	}
+	if newHash(c.Algorithm) == nil {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalid, c.Algorithm)
+	}
+	return nil
+}
+
+// URI 返回规范的 otpauth URI，Parse(c.URI()) 得到相同的配置
+func (c *Config) URI() string {
+	label := c.Account
+	if c.Issuer != "" {
+		label = c.Issuer + ":" + c.Account
+	}
+	q := url.Values{}
+	q.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(c.Secret))
+	if c.Issuer != "" {
+		q.Set("issuer", c.Issuer)
+	}
+	q.Set("algorithm", c.Algorithm)
+	q.Set("digits", strconv.Itoa(c.Digits))
+	q.Set("period", strconv.Itoa(c.Period))
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// Code 返回时间 t 的验证码
+func (c *Config) Code(t time.Time) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+	counter := uint64(t.Unix()) / uint64(c.Period)
+	return HOTP(c.Secret, counter, c.Digits, c.Algorithm)
+}
+
+// Remaining 返回时间 t 的验证码还剩多久过期
+func (c *Config) Remaining(t time.Time) time.Duration {
+	period := time.Duration(c.Period) * time.Second
+	return period - time.Duration(t.UnixNano())%period
+}
+
+// HOTP 按 RFC 4226 计算计数器 counter 对应的验证码
+func HOTP(secret []byte, counter uint64, digits int, algorithm string) (string, error) {
+	newH := newHash(algorithm)
+	if newH == nil {
+		return "", fmt.Errorf("%w: unsupported algorithm %q", ErrInvalid, algorithm)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(newH, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// 动态截断
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod), nil
+}
+
+// newHash 返回算法对应的哈希函数，不支持时返回 nil
+func newHash(algorithm string) func() hash.Hash {
+	switch strings.ToUpper(algorithm) {
+	case "SHA1":
+		return sha1.New
+	case "SHA256":
+		return sha256.New
+	case "SHA512":
+		return sha512.New
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package totp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 中各算法使用的种子
+var rfcSeeds = map[string][]byte{
+	"SHA1":   []byte("12345678901234567890"),
+	"SHA256": []byte("12345678901234567890123456789012"),
+	"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix      int64
+		algorithm string
+		want      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm+"/"+time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			c := &Config{Secret: rfcSeeds[tt.algorithm], Digits: 8, Period: 30, Algorithm: tt.algorithm}
+			got, err := c.Code(time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Code = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSecret(t *testing.T) {
+	want := []byte("Hello!\xde\xad\xbe\xef")
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"canonical", "JBSWY3DPEHPK3PXP", false},
+		{"lower case", "jbswy3dpehpk3pxp", false},
+		{"spaces", "JBSW Y3DP EHPK 3PXP", false},
+		{"dashes", "JBSW-Y3DP-EHPK-3PXP", false},
+		{"surrounding whitespace", "  JBSWY3DPEHPK3PXP\n", false},
+		{"empty", "", true},
+		{"invalid character", "JBSWY3DPEHPK3PX1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.secret)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("Parse error = %v, want ErrInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(c.Secret, want) {
+				t.Fatalf("Secret = %x, want %x", c.Secret, want)
+			}
+			if c.Digits != DefaultDigits || c.Period != DefaultPeriod || c.Algorithm != DefaultAlgorithm {
+				t.Fatalf("defaults = %d/%d/%s", c.Digits, c.Period, c.Algorithm)
+			}
+		})
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			uri:  "otpauth://totp/alice@example.com?secret=JBSWY3DPEHPK3PXP",
+			want: Config{Digits: 6, Period: 30, Algorithm: "SHA1", Account: "alice@example.com"},
+		},
+		{
+			name: "issuer in label",
+			uri:  "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP",
+			want: Config{Digits: 6, Period: 30, Algorithm: "SHA1", Issuer: "Example", Account: "alice@example.com"},
+		},
+		{
+			name: "issuer parameter wins",
+			uri:  "otpauth://totp/Old:alice?secret=JBSWY3DPEHPK3PXP&issuer=New",
+			want: Config{Digits: 6, Period: 30, Algorithm: "SHA1", Issuer: "New", Account: "alice"},
+		},
+		{
+			name: "all parameters",
+			uri:  "otpauth://totp/ACME%20Co:john?secret=JBSWY3DPEHPK3PXP&algorithm=sha256&digits=8&period=60",
+			want: Config{Digits: 8, Period: 60, Algorithm: "SHA256", Issuer: "ACME Co", Account: "john"},
+		},
+		{name: "hotp", uri: "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=1", wantErr: true},
+		{name: "missing secret", uri: "otpauth://totp/alice", wantErr: true},
+		{name: "bad digits", uri: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=10", wantErr: true},
+		{name: "bad period", uri: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0", wantErr: true},
+		{name: "bad algorithm", uri: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.uri)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("Parse error = %v, want ErrInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Digits != tt.want.Digits || c.Period != tt.want.Period || c.Algorithm != tt.want.Algorithm ||
+				c.Issuer != tt.want.Issuer || c.Account != tt.want.Account {
+				t.Fatalf("Parse = %+v, want %+v", c, tt.want)
+			}
+
+			// URI 得到的配置可以原样解析回来
+			again, err := Parse(c.URI())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again.Secret, c.Secret) || again.URI() != c.URI() {
+				t.Fatalf("Parse(URI()) = %+v, want %+v", again, c)
+			}
+		})
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	c := &Config{Period: 30}
+	if got := c.Remaining(time.Unix(59, 0)); got != time.Second {
+		t.Fatalf("Remaining = %s, want 1s", got)
+	}
+	if got := c.Remaining(time.Unix(60, 0)); got != 30*time.Second {
+		t.Fatalf("Remaining = %s, want 30s", got)
+	}
+}
@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// 表单字段的下标
+const (
+	fieldName = iota
+	fieldUsername
+	fieldPassword
+	fieldURL
+	fieldNotes
+	fieldTags
+)
+
+// input 是单行文本输入框
+type input struct {
+	label    string
+	value    []rune
+	cursor   int
+	secret   bool // 未显示明文时用圆点代替
+	readOnly bool
+}
+
+func (in *input) set(s string) {
+	in.value = []rune(s)
+	in.cursor = len(in.value)
+}
+
+func (in *input) String() string {
+	return string(in.value)
+}
+
+// handle 处理编辑按键，返回是否已处理
+func (in *input) handle(k key) bool {
+	if in.readOnly {
+		return false
+	}
+	switch k.code {
+	case keyRune:
+		in.value = append(in.value[:in.cursor], append([]rune{k.r}, in.value[in.cursor:]...)...)
+		in.cursor++
+	case keyBackspace:
+		if in.cursor > 0 {
+			in.value = append(in.value[:in.cursor-1], in.value[in.cursor:]...)
+			in.cursor--
+		}
+	case keyDelete:
+		if in.cursor < len(in.value) {
+			in.value = append(in.value[:in.cursor], in.value[in.cursor+1:]...)
+		}
+	case keyLeft:
+		if in.cursor > 0 {
+			in.cursor--
+		}
+	case keyRight:
+		if in.cursor < len(in.value) {
+			in.cursor++
+		}
+	case keyHome:
+		in.cursor = 0
+	case keyEnd:
+		in.cursor = len(in.value)
+	case keyCtrl:
+		switch k.r {
+		case 'a':
+			in.cursor = 0
+		case 'e':
+			in.cursor = len(in.value)
+		case 'u':
+			in.value = in.value[in.cursor:]
+			in.cursor = 0
+		case 'k':
+			in.value = in.value[:in.cursor]
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// display 返回显示内容和光标所在的显示列
+func (in *input) display(reveal bool) (string, int) {
+	value := in.value
+	if in.secret && !reveal {
+		value = []rune(strings.Repeat("•", len(in.value)))
+	}
+	return string(value), textWidth(string(value[:in.cursor]))
+}
+
+// form 是新增或编辑条目的表单
+type form struct {
+	title   string
+	editing string // 正在编辑的条目 ID，新增时为空
+	fields  []*input
+	focus   int
+	reveal  bool
+
+	// original 保存编辑前的值，保存时只提交有变化的字段
+	original []string
+}
+
+func newForm(title string) *form {
+	return &form{
+		title: title,
+		fields: []*input{
+			fieldName:     {label: "Name"},
+			fieldUsername: {label: "Username"},
+			fieldPassword: {label: "Password", secret: true},
+			fieldURL:      {label: "URL"},
+			fieldNotes:    {label: "Notes", secret: true},
+			fieldTags:     {label: "Tags"},
+		},
+	}
+}
+
+// newEditForm 创建编辑 entry 的表单，password 和 notes 为解密后的明文
+func newEditForm(entry *types.Entry, password, notes string) *form {
+	f := newForm("Edit entry")
+	f.editing = entry.ID
+	f.fields[fieldName].set(entry.Path())
+	f.fields[fieldName].readOnly = true
+	f.fields[fieldUsername].set(entry.Username)
+	f.fields[fieldPassword].set(password)
+	f.fields[fieldURL].set(entry.URL)
+	f.fields[fieldNotes].set(notes)
+	f.fields[fieldTags].set(strings.Join(entry.Tags, ", "))
+	f.focus = fieldUsername
+
+	f.original = make([]string, len(f.fields))
+	for i, in := range f.fields {
+		f.original[i] = in.String()
+	}
+	return f
+}
+
+func (f *form) current() *input {
+	return f.fields[f.focus]
+}
+
+// move 将焦点移动 delta 个字段，跳过只读字段
+func (f *form) move(delta int) {
+	n := len(f.fields)
+	for i := 0; i < n; i++ {
+		f.focus = (f.focus + delta + n) % n
+		if !f.fields[f.focus].readOnly {
+			return
+		}
+	}
+}
+
+func (f *form) value(i int) string {
+	return strings.TrimSpace(f.fields[i].String())
+}
+
+// updates 返回编辑表单中有变化的字段，键与 vault.Manager.UpdateEntry 一致
+func (f *form) updates() map[string]string {
+	keys := map[int]string{
+		fieldUsername: "username",
+		fieldPassword: "password",
+		fieldURL:      "url",
+		fieldNotes:    "notes",
+		fieldTags:     "tags",
+	}
+	updates := make(map[string]string)
+	for i, k := range keys {
+		v := f.fields[i].String()
+		if i != fieldPassword && i != fieldNotes {
+			v = strings.TrimSpace(v)
+		}
+		if v != f.original[i] {
+			updates[k] = v
+		}
+	}
+	return updates
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package tui
+
+import "os"
+
+// notifyResize 在 Windows 上没有对应的信号，窗口大小在每次按键后更新
+func notifyResize(ch chan<- os.Signal) {}
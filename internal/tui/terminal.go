@@ -0,0 +1,271 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal 表示标准输入不是终端
+var ErrNotTerminal = errors.New("tui: stdin is not a terminal")
+
+// terminal 管理原始模式、备用屏幕和按键输入
+type terminal struct {
+	in     *os.File
+	out    *bufio.Writer
+	fd     int
+	state  *term.State
+	width  int
+	height int
+}
+
+func openTerminal() (*terminal, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, ErrNotTerminal
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &terminal{
+		in:    os.Stdin,
+		out:   bufio.NewWriterSize(os.Stdout, 64*1024),
+		fd:    fd,
+		state: state,
+	}
+	t.updateSize()
+	// 切换到备用屏幕并隐藏光标，退出时恢复，不在滚动历史中留下密码
+	t.out.WriteString("\x1b[?1049h\x1b[?25l")
+	t.out.Flush()
+	return t, nil
+}
+
+func (t *terminal) close() {
+	t.out.WriteString("\x1b[0m\x1b[?25h\x1b[?1049l")
+	t.out.Flush()
+	term.Restore(t.fd, t.state)
+}
+
+func (t *terminal) updateSize() {
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		w, h = 80, 24
+	}
+	t.width, t.height = w, h
+}
+
+// readKeys 在后台读取输入并解码为按键
+func (t *terminal) readKeys() <-chan key {
+	ch := make(chan key)
+	go func() {
+		defer close(ch)
+		buf := make([]byte, 256)
+		for {
+			n, err := t.in.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, k := range decodeKeys(buf[:n]) {
+				ch <- k
+			}
+		}
+	}()
+	return ch
+}
+
+// keyCode 是按键类型
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyCtrl         // r 为对应的小写字母
+	keyEnter
+	keyEsc
+	keyTab
+	keyBacktab
+	keyBackspace
+	keyDelete
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyPgUp
+	keyPgDn
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// escapeKeys 将常见的终端转义序列映射到按键
+var escapeKeys = map[string]keyCode{
+	"[A": keyUp, "[B": keyDown, "[C": keyRight, "[D": keyLeft,
+	"OA": keyUp, "OB": keyDown, "OC": keyRight, "OD": keyLeft,
+	"[H": keyHome, "[F": keyEnd, "OH": keyHome, "OF": keyEnd,
+	"[1~": keyHome, "[7~": keyHome, "[4~": keyEnd, "[8~": keyEnd,
+	"[3~": keyDelete, "[5~": keyPgUp, "[6~": keyPgDn, "[Z": keyBacktab,
+}
+
+// decodeKeys 将一次读取的字节解码为按键
+//
+// 单独的 ESC 视为 Esc 键；ESC 后紧跟的序列按转义序列解析，无法识别时丢弃。
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		c := b[0]
+		switch {
+		case c == 0x1b:
+			if len(b) == 1 {
+				keys = append(keys, key{code: keyEsc})
+				b = b[1:]
+				continue
+			}
+			n := escapeLen(b[1:])
+			if code, ok := escapeKeys[string(b[1:1+n])]; ok {
+				keys = append(keys, key{code: code})
+			} else if n == 0 {
+				keys = append(keys, key{code: keyEsc})
+			}
+			b = b[1+n:]
+		case c == '\r' || c == '\n':
+			keys = append(keys, key{code: keyEnter})
+			b = b[1:]
+		case c == '\t':
+			keys = append(keys, key{code: keyTab})
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, key{code: keyBackspace})
+			b = b[1:]
+		case c < 0x20:
+			keys = append(keys, key{code: keyCtrl, r: rune('a' + c - 1)})
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, key{code: keyRune, r: r})
+			b = b[size:]
+		}
+	}
+	return keys
+}
+
+// escapeLen 返回 ESC 之后转义序列的长度
+func escapeLen(b []byte) int {
+	if len(b) == 0 || (b[0] != '[' && b[0] != 'O') {
+		return 0
+	}
+	for i := 1; i < len(b); i++ {
+		if b[i] >= 0x40 && b[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(b)
+}
+
+// 显示样式
+const (
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+)
+
+// styled 为已按宽度截断的文本加上样式
+func styled(style, s string) string {
+	if style == "" {
+		return s
+	}
+	return style + s + styleReset
+}
+
+// fit 将 s 截断或用空格填充到恰好 width 个显示宽度
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		if r == '\n' || r == '\t' {
+			r = ' '
+		}
+		rw := runeWidth(r)
+		if w+rw > width {
+			// 放不下时以省略号结尾
+			out := []rune(b.String())
+			for w > width-1 && len(out) > 0 {
+				w -= runeWidth(out[len(out)-1])
+				out = out[:len(out)-1]
+			}
+			b.Reset()
+			b.WriteString(string(out))
+			b.WriteRune('…')
+			w++
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	if w < width {
+		b.WriteString(strings.Repeat(" ", width-w))
+	}
+	return b.String()
+}
+
+// textWidth 返回字符串的显示宽度
+func textWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// runeWidth 返回字符的显示宽度，东亚宽字符和表情符号占两列
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case r >= 0x300 && r <= 0x36f, r == 0x200b, r >= 0xfe00 && r <= 0xfe0f:
+		return 0
+	case r >= 0x1100 && r <= 0x115f,
+		r >= 0x2e80 && r <= 0xa4cf,
+		r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff,
+		r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1faff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// wrap 将文本按 width 拆分为多行
+func wrap(s string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		w := 0
+		for _, r := range para {
+			rw := runeWidth(r)
+			if w+rw > width && line != "" {
+				lines = append(lines, line)
+				line, w = "", 0
+			}
+			line += string(r)
+			w += rw
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,868 @@
+// Package tui 提供浏览和编辑密码条目的全屏终端界面
+//
+// 界面由标签侧栏、条目列表和详情面板组成，支持模糊过滤、新增/编辑表单和
+// 复制快捷键。所有修改都通过 vault.Manager 完成，与命令行共享同一套校验逻辑。
+// 密码和备注默认以圆点显示，按 r 后才解密显示。
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/clipboard"
+	"github.com/imerr0rlog/CipherHub/internal/query"
+	"github.com/imerr0rlog/CipherHub/internal/vault"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// Options 定义终端界面的选项
+type Options struct {
+	ClipboardTimeout time.Duration // 复制后多久清除剪贴板，不大于 0 时不清除
+}
+
+// mode 是界面当前的交互状态
+type mode int
+
+const (
+	modeBrowse  mode = iota
+	modeFilter       // 正在输入过滤条件
+	modeForm         // 正在填写新增或编辑表单
+	modeConfirm      // 正在确认删除
+	modeHelp
+)
+
+// 侧栏和列表的宽度
+const (
+	sidebarWidth = 20
+	minListWidth = 24
+)
+
+// app 保存界面状态
+type app struct {
+	mgr  *vault.Manager
+	opts Options
+	t    *terminal
+
+	mode         mode
+	sidebarFocus bool
+
+	entries []*types.Entry // 当前过滤后显示的条目
+	cursor  int
+	offset  int
+
+	tags      []string // 侧栏中的标签，第一个为空字符串表示全部
+	tagCounts map[string]int
+	tagCursor int
+
+	filter *input
+	form   *form
+
+	// revealed 保存当前条目已解密的密码和备注，切换条目时清除
+	revealed *secrets
+
+	message string
+	isError bool
+}
+
+type secrets struct {
+	id       string
+	password string
+	notes    string
+}
+
+// Run 在当前终端中运行界面，直到用户退出
+//
+// mgr 必须已经打开。
+func Run(mgr *vault.Manager, opts Options) error {
+	if !mgr.IsOpen() {
+		return vault.ErrVaultNotOpen
+	}
+	t, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	a := &app{
+		mgr:    mgr,
+		opts:   opts,
+		t:      t,
+		filter: &input{label: "/"},
+	}
+	if err := a.refresh(""); err != nil {
+		return err
+	}
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	defer signal.Stop(resize)
+
+	keys := t.readKeys()
+	for {
+		a.draw()
+		select {
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if quit := a.handle(k); quit {
+				return nil
+			}
+			t.updateSize()
+		case <-resize:
+			t.updateSize()
+		}
+	}
+}
+
+// refresh 重新读取条目并应用标签和过滤条件，尽量保持选中 keep 条目
+func (a *app) refresh(keep string) error {
+	all, err := a.mgr.ListEntries()
+	if err != nil {
+		return err
+	}
+
+	a.tagCounts = make(map[string]int)
+	for _, e := range all {
+		for _, tag := range e.Tags {
+			a.tagCounts[tag]++
+		}
+	}
+	selectedTag := a.selectedTag()
+	a.tags = []string{""}
+	for tag := range a.tagCounts {
+		a.tags = append(a.tags, tag)
+	}
+	sort.Strings(a.tags[1:])
+	// 选中的标签已不存在时回到全部
+	a.tagCursor = 0
+	for i, tag := range a.tags {
+		if tag == selectedTag {
+			a.tagCursor = i
+		}
+	}
+	selectedTag = a.selectedTag()
+
+	if keep == "" {
+		if e := a.selected(); e != nil {
+			keep = e.Path()
+		}
+	}
+
+	type scored struct {
+		entry *types.Entry
+		score int
+	}
+	query := strings.TrimSpace(a.filter.String())
+	var matches []scored
+	for _, e := range all {
+		if selectedTag != "" && !hasTag(e, selectedTag) {
+			continue
+		}
+		// 已归档的条目只在搜索时显示
+		if e.Archived && query == "" {
+			continue
+		}
+		score, ok := matchEntry(query, e)
+		if !ok {
+			continue
+		}
+		matches = append(matches, scored{e, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if matches[i].entry.Favorite != matches[j].entry.Favorite {
+			return matches[i].entry.Favorite
+		}
+		return strings.ToLower(matches[i].entry.Path()) < strings.ToLower(matches[j].entry.Path())
+	})
+
+	a.entries = a.entries[:0]
+	for _, m := range matches {
+		a.entries = append(a.entries, m.entry)
+	}
+
+	a.cursor = 0
+	for i, e := range a.entries {
+		if e.Path() == keep {
+			a.cursor = i
+			break
+		}
+	}
+	a.hide()
+	return nil
+}
+
+// matchEntry 对条目的名称、用户名、URL 和标签进行模糊匹配，名称的得分加倍
+func matchEntry(pattern string, e *types.Entry) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	best, found := 0, false
+	try := func(text string, weight int) {
+		if s, ok := query.FuzzyScore(pattern, text); ok && (!found || s*weight > best) {
+			best, found = s*weight, true
+		}
+	}
+	try(e.Path(), 2)
+	try(e.Username, 1)
+	try(e.URL, 1)
+	for _, tag := range e.Tags {
+		try(tag, 1)
+	}
+	return best, found
+}
+
+func hasTag(e *types.Entry, tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *app) selected() *types.Entry {
+	if a.cursor < 0 || a.cursor >= len(a.entries) {
+		return nil
+	}
+	return a.entries[a.cursor]
+}
+
+func (a *app) selectedTag() string {
+	if a.tagCursor < 0 || a.tagCursor >= len(a.tags) {
+		return ""
+	}
+	return a.tags[a.tagCursor]
+}
+
+// hide 清除已解密的内容
+func (a *app) hide() {
+	a.revealed = nil
+}
+
+func (a *app) setMessage(format string, args ...interface{}) {
+	a.message = fmt.Sprintf(format, args...)
+	a.isError = false
+}
+
+func (a *app) setError(err error) {
+	a.message = "Error: " + err.Error()
+	a.isError = true
+}
+
+// handle 处理一个按键，返回是否退出
+func (a *app) handle(k key) bool {
+	if k.code == keyCtrl && k.r == 'c' {
+		return true
+	}
+	if a.mode != modeConfirm {
+		a.message = ""
+	}
+
+	switch a.mode {
+	case modeFilter:
+		a.handleFilter(k)
+	case modeForm:
+		a.handleForm(k)
+	case modeConfirm:
+		a.handleConfirm(k)
+	case modeHelp:
+		a.mode = modeBrowse
+	default:
+		return a.handleBrowse(k)
+	}
+	return false
+}
+
+func (a *app) handleBrowse(k key) bool {
+	switch k.code {
+	case keyUp:
+		a.move(-1)
+	case keyDown:
+		a.move(1)
+	case keyPgUp:
+		a.move(-a.bodyHeight())
+	case keyPgDn:
+		a.move(a.bodyHeight())
+	case keyHome:
+		a.move(-len(a.entries) - len(a.tags))
+	case keyEnd:
+		a.move(len(a.entries) + len(a.tags))
+	case keyTab, keyBacktab:
+		a.sidebarFocus = !a.sidebarFocus
+	case keyLeft:
+		a.sidebarFocus = true
+	case keyRight:
+		a.sidebarFocus = false
+	case keyEsc:
+		if a.filter.String() != "" {
+			a.filter.set("")
+			a.refresh("")
+		}
+	case keyEnter:
+		if a.sidebarFocus {
+			a.sidebarFocus = false
+		} else {
+			a.toggleReveal()
+		}
+	case keyRune:
+		switch k.r {
+		case 'q':
+			return true
+		case 'k':
+			a.move(-1)
+		case 'j':
+			a.move(1)
+		case 'g':
+			a.move(-len(a.entries) - len(a.tags))
+		case 'G':
+			a.move(len(a.entries) + len(a.tags))
+		case 'h':
+			a.sidebarFocus = true
+		case 'l':
+			a.sidebarFocus = false
+		case '/':
+			a.mode = modeFilter
+			a.filter.cursor = len(a.filter.value)
+		case 'r', ' ':
+			a.toggleReveal()
+		case 'c':
+			a.copyField("password")
+		case 'u':
+			a.copyField("username")
+		case 'U':
+			a.copyField("url")
+		case 'n':
+			a.copyField("notes")
+		case 'a':
+			a.form = newForm("New entry")
+			a.mode = modeForm
+		case 'e':
+			a.editSelected()
+		case 'd':
+			if e := a.selected(); e != nil {
+				a.mode = modeConfirm
+				a.setMessage("Delete '%s'? (y/N)", e.Path())
+			}
+		case '?':
+			a.mode = modeHelp
+		}
+	}
+	return false
+}
+
+// move 移动当前焦点所在面板的光标
+func (a *app) move(delta int) {
+	if a.sidebarFocus {
+		a.tagCursor = clamp(a.tagCursor+delta, 0, len(a.tags)-1)
+		a.refresh("")
+		return
+	}
+	old := a.cursor
+	a.cursor = clamp(a.cursor+delta, 0, len(a.entries)-1)
+	if a.cursor != old {
+		a.hide()
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
+
+func (a *app) handleFilter(k key) {
+	switch k.code {
+	case keyEnter:
+		a.mode = modeBrowse
+		return
+	case keyEsc:
+		a.filter.set("")
+		a.mode = modeBrowse
+	case keyUp, keyDown:
+		a.move(map[keyCode]int{keyUp: -1, keyDown: 1}[k.code])
+		return
+	default:
+		if !a.filter.handle(k) {
+			return
+		}
+	}
+	a.sidebarFocus = false
+	a.refresh("")
+}
+
+func (a *app) handleConfirm(k key) {
+	a.mode = modeBrowse
+	e := a.selected()
+	if e == nil || k.code != keyRune || (k.r != 'y' && k.r != 'Y') {
+		a.setMessage("Cancelled")
+		return
+	}
+	if err := a.mgr.DeleteEntry(e.ID); err != nil {
+		a.setError(err)
+		return
+	}
+	if err := a.refresh(""); err != nil {
+		a.setError(err)
+		return
+	}
+	if a.mgr.TrashRetention() > 0 {
+		a.setMessage("✓ Entry '%s' moved to the trash", e.Path())
+		return
+	}
+	a.setMessage("✓ Entry '%s' deleted", e.Path())
+}
+
+// toggleReveal 解密或隐藏当前条目的密码和备注
+func (a *app) toggleReveal() {
+	e := a.selected()
+	if e == nil {
+		return
+	}
+	if a.revealed != nil {
+		a.hide()
+		return
+	}
+	password, err := a.mgr.GetDecryptedPassword(e.ID)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	notes, err := a.mgr.GetDecryptedNotes(e.ID)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.revealed = &secrets{id: e.ID, password: password, notes: notes}
+}
+
+// copyField 复制当前条目的字段到剪贴板
+func (a *app) copyField(field string) {
+	e := a.selected()
+	if e == nil {
+		return
+	}
+	value, err := a.mgr.GetField(e.ID, field)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	if value == "" {
+		a.setMessage("%s is empty", fieldLabels[field])
+		return
+	}
+	if _, err := clipboard.Copy(value, a.opts.ClipboardTimeout); err != nil {
+		a.setError(err)
+		return
+	}
+	if a.opts.ClipboardTimeout > 0 {
+		a.setMessage("✓ %s copied to clipboard (clears in %s)", fieldLabels[field], a.opts.ClipboardTimeout)
+	} else {
+		a.setMessage("✓ %s copied to clipboard", fieldLabels[field])
+	}
+}
+
+var fieldLabels = map[string]string{
+	"username": "Username",
+	"password": "Password",
+	"url":      "URL",
+	"notes":    "Notes",
+}
+
+func (a *app) editSelected() {
+	e := a.selected()
+	if e == nil {
+		return
+	}
+	password, err := a.mgr.GetDecryptedPassword(e.ID)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	notes, err := a.mgr.GetDecryptedNotes(e.ID)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.form = newEditForm(e, password, notes)
+	a.mode = modeForm
+}
+
+func (a *app) handleForm(k key) {
+	f := a.form
+	switch k.code {
+	case keyEsc:
+		a.form = nil
+		a.mode = modeBrowse
+		a.setMessage("Cancelled")
+	case keyTab, keyDown:
+		f.move(1)
+	case keyBacktab, keyUp:
+		f.move(-1)
+	case keyEnter:
+		if f.focus == len(f.fields)-1 {
+			a.saveForm()
+		} else {
+			f.move(1)
+		}
+	case keyCtrl:
+		switch k.r {
+		case 's':
+			a.saveForm()
+		case 'r':
+			f.reveal = !f.reveal
+		case 'g':
+			password, err := types.SecureRandomString(20)
+			if err != nil {
+				a.setError(err)
+				return
+			}
+			f.fields[fieldPassword].set(password)
+			a.setMessage("Generated a random 20-character password")
+		default:
+			f.current().handle(k)
+		}
+	default:
+		f.current().handle(k)
+	}
+}
+
+// saveForm 通过 vault.Manager 保存表单，失败时保留表单并显示错误
+func (a *app) saveForm() {
+	f := a.form
+	var err error
+	name := f.value(fieldName)
+
+	if f.editing == "" {
+		if name == "" {
+			a.setError(errors.New("name is required"))
+			f.focus = fieldName
+			return
+		}
+		_, err = a.mgr.AddEntry(name,
+			f.value(fieldUsername),
+			f.fields[fieldPassword].String(),
+			f.value(fieldURL),
+			f.fields[fieldNotes].String(),
+			types.ParseTags(f.value(fieldTags)))
+	} else {
+		updates := f.updates()
+		if len(updates) == 0 {
+			a.form = nil
+			a.mode = modeBrowse
+			a.setMessage("Nothing changed")
+			return
+		}
+		_, err = a.mgr.UpdateEntry(f.editing, updates)
+	}
+	if err != nil {
+		a.setError(err)
+		return
+	}
+
+	a.form = nil
+	a.mode = modeBrowse
+	if err := a.refresh(name); err != nil {
+		a.setError(err)
+		return
+	}
+	a.sidebarFocus = false
+	if f.editing == "" {
+		a.setMessage("✓ Entry '%s' added", name)
+	} else {
+		a.setMessage("✓ Entry '%s' updated", name)
+	}
+}
+
+// bodyHeight 返回标题栏和状态栏之间的行数
+func (a *app) bodyHeight() int {
+	return max(a.t.height-2, 1)
+}
+
+// draw 重绘整个屏幕
+func (a *app) draw() {
+	w := a.t.width
+	lines := make([]string, 0, a.t.height)
+
+	title := fmt.Sprintf(" CipherHub  %d/%d entries", len(a.entries), a.total())
+	if q := a.filter.String(); q != "" {
+		title += "  filter: " + q
+	}
+	if tag := a.selectedTag(); tag != "" {
+		title += "  tag: " + tag
+	}
+	lines = append(lines, styled(styleReverse, fit(title, w)))
+
+	var body []string
+	cursorRow, cursorCol := -1, -1
+	switch a.mode {
+	case modeForm:
+		body, cursorRow, cursorCol = a.drawForm(w)
+	case modeHelp:
+		body = a.drawHelp(w)
+	default:
+		body = a.drawBrowse(w)
+	}
+	for i := 0; i < a.bodyHeight(); i++ {
+		if i < len(body) {
+			lines = append(lines, body[i])
+		} else {
+			lines = append(lines, fit("", w))
+		}
+	}
+
+	status, style := a.statusLine()
+	if a.mode == modeFilter {
+		text, col := a.filter.display(true)
+		status = "/" + text
+		cursorRow, cursorCol = a.t.height-1, 1+col
+	}
+	lines = append(lines, styled(style, fit(status, w)))
+
+	out := a.t.out
+	out.WriteString("\x1b[?25l\x1b[H")
+	out.WriteString(strings.Join(lines, "\r\n"))
+	if cursorRow >= 0 {
+		fmt.Fprintf(out, "\x1b[%d;%dH\x1b[?25h", cursorRow+1, cursorCol+1)
+	}
+	out.Flush()
+}
+
+func (a *app) total() int {
+	all, _ := a.mgr.ListEntries()
+	return len(all)
+}
+
+func (a *app) statusLine() (string, string) {
+	if a.message != "" {
+		if a.isError {
+			return " " + a.message, styleBold
+		}
+		return " " + a.message, ""
+	}
+	switch a.mode {
+	case modeForm:
+		return " Tab next · Shift-Tab prev · Ctrl-G generate · Ctrl-R reveal · Ctrl-S save · Esc cancel", styleDim
+	case modeHelp:
+		return " Press any key to return", styleDim
+	}
+	return " / filter · r reveal · c copy password · u username · a add · e edit · d delete · ? help · q quit", styleDim
+}
+
+// drawBrowse 绘制标签侧栏、条目列表和详情面板
+func (a *app) drawBrowse(w int) []string {
+	h := a.bodyHeight()
+	sw := sidebarWidth
+	if w < 80 {
+		sw = 0
+	}
+	lw := max((w-sw)*2/5, minListWidth)
+	dw := w - sw - lw - 2
+	if sw > 0 {
+		dw--
+	}
+	if dw < 10 {
+		lw = w - sw
+		dw = 0
+	}
+
+	// 保持光标在可见范围内
+	if a.cursor < a.offset {
+		a.offset = a.cursor
+	}
+	if a.cursor >= a.offset+h {
+		a.offset = a.cursor - h + 1
+	}
+
+	detail := a.detailLines(dw)
+	lines := make([]string, h)
+	for i := 0; i < h; i++ {
+		var b strings.Builder
+		if sw > 0 {
+			b.WriteString(a.sidebarCell(i, sw))
+			b.WriteString(styled(styleDim, "│"))
+		}
+		b.WriteString(a.listCell(a.offset+i, lw))
+		if dw > 0 {
+			b.WriteString(styled(styleDim, "│"))
+			b.WriteString(" ")
+			if i < len(detail) {
+				b.WriteString(detail[i])
+			} else {
+				b.WriteString(fit("", dw-1))
+			}
+		}
+		lines[i] = b.String()
+	}
+	return lines
+}
+
+func (a *app) sidebarCell(i, width int) string {
+	if i == 0 {
+		return styled(styleBold, fit(" TAGS", width))
+	}
+	idx := i - 1
+	if idx >= len(a.tags) {
+		return fit("", width)
+	}
+	label := "All"
+	count := a.total()
+	if tag := a.tags[idx]; tag != "" {
+		label, count = tag, a.tagCounts[tag]
+	}
+	countText := fmt.Sprintf(" %d ", count)
+	text := " " + fit(label, width-1-textWidth(countText)) + countText
+	if idx == a.tagCursor {
+		if a.sidebarFocus {
+			return styled(styleReverse, text)
+		}
+		return styled(styleBold, text)
+	}
+	return text
+}
+
+func (a *app) listCell(idx, width int) string {
+	if idx >= len(a.entries) {
+		if idx == 0 {
+			return styled(styleDim, fit(" No entries", width))
+		}
+		return fit("", width)
+	}
+	e := a.entries[idx]
+	nameWidth := width * 3 / 5
+	text := " " + fit(e.Path(), nameWidth-1) + " " + fit(e.Username, width-nameWidth-1)
+	if idx == a.cursor {
+		if a.sidebarFocus {
+			return styled(styleBold, text)
+		}
+		return styled(styleReverse, text)
+	}
+	return text
+}
+
+// detailLines 返回当前条目详情面板的各行
+func (a *app) detailLines(width int) []string {
+	if width <= 0 {
+		return nil
+	}
+	width--
+	e := a.selected()
+	if e == nil {
+		return nil
+	}
+
+	var lines []string
+	row := func(label, value string) {
+		lines = append(lines, styled(styleDim, fit(label, 10))+fit(value, width-10))
+	}
+
+	password := "••••••••  (r to reveal)"
+	notes := ""
+	if e.Notes != "" {
+		notes = "••••••••"
+	}
+	if a.revealed != nil && a.revealed.id == e.ID {
+		password = a.revealed.password
+		notes = a.revealed.notes
+	}
+
+	lines = append(lines, styled(styleBold, fit(e.Path(), width)))
+	lines = append(lines, fit("", width))
+	row("Username", e.Username)
+	row("Password", password)
+	row("URL", e.URL)
+	row("Tags", strings.Join(e.Tags, ", "))
+	row("Created", e.CreatedAt.Format("2006-01-02 15:04"))
+	row("Updated", e.UpdatedAt.Format("2006-01-02 15:04"))
+	if notes != "" {
+		lines = append(lines, fit("", width))
+		lines = append(lines, styled(styleDim, fit("Notes", width)))
+		for _, l := range wrap(notes, width) {
+			lines = append(lines, fit(l, width))
+		}
+	}
+	return lines
+}
+
+// drawForm 绘制表单，返回各行以及光标位置
+func (a *app) drawForm(w int) ([]string, int, int) {
+	f := a.form
+	lines := []string{
+		fit("", w),
+		styled(styleBold, fit("  "+f.title, w)),
+		fit("", w),
+	}
+	const labelWidth = 12
+	cursorRow, cursorCol := -1, -1
+	for i, in := range f.fields {
+		text, col := in.display(f.reveal)
+		label := fit("  "+in.label, labelWidth)
+		valueWidth := w - labelWidth - 2
+		value := fit(text, valueWidth)
+		switch {
+		case in.readOnly:
+			value = styled(styleDim, value)
+		case i == f.focus:
+			value = styled(styleReverse, value)
+			cursorRow, cursorCol = len(lines)+1, labelWidth+min(col, valueWidth-1)
+		}
+		lines = append(lines, label+value+"  ")
+	}
+	if f.editing != "" {
+		lines = append(lines, fit("", w), styled(styleDim, fit("  Tags are comma-separated. Renaming is not supported here.", w)))
+	} else {
+		lines = append(lines, fit("", w), styled(styleDim, fit("  Tags are comma-separated. Leave the password empty and press Ctrl-G to generate one.", w)))
+	}
+	return lines, cursorRow, cursorCol
+}
+
+var helpText = []string{
+	"Navigation",
+	"  ↑/↓, j/k        move the selection",
+	"  PgUp/PgDn, g/G  page up/down, first/last",
+	"  Tab, ←/→, h/l   switch between tag sidebar and entry list",
+	"  /               fuzzy filter (Enter keeps it, Esc clears it)",
+	"",
+	"Entries",
+	"  r, Space, Enter reveal or hide password and notes",
+	"  c               copy password",
+	"  u               copy username",
+	"  U               copy URL",
+	"  n               copy notes",
+	"  a               add an entry",
+	"  e               edit the selected entry",
+	"  d               delete the selected entry",
+	"",
+	"Forms",
+	"  Tab/Shift-Tab   next/previous field",
+	"  Ctrl-G          generate a password",
+	"  Ctrl-R          show or hide secret fields",
+	"  Ctrl-S          save (Enter on the last field also saves)",
+	"  Esc             cancel",
+	"",
+	"  q, Ctrl-C       quit",
+}
+
+func (a *app) drawHelp(w int) []string {
+	lines := []string{fit("", w)}
+	for _, l := range helpText {
+		if l != "" && !strings.HasPrefix(l, " ") {
+			lines = append(lines, styled(styleBold, fit("  "+l, w)))
+		} else {
+			lines = append(lines, fit("  "+l, w))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,239 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// MinIDPrefix 是按 ID 前缀查找条目时前缀的最小长度
+const MinIDPrefix = 4
+
+var (
+	// ErrAmbiguousEntry 表示名称、别名或 ID 前缀匹配了多个条目，具体的候选条目见 AmbiguousError
+	ErrAmbiguousEntry = errors.New("vault: ambiguous entry")
+	// ErrAliasExists 表示别名已被使用，或与某个条目的路径相同
+	ErrAliasExists = errors.New("vault: alias already exists")
+	// ErrAliasNotFound 表示没有条目使用该别名
+	ErrAliasNotFound = errors.New("vault: alias not found")
+	// ErrInvalidAlias 表示别名无效（如为空、包含空白、逗号或路径分隔符）
+	ErrInvalidAlias = errors.New("vault: invalid alias")
+)
+
+// AmbiguousError 表示一个条目引用匹配了多个条目
+//
+// 满足 errors.Is(err, ErrAmbiguousEntry)，错误信息中列出所有候选条目的路径和短 ID。
+type AmbiguousError struct {
+	Ref        string         // 用户指定的名称、别名或 ID 前缀
+	Candidates []*types.Entry // 所有匹配的条目
+}
+
+func (e *AmbiguousError) Error() string {
+	candidates := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		candidates[i] = fmt.Sprintf("%s (id %s)", c.Path(), c.ShortID())
+	}
+	return fmt.Sprintf("vault: %q matches %d entries: %s", e.Ref, len(e.Candidates), strings.Join(candidates, ", "))
+}
+
+func (e *AmbiguousError) Unwrap() error {
+	return ErrAmbiguousEntry
+}
+
+// findEntry 查找用户指定的条目
+//
+// ref 依次按完整路径、完整 ID、别名（不区分大小写）和 ID 前缀（至少 MinIDPrefix 个字符）匹配，
+// 使用第一种有匹配的方式；匹配多个条目时返回 *AmbiguousError。
+//
+// 参数:
+//   ref - 条目路径、ID、ID 前缀或别名
+//
+// 返回:
+//   找到的条目和可能的错误，没有匹配时返回 ErrEntryNotFound
+func (m *Manager) findEntry(ref string) (*types.Entry, error) {
+	return findEntryIn(m.vault.Entries, ref)
+}
+
+// findEntryIn 按 findEntry 的规则在 entries 中查找条目
+func findEntryIn(entries []*types.Entry, ref string) (*types.Entry, error) {
+	path := strings.TrimPrefix(ref, types.PathSeparator)
+	matchers := []func(e *types.Entry) bool{
+		func(e *types.Entry) bool { return e.Path() == path },
+		func(e *types.Entry) bool { return strings.EqualFold(e.ID, ref) },
+		func(e *types.Entry) bool { return e.HasAlias(ref) },
+	}
+	if isIDPrefix(ref) {
+		prefix := strings.ToLower(ref)
+		matchers = append(matchers, func(e *types.Entry) bool {
+			return strings.HasPrefix(strings.ToLower(e.ID), prefix)
+		})
+	}
+
+	for _, match := range matchers {
+		var found []*types.Entry
+		for _, e := range entries {
+			if match(e) {
+				found = append(found, e)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			return nil, &AmbiguousError{Ref: ref, Candidates: found}
+		}
+	}
+	return nil, ErrEntryNotFound
+}
+
+// isIDPrefix 返回 ref 是否可能是 ID 前缀：至少 MinIDPrefix 个字符，只包含十六进制数字和 -
+func isIDPrefix(ref string) bool {
+	if len(ref) < MinIDPrefix {
+		return false
+	}
+	for _, r := range ref {
+		if r != '-' && !unicode.Is(unicode.ASCII_Hex_Digit, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListAliases 列出所有别名及其条目
+//
+// 返回:
+//   别名到条目的映射和可能的错误
+func (m *Manager) ListAliases() (map[string]*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	aliases := make(map[string]*types.Entry)
+	for _, e := range m.vault.Entries {
+		for _, alias := range e.Aliases {
+			aliases[alias] = e
+		}
+	}
+	return aliases, nil
+}
+
+// AddAlias 为条目添加别名
+//
+// 参数:
+//   name - 条目路径、ID 或已有的别名
+//   alias - 新别名，不能包含空白、逗号和路径分隔符，不区分大小写地在密码库内唯一
+//
+// 返回:
+//   更新后的条目和可能的错误，别名已被使用或与条目路径相同时返回 ErrAliasExists
+func (m *Manager) AddAlias(name, alias string) (*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	if strings.TrimSpace(alias) == "" {
+		return nil, fmt.Errorf("%w: alias must not be empty", ErrInvalidAlias)
+	}
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := m.checkAliases(entry, append(append([]string{}, entry.Aliases...), alias))
+	if err != nil {
+		return nil, err
+	}
+	if len(aliases) == len(entry.Aliases) {
+		return nil, ErrAliasExists
+	}
+
+	entry.Aliases = aliases
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// RemoveAlias 删除别名
+//
+// 参数:
+//   alias - 要删除的别名（不区分大小写）
+//
+// 返回:
+//   使用该别名的条目和可能的错误，没有条目使用该别名时返回 ErrAliasNotFound
+func (m *Manager) RemoveAlias(alias string) (*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	for _, e := range m.vault.Entries {
+		if !e.HasAlias(alias) {
+			continue
+		}
+		aliases := make([]string, 0, len(e.Aliases)-1)
+		for _, a := range e.Aliases {
+			if !strings.EqualFold(a, alias) {
+				aliases = append(aliases, a)
+			}
+		}
+		e.Aliases = aliases
+		if len(aliases) == 0 {
+			e.Aliases = nil
+		}
+		if err := m.save(); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	return nil, ErrAliasNotFound
+}
+
+// checkPath 检查条目 entry 能否使用路径 path（新建条目时 entry 为 nil）
+//
+// 路径不能被其他条目使用，也不能与任何别名相同，否则按名称查找时该别名将无法使用；
+// aliases 是 entry 修改后的别名列表。
+func (m *Manager) checkPath(entry *types.Entry, path string, aliases []string) error {
+	if other := m.findEntryByPath(path); other != nil && other != entry {
+		return ErrEntryExists
+	}
+	for _, e := range m.vault.Entries {
+		if e != entry && slices.Contains(e.Aliases, path) {
+			return fmt.Errorf("%w: %q is an alias of %s", ErrEntryExists, path, e.Path())
+		}
+	}
+	if slices.Contains(aliases, path) {
+		return fmt.Errorf("%w: %q is an alias of this entry, remove it first", ErrEntryExists, path)
+	}
+	return nil
+}
+
+// checkAliases 检查条目 entry 的新别名列表，返回去重、排序后的列表
+func (m *Manager) checkAliases(entry *types.Entry, aliases []string) ([]string, error) {
+	seen := make(map[string]bool, len(aliases))
+	var out []string
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" {
+			continue
+		}
+		if strings.ContainsFunc(alias, unicode.IsSpace) || strings.ContainsAny(alias, types.PathSeparator+",") {
+			return nil, fmt.Errorf("%w: %q must not contain spaces, commas or %q", ErrInvalidAlias, alias, types.PathSeparator)
+		}
+		key := strings.ToLower(alias)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		for _, e := range m.vault.Entries {
+			if e.Path() == alias || (e != entry && e.HasAlias(alias)) {
+				return nil, fmt.Errorf("%w: %q is used by %s", ErrAliasExists, alias, e.Path())
+			}
+		}
+		out = append(out, alias)
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,230 @@
+package vault
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+func TestFindEntry(t *testing.T) {
+	entries := []*types.Entry{
+		{ID: "abcd1234-0000-4000-8000-000000000001", Name: "github", Folder: "work", Aliases: []string{"gh"}},
+		{ID: "abcd5678-0000-4000-8000-000000000002", Name: "github", Folder: "personal"},
+		{ID: "ef012345-0000-4000-8000-000000000003", Name: "beef", Aliases: []string{"db"}},
+		{ID: "beef0000-0000-4000-8000-000000000004", Name: "deadbeef"},
+	}
+	tests := []struct {
+		name           string
+		ref            string
+		want           string // 找到的条目 ID
+		wantErr        error
+		wantCandidates int
+	}{
+		{"path", "work/github", entries[0].ID, nil, 0},
+		{"path with leading separator", "/personal/github", entries[1].ID, nil, 0},
+		{"full ID", entries[1].ID, entries[1].ID, nil, 0},
+		{"full ID upper case", "ABCD5678-0000-4000-8000-000000000002", entries[1].ID, nil, 0},
+		{"alias", "gh", entries[0].ID, nil, 0},
+		{"alias is case insensitive", "GH", entries[0].ID, nil, 0},
+		{"ID prefix", "ef01", entries[2].ID, nil, 0},
+		{"ID prefix upper case", "ABCD1", entries[0].ID, nil, 0},
+		{"path wins over ID prefix", "beef", entries[2].ID, nil, 0},
+		{"ambiguous ID prefix", "abcd", "", ErrAmbiguousEntry, 2},
+		{"short ID prefix", "abc", "", ErrEntryNotFound, 0},
+		{"name without folder", "github", "", ErrEntryNotFound, 0},
+		{"unknown", "gitlab", "", ErrEntryNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findEntryIn(entries, tt.ref)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("findEntryIn(%q) error = %v, want %v", tt.ref, err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				var ae *AmbiguousError
+				if errors.As(err, &ae) != (tt.wantCandidates > 0) {
+					t.Fatalf("findEntryIn(%q) error = %T, want AmbiguousError: %t", tt.ref, err, tt.wantCandidates > 0)
+				}
+				if ae != nil && len(ae.Candidates) != tt.wantCandidates {
+					t.Fatalf("candidates = %d, want %d", len(ae.Candidates), tt.wantCandidates)
+				}
+				return
+			}
+			if got.ID != tt.want {
+				t.Fatalf("findEntryIn(%q) = %s, want %s", tt.ref, got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmbiguousErrorMessage(t *testing.T) {
+	err := &AmbiguousError{Ref: "abcd", Candidates: []*types.Entry{
+		{ID: "abcd1234-0000", Name: "github", Folder: "work"},
+		{ID: "abcd5678-0000", Name: "github", Folder: "personal"},
+	}}
+	want := `vault: "abcd" matches 2 entries: work/github (id abcd1234), personal/github (id abcd5678)`
+	if err.Error() != want {
+		t.Fatalf("Error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestAddAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		alias   string
+		wantErr error
+		want    []string
+	}{
+		{"new alias", "github", "hub", nil, []string{"gh", "hub"}},
+		{"aliases are sorted", "github", "abc", nil, []string{"abc", "gh"}},
+		{"by existing alias", "gh", "hub", nil, []string{"gh", "hub"}},
+		{"duplicate on same entry", "github", "GH", ErrAliasExists, []string{"gh"}},
+		{"used by another entry", "gitlab", "gh", ErrAliasExists, []string{"gh"}},
+		{"same as a path", "github", "gitlab", ErrAliasExists, []string{"gh"}},
+		{"empty", "github", " ", ErrInvalidAlias, []string{"gh"}},
+		{"contains space", "github", "git hub", ErrInvalidAlias, []string{"gh"}},
+		{"contains comma", "github", "a,b", ErrInvalidAlias, []string{"gh"}},
+		{"contains separator", "github", "work/gh", ErrInvalidAlias, []string{"gh"}},
+		{"unknown entry", "bitbucket", "bb", ErrEntryNotFound, []string{"gh"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t)
+			for _, name := range []string{"github", "gitlab"} {
+				if _, err := m.AddEntry(name, "alice", "hunter2", "", "", nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := m.AddAlias("github", "gh"); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := m.AddAlias(tt.entry, tt.alias)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddAlias error = %v, want %v", err, tt.wantErr)
+			}
+			entry, err := m.GetEntry("github")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(entry.Aliases, tt.want) {
+				t.Fatalf("aliases = %v, want %v", entry.Aliases, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveAlias(t *testing.T) {
+	m, _ := newTestManager(t)
+	if _, err := m.AddEntry("github", "alice", "hunter2", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddAlias("github", "gh"); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := m.RemoveAlias("GH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Aliases != nil {
+		t.Fatalf("aliases = %v, want none", entry.Aliases)
+	}
+	if _, err := m.GetEntry("gh"); !errors.Is(err, ErrEntryNotFound) {
+		t.Fatalf("GetEntry by removed alias error = %v, want ErrEntryNotFound", err)
+	}
+	if _, err := m.RemoveAlias("gh"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("RemoveAlias error = %v, want ErrAliasNotFound", err)
+	}
+}
+
+func TestPathAliasCollision(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      func(m *Manager) error
+		wantErr error
+	}{
+		{"add entry named like an alias", func(m *Manager) error {
+			_, err := m.AddEntry("gh", "bob", "pw", "", "", nil)
+			return err
+		}, ErrEntryExists},
+		{"add entry named like an alias in a folder", func(m *Manager) error {
+			_, err := m.AddEntry("work/gh", "bob", "pw", "", "", nil)
+			return err
+		}, nil},
+		{"rename to another entry's alias", func(m *Manager) error {
+			_, err := m.UpdateEntry("gitlab", map[string]string{"name": "gh"})
+			return err
+		}, ErrEntryExists},
+		{"move to another entry's alias", func(m *Manager) error {
+			_, err := m.MoveEntry("gitlab", "gh")
+			return err
+		}, ErrEntryExists},
+		{"rename to own alias", func(m *Manager) error {
+			_, err := m.UpdateEntry("github", map[string]string{"name": "gh"})
+			return err
+		}, ErrEntryExists},
+		{"rename to own alias while removing it", func(m *Manager) error {
+			_, err := m.UpdateEntry("github", map[string]string{"name": "gh", "aliases": ""})
+			return err
+		}, nil},
+		{"restore to an alias", func(m *Manager) error {
+			if err := m.DeleteEntry("gitlab"); err != nil {
+				return err
+			}
+			_, err := m.RestoreEntry("gitlab", "gh")
+			return err
+		}, ErrEntryExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t)
+			for _, name := range []string{"github", "gitlab"} {
+				if _, err := m.AddEntry(name, "alice", "hunter2", "", "", nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := m.AddAlias("github", "gh"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.op(m); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			// 出错时别名仍指向原来的条目
+			if tt.wantErr != nil {
+				entry, err := m.GetEntry("gh")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if entry.Path() != "github" {
+					t.Fatalf("alias gh resolves to %s, want github", entry.Path())
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreEntryDropsAliasEqualToPath(t *testing.T) {
+	m, _ := newTestManager(t)
+	if _, err := m.AddEntry("github", "alice", "hunter2", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddAlias("github", "gh"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteEntry("github"); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := m.RestoreEntry("github", "gh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Path() != "gh" || len(entry.Aliases) != 0 {
+		t.Fatalf("restored %s with aliases %v, want gh without aliases", entry.Path(), entry.Aliases)
+	}
+}
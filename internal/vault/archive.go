@@ -0,0 +1,50 @@
+package vault
+
+import (
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// SetFavorite 收藏条目或取消收藏
+//
+// 收藏不算对条目的修改，不更新 UpdatedAt。
+//
+// 参数:
+//   name - 条目名称
+//   favorite - 是否收藏
+//
+// 返回:
+//   更新后的条目和可能的错误
+func (m *Manager) SetFavorite(name string, favorite bool) (*types.Entry, error) {
+	return m.setFlag(name, func(e *types.Entry) { e.Favorite = favorite })
+}
+
+// SetArchived 归档条目或取消归档
+//
+// 归档的条目仍可以按名称使用，只是默认不在列表中显示，也不再提醒轮换密码。
+// 归档不算对条目的修改，不更新 UpdatedAt。
+//
+// 参数:
+//   name - 条目名称
+//   archived - 是否归档
+//
+// 返回:
+//   更新后的条目和可能的错误
+func (m *Manager) SetArchived(name string, archived bool) (*types.Entry, error) {
+	return m.setFlag(name, func(e *types.Entry) { e.Archived = archived })
+}
+
+// setFlag 对条目 name 调用 set 并保存密码库
+func (m *Manager) setFlag(name string, set func(e *types.Entry)) (*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	set(entry)
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,374 @@
+package vault
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/imerr0rlog/CipherHub/internal/storage"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// AttachmentChunkSize 是附件每个数据块的明文大小
+//
+// 添加和读取附件时同时只在内存中保留一个数据块，因此附件可以大于可用内存。
+const AttachmentChunkSize = 1 << 20
+
+// attachmentAADPrefix 是附件数据块附加数据的前缀，与其他用途的密文区分
+const attachmentAADPrefix = "cipherhub-attachment\x00"
+
+var (
+	// ErrNoBlobStore 表示没有设置附件数据块的存储
+	ErrNoBlobStore = errors.New("vault: attachments are not available")
+	// ErrAttachmentNotFound 表示条目没有指定名称的附件
+	ErrAttachmentNotFound = errors.New("vault: attachment not found")
+	// ErrAttachmentExists 表示条目已有同名附件
+	ErrAttachmentExists = errors.New("vault: attachment already exists")
+	// ErrInvalidAttachmentName 表示附件名称无效（如为空或包含路径分隔符）
+	ErrInvalidAttachmentName = errors.New("vault: invalid attachment name")
+	// ErrAttachmentCorrupted 表示附件数据块缺失、被篡改或被截断
+	ErrAttachmentCorrupted = errors.New("vault: attachment corrupted")
+)
+
+// BlobStore 根据名称返回保存附件数据块的存储
+type BlobStore func(name string) (storage.Storage, error)
+
+// SetBlobStore 设置附件数据块的存储
+//
+// 参数:
+//   store - 根据数据块名称创建存储的函数，传入 nil 时附件操作返回 ErrNoBlobStore
+func (m *Manager) SetBlobStore(store BlobStore) {
+	m.blobs = store
+}
+
+// AddAttachment 读取 r 的全部内容，加密后作为条目 name 的附件 filename 保存
+//
+// 内容按 AttachmentChunkSize 分块，每块使用 AES-256-GCM 单独加密并作为独立的数据块写入，
+// 附加数据包含附件 ID、块序号和是否为最后一块，防止数据块被替换、重排或截断。
+// 全部数据块写入后才保存密码库；中途失败时删除已写入的数据块。
+//
+// 参数:
+//   name - 条目名称
+//   filename - 附件名称，在条目内唯一
+//   r - 附件内容
+//
+// 返回:
+//   新附件的元数据和可能的错误
+func (m *Manager) AddAttachment(name, filename string, r io.Reader) (*types.Attachment, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	if m.blobs == nil {
+		return nil, ErrNoBlobStore
+	}
+	if err := validateAttachmentName(filename); err != nil {
+		return nil, err
+	}
+
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	if findAttachment(entry, filename) != -1 {
+		return nil, ErrAttachmentExists
+	}
+
+	id, err := types.GenerateUUID()
+	if err != nil {
+		return nil, ErrRandomGenFailed
+	}
+	att := types.Attachment{ID: id, Name: filename, CreatedAt: time.Now()}
+
+	if err := m.writeChunks(&att, r); err != nil {
+		m.deleteChunks(att)
+		return nil, err
+	}
+
+	entry.Attachments = append(entry.Attachments, att)
+	entry.UpdatedAt = time.Now()
+	if err := m.save(); err != nil {
+		entry.Attachments = entry.Attachments[:len(entry.Attachments)-1]
+		m.deleteChunks(att)
+		return nil, err
+	}
+	return &att, nil
+}
+
+// writeChunks 分块加密 r 的内容并写入存储，更新 att 的大小和块数
+//
+// 空文件也写入一个空的最后一块，读取时据此确认内容完整。
+func (m *Manager) writeChunks(att *types.Attachment, r io.Reader) error {
+	br := bufio.NewReader(r)
+	buf := make([]byte, AttachmentChunkSize)
+	for {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read attachment: %w", err)
+		}
+		final := n < len(buf)
+		if !final {
+			if _, err := br.Peek(1); err == io.EOF {
+				final = true
+			} else if err != nil {
+				return fmt.Errorf("failed to read attachment: %w", err)
+			}
+		}
+
+		sealed, err := m.crypto.Seal(buf[:n], chunkAAD(att.ID, att.Chunks, final))
+		if err != nil {
+			return err
+		}
+		st, err := m.blobs(chunkName(att.ID, att.Chunks))
+		if err != nil {
+			return err
+		}
+		// 先计数，写入失败时清理也会删除可能已部分写入的这一块
+		att.Chunks++
+		if err := st.Write(m.ctx, sealed); err != nil {
+			return err
+		}
+		att.Size += int64(n)
+
+		if final {
+			return nil
+		}
+	}
+}
+
+// ReadAttachment 解密条目 name 的附件 filename 并写入 w
+//
+// 数据块逐个读取、验证和解密。任何数据块缺失、被篡改或内容被截断时返回 ErrAttachmentCorrupted，
+// 此时 w 中可能已写入部分内容，调用方应丢弃。
+//
+// 参数:
+//   name - 条目名称
+//   filename - 附件名称
+//   w - 接收明文内容
+//
+// 返回:
+//   附件的元数据和可能的错误
+func (m *Manager) ReadAttachment(name, filename string, w io.Writer) (*types.Attachment, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	if m.blobs == nil {
+		return nil, ErrNoBlobStore
+	}
+
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	idx := findAttachment(entry, filename)
+	if idx == -1 {
+		return nil, ErrAttachmentNotFound
+	}
+	att := entry.Attachments[idx]
+
+	var size int64
+	for i := 0; i < att.Chunks; i++ {
+		st, err := m.blobs(chunkName(att.ID, i))
+		if err != nil {
+			return nil, err
+		}
+		sealed, err := st.Read(m.ctx)
+		if err != nil {
+			if errors.Is(err, storage.ErrStorageNotFound) {
+				return nil, fmt.Errorf("%w: chunk %d is missing", ErrAttachmentCorrupted, i)
+			}
+			return nil, err
+		}
+		plain, err := m.crypto.Open(sealed, chunkAAD(att.ID, i, i == att.Chunks-1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: chunk %d failed authentication", ErrAttachmentCorrupted, i)
+		}
+		if _, err := w.Write(plain); err != nil {
+			return nil, err
+		}
+		size += int64(len(plain))
+	}
+
+	if att.Chunks == 0 || size != att.Size {
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrAttachmentCorrupted, att.Size, size)
+	}
+	return &att, nil
+}
+
+// RemoveAttachment 删除条目 name 的附件 filename
+//
+// 先保存密码库再删除数据块。数据块删除失败时忽略，残留的数据块无法再被引用，
+// 不影响密码库的使用。
+//
+// 参数:
+//   name - 条目名称
+//   filename - 附件名称
+//
+// 返回:
+//   可能的错误
+func (m *Manager) RemoveAttachment(name, filename string) error {
+	if !m.open {
+		return ErrVaultNotOpen
+	}
+
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return err
+	}
+	idx := findAttachment(entry, filename)
+	if idx == -1 {
+		return ErrAttachmentNotFound
+	}
+	att := entry.Attachments[idx]
+
+	entry.Attachments = append(entry.Attachments[:idx:idx], entry.Attachments[idx+1:]...)
+	entry.UpdatedAt = time.Now()
+	if err := m.save(); err != nil {
+		return err
+	}
+	m.deleteChunks(att)
+	return nil
+}
+
+// PushAttachments 将密码库（包括回收站）中所有附件的数据块复制到 to
+//
+// 数据块按附件 ID 命名且不会被修改，to 中已存在的数据块直接跳过。同步密码库时应先复制数据块，
+// 再写入密码库文件，使远程密码库引用的数据块都已存在。
+//
+// 参数:
+//   ctx - 用于取消或限制复制时间的 context
+//   to - 目标数据块存储，如远程存储
+//
+// 返回:
+//   复制的数据块数量和可能的错误，本地缺少数据块时返回 ErrAttachmentCorrupted
+func (m *Manager) PushAttachments(ctx context.Context, to BlobStore) (int, error) {
+	if !m.open {
+		return 0, ErrVaultNotOpen
+	}
+	if m.blobs == nil {
+		return 0, ErrNoBlobStore
+	}
+	return SyncAttachments(ctx, m.vault, m.blobs, to)
+}
+
+// SyncAttachments 将 v（包括回收站）中所有附件的数据块从 from 复制到 to
+//
+// 附件的元数据不加密，因此不需要主密码，可以用于拉取远程密码库。to 中已存在的数据块直接跳过。
+// from 中缺少数据块的附件不会中断复制，全部复制完成后对每个这样的附件返回一个
+// ErrAttachmentCorrupted 错误（合并为一个错误）；其他错误立即返回。
+//
+// 参数:
+//   ctx - 用于取消或限制复制时间的 context
+//   v - 密码库数据
+//   from - 源数据块存储
+//   to - 目标数据块存储
+//
+// 返回:
+//   复制的数据块数量和可能的错误
+func SyncAttachments(ctx context.Context, v *types.Vault, from, to BlobStore) (int, error) {
+	copied := 0
+	var missing []error
+	for _, entries := range [][]*types.Entry{v.Entries, v.Trash} {
+		for _, entry := range entries {
+			for _, att := range entry.Attachments {
+				n, err := copyChunks(ctx, att, from, to)
+				copied += n
+				if errors.Is(err, storage.ErrStorageNotFound) {
+					missing = append(missing, fmt.Errorf("%w: %s of entry '%s' is missing blobs", ErrAttachmentCorrupted, att.Name, entry.Path()))
+					continue
+				}
+				if err != nil {
+					return copied, err
+				}
+			}
+		}
+	}
+	return copied, errors.Join(missing...)
+}
+
+// copyChunks 复制附件的数据块，源数据块不存在时返回 storage.ErrStorageNotFound
+func copyChunks(ctx context.Context, att types.Attachment, from, to BlobStore) (int, error) {
+	copied := 0
+	for i := 0; i < att.Chunks; i++ {
+		dst, err := to(chunkName(att.ID, i))
+		if err != nil {
+			return copied, err
+		}
+		exists, err := dst.Exists(ctx)
+		if err != nil {
+			return copied, err
+		}
+		if exists {
+			continue
+		}
+
+		src, err := from(chunkName(att.ID, i))
+		if err != nil {
+			return copied, err
+		}
+		data, err := src.Read(ctx)
+		if err != nil {
+			return copied, err
+		}
+		if err := dst.Write(ctx, data); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+	return copied, nil
+}
+
+// deleteChunks 尽力删除附件的所有数据块
+func (m *Manager) deleteChunks(att types.Attachment) {
+	if m.blobs == nil {
+		return
+	}
+	for i := 0; i < att.Chunks; i++ {
+		if st, err := m.blobs(chunkName(att.ID, i)); err == nil {
+			st.Delete(m.ctx)
+		}
+	}
+}
+
+// validateAttachmentName 检查附件名称：不能为空，不能包含路径分隔符或控制字符
+func validateAttachmentName(filename string) error {
+	if strings.TrimSpace(filename) == "" || filename == "." || filename == ".." {
+		return ErrInvalidAttachmentName
+	}
+	if strings.ContainsAny(filename, `/\`) || strings.IndexFunc(filename, unicode.IsControl) != -1 {
+		return fmt.Errorf("%w: %q", ErrInvalidAttachmentName, filename)
+	}
+	return nil
+}
+
+// findAttachment 返回条目中名为 filename 的附件的下标，不存在时返回 -1
+func findAttachment(entry *types.Entry, filename string) int {
+	for i, att := range entry.Attachments {
+		if att.Name == filename {
+			return i
+		}
+	}
+	return -1
+}
+
+// chunkName 返回附件第 i 块的数据块名称
+func chunkName(id string, i int) string {
+	return fmt.Sprintf("%s.%d", id, i)
+}
+
+// chunkAAD 返回附件第 i 块的附加数据
+func chunkAAD(id string, i int, final bool) []byte {
+	aad := make([]byte, 0, len(attachmentAADPrefix)+len(id)+9)
+	aad = append(aad, attachmentAADPrefix...)
+	aad = append(aad, id...)
+	aad = binary.BigEndian.AppendUint64(aad, uint64(i))
+	if final {
+		return append(aad, 1)
+	}
+	return append(aad, 0)
+}
@@ -0,0 +1,176 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imerr0rlog/CipherHub/internal/storage"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// dirBlobStore 返回把数据块保存在 dir 中的 BlobStore
+func dirBlobStore(dir string) BlobStore {
+	return func(name string) (storage.Storage, error) {
+		return storage.NewLocalStorage(filepath.Join(dir, name)), nil
+	}
+}
+
+// newAttachmentManager 初始化一个数据块保存在同目录 attachments 下的密码库
+func newAttachmentManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	m, path := newTestManager(t)
+	m.SetBlobStore(dirBlobStore(filepath.Join(filepath.Dir(path), "attachments")))
+	return m, path
+}
+
+func TestAttachmentRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"small", 100},
+		{"exact chunk", AttachmentChunkSize},
+		{"multiple chunks", 2*AttachmentChunkSize + 17},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newAttachmentManager(t)
+			if _, err := m.AddEntry("server", "root", "pw", "", "", nil); err != nil {
+				t.Fatal(err)
+			}
+			content := bytes.Repeat([]byte("k"), tt.size)
+			att, err := m.AddAttachment("server", "key.pem", bytes.NewReader(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if att.Size != int64(tt.size) {
+				t.Fatalf("Size = %d, want %d", att.Size, tt.size)
+			}
+
+			var buf bytes.Buffer
+			if _, err := m.ReadAttachment("server", "key.pem", &buf); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), content) {
+				t.Fatalf("ReadAttachment returned %d bytes, want %d", buf.Len(), tt.size)
+			}
+		})
+	}
+}
+
+func TestReadAttachmentCorrupted(t *testing.T) {
+	m, path := newAttachmentManager(t)
+	if _, err := m.AddEntry("server", "root", "pw", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	att, err := m.AddAttachment("server", "key.pem", strings.NewReader("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := filepath.Join(filepath.Dir(path), "attachments", chunkName(att.ID, 0))
+	if err := os.WriteFile(chunk, []byte("tampered"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.ReadAttachment("server", "key.pem", &buf); !errors.Is(err, ErrAttachmentCorrupted) {
+		t.Fatalf("ReadAttachment error = %v, want ErrAttachmentCorrupted", err)
+	}
+}
+
+// TestSyncAttachments 推送带附件的密码库，再拉取到另一个密码库
+func TestSyncAttachments(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newAttachmentManager(t)
+	if _, err := m.AddEntry("server", "root", "pw", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	content := bytes.Repeat([]byte("0123456789"), AttachmentChunkSize/5)
+	if _, err := m.AddAttachment("server", "backup.tar", bytes.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 推送：先复制数据块，再写入密码库
+	remoteDir := t.TempDir()
+	remoteBlobs := dirBlobStore(filepath.Join(remoteDir, "attachments"))
+	copied, err := m.PushAttachments(ctx, remoteBlobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != 2 {
+		t.Fatalf("pushed %d chunks, want 2", copied)
+	}
+	remote := storage.NewLocalStorage(filepath.Join(remoteDir, "vault.json"))
+	if err := m.SyncContext(ctx, remote); err != nil {
+		t.Fatal(err)
+	}
+	if copied, err := m.PushAttachments(ctx, remoteBlobs); err != nil || copied != 0 {
+		t.Fatalf("second push copied %d chunks, %v, want 0", copied, err)
+	}
+
+	// 拉取到另一个目录中的密码库
+	data, err := remote.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remoteVault types.Vault
+	if err := json.Unmarshal(data, &remoteVault); err != nil {
+		t.Fatal(err)
+	}
+	otherDir := t.TempDir()
+	otherBlobs := dirBlobStore(filepath.Join(otherDir, "attachments"))
+	if _, err := SyncAttachments(ctx, &remoteVault, remoteBlobs, otherBlobs); err != nil {
+		t.Fatal(err)
+	}
+	otherPath := filepath.Join(otherDir, "vault.json")
+	if err := os.WriteFile(otherPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := reopen(t, otherPath, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.SetBlobStore(otherBlobs)
+	var buf bytes.Buffer
+	if _, err := other.ReadAttachment("server", "backup.tar", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), content) {
+		t.Fatal("pulled attachment differs from the original")
+	}
+}
+
+func TestSyncAttachmentsReportsMissingChunks(t *testing.T) {
+	ctx := context.Background()
+	m, path := newAttachmentManager(t)
+	if _, err := m.AddEntry("server", "root", "pw", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	lost, err := m.AddAttachment("server", "lost.txt", strings.NewReader("gone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddAttachment("server", "kept.txt", strings.NewReader("here")); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(filepath.Dir(path), "attachments", chunkName(lost.ID, 0)))
+
+	copied, err := m.PushAttachments(ctx, dirBlobStore(t.TempDir()))
+	if !errors.Is(err, ErrAttachmentCorrupted) {
+		t.Fatalf("PushAttachments error = %v, want ErrAttachmentCorrupted", err)
+	}
+	if !strings.Contains(err.Error(), "lost.txt") || strings.Contains(err.Error(), "kept.txt") {
+		t.Fatalf("error %q should name only lost.txt", err)
+	}
+	if copied != 1 {
+		t.Fatalf("copied %d chunks, want 1", copied)
+	}
+}
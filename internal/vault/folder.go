@@ -0,0 +1,227 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+var (
+	// ErrFolderNotFound 表示文件夹不存在
+	ErrFolderNotFound = errors.New("vault: folder not found")
+	// ErrFolderExists 表示文件夹已存在
+	ErrFolderExists = errors.New("vault: folder already exists")
+	// ErrFolderNotEmpty 表示文件夹中还有条目或子文件夹
+	ErrFolderNotEmpty = errors.New("vault: folder not empty")
+)
+
+// ListFolders 列出所有文件夹的路径
+//
+// 返回:
+//   按字典序排列的文件夹路径（包括空文件夹和条目所在文件夹的上级文件夹）和可能的错误
+func (m *Manager) ListFolders() ([]string, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	m.vault.NormalizeFolders()
+	return m.vault.Folders, nil
+}
+
+// AddFolder 创建文件夹，上级文件夹不存在时一并创建
+//
+// 参数:
+//   path - 文件夹路径，如 work/aws/prod
+//
+// 返回:
+//   规范化后的文件夹路径和可能的错误，文件夹已存在时返回 ErrFolderExists
+func (m *Manager) AddFolder(path string) (string, error) {
+	if !m.open {
+		return "", ErrVaultNotOpen
+	}
+	folder, err := types.CleanFolder(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidName, err)
+	}
+	if folder == "" || m.folderExists(folder) {
+		return "", ErrFolderExists
+	}
+
+	m.vault.Folders = append(m.vault.Folders, folder)
+	if err := m.save(); err != nil {
+		return "", err
+	}
+	return folder, nil
+}
+
+// RemoveFolder 删除空文件夹
+//
+// 参数:
+//   path - 文件夹路径
+//
+// 返回:
+//   可能的错误，文件夹中还有条目或子文件夹时返回 ErrFolderNotEmpty
+func (m *Manager) RemoveFolder(path string) error {
+	if !m.open {
+		return ErrVaultNotOpen
+	}
+	folder, err := types.CleanFolder(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidName, err)
+	}
+	if folder == "" || !m.folderExists(folder) {
+		return ErrFolderNotFound
+	}
+
+	for _, e := range m.vault.Entries {
+		if types.InFolder(e.Folder, folder) {
+			return ErrFolderNotEmpty
+		}
+	}
+	folders := make([]string, 0, len(m.vault.Folders))
+	for _, f := range m.vault.Folders {
+		if f == folder {
+			continue
+		}
+		if types.InFolder(f, folder) {
+			return ErrFolderNotEmpty
+		}
+		folders = append(folders, f)
+	}
+
+	m.vault.Folders = folders
+	return m.save()
+}
+
+// MoveEntry 移动或重命名条目
+//
+// dest 以 / 结尾、为空或是已存在的文件夹时，条目保留名称移动到该文件夹（空表示根目录，
+// 文件夹不存在时自动创建）；否则 dest 是条目的新路径。
+//
+// 参数:
+//   name - 条目路径
+//   dest - 目标文件夹或新路径
+//
+// 返回:
+//   移动后的条目和可能的错误，目标路径已被其他条目使用或与某个别名相同时返回 ErrEntryExists
+func (m *Manager) MoveEntry(name, dest string) (*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return nil, err
+	}
+
+	path := dest
+	if m.isFolderTarget(dest) {
+		folder, err := types.CleanFolder(dest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidName, err)
+		}
+		path = types.JoinPath(folder, entry.Name)
+	}
+	return m.UpdateEntry(name, map[string]string{"name": path})
+}
+
+// MoveFolder 移动或重命名文件夹，其中的条目和子文件夹一起移动
+//
+// dest 以 / 结尾、为空或是已存在的文件夹时，src 移动到该文件夹中；否则 dest 是 src 的新路径。
+//
+// 参数:
+//   src - 文件夹路径
+//   dest - 目标文件夹或新路径
+//
+// 返回:
+//   移动后的文件夹路径、移动的条目数量和可能的错误。
+//   目标文件夹已存在时返回 ErrFolderExists，移动后的路径与其他条目冲突时返回 ErrEntryExists。
+func (m *Manager) MoveFolder(src, dest string) (string, int, error) {
+	if !m.open {
+		return "", 0, ErrVaultNotOpen
+	}
+	from, err := types.CleanFolder(src)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrInvalidName, err)
+	}
+	if from == "" || !m.folderExists(from) {
+		return "", 0, ErrFolderNotFound
+	}
+
+	to, err := types.CleanFolder(dest)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrInvalidName, err)
+	}
+	if m.isFolderTarget(dest) {
+		_, base := types.SplitPath(from)
+		to = types.JoinPath(to, base)
+	}
+	if to == from {
+		return to, 0, nil
+	}
+	if types.InFolder(to, from) {
+		return "", 0, fmt.Errorf("%w: cannot move %q into itself", ErrInvalidName, from)
+	}
+	if m.folderExists(to) {
+		return "", 0, ErrFolderExists
+	}
+
+	rebase := func(folder string) string {
+		return to + strings.TrimPrefix(folder, from)
+	}
+
+	// 先检查冲突，避免部分条目已移动后才失败
+	var moving []*types.Entry
+	for _, e := range m.vault.Entries {
+		if types.InFolder(e.Folder, from) {
+			moving = append(moving, e)
+		}
+	}
+	for _, e := range moving {
+		if other := m.findEntryByPath(types.JoinPath(rebase(e.Folder), e.Name)); other != nil && !types.InFolder(other.Folder, from) {
+			return "", 0, ErrEntryExists
+		}
+	}
+
+	now := time.Now()
+	for _, e := range moving {
+		e.Folder = rebase(e.Folder)
+		e.UpdatedAt = now
+	}
+	for i, f := range m.vault.Folders {
+		if types.InFolder(f, from) {
+			m.vault.Folders[i] = rebase(f)
+		}
+	}
+
+	if err := m.save(); err != nil {
+		return "", 0, err
+	}
+	return to, len(moving), nil
+}
+
+// IsFolder 返回 path 是否是已存在的文件夹
+func (m *Manager) IsFolder(path string) bool {
+	if !m.open {
+		return false
+	}
+	folder, err := types.CleanFolder(path)
+	return err == nil && folder != "" && m.folderExists(folder)
+}
+
+// isFolderTarget 返回移动的目标 dest 是否表示文件夹（而不是新路径）
+func (m *Manager) isFolderTarget(dest string) bool {
+	return dest == "" || strings.HasSuffix(dest, types.PathSeparator) || m.IsFolder(dest)
+}
+
+// folderExists 返回规范化的文件夹路径是否存在
+func (m *Manager) folderExists(folder string) bool {
+	m.vault.NormalizeFolders()
+	for _, f := range m.vault.Folders {
+		if f == folder {
+			return true
+		}
+	}
+	return false
+}
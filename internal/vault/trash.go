@@ -0,0 +1,145 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// SetTrashRetention 设置删除的条目在回收站中保留的时长
+//
+// 参数:
+//   retention - 保留时长，不大于 0 时删除的条目不进入回收站而是直接永久删除，
+//               已在回收站中的条目则一直保留到用 EmptyTrash 清空；
+//               超过保留期的条目在下次保存密码库时永久删除
+func (m *Manager) SetTrashRetention(retention time.Duration) {
+	m.trashRetention = max(retention, 0)
+}
+
+// TrashRetention 返回删除的条目在回收站中保留的时长，0 表示删除的条目不进入回收站
+func (m *Manager) TrashRetention() time.Duration {
+	return m.trashRetention
+}
+
+// ListTrash 列出回收站中的条目
+//
+// 返回:
+//   按删除时间排列（最近删除的在前）的条目和可能的错误，不包括已超过保留期的条目；
+//   保留时长为 0 时返回回收站中的所有条目
+func (m *Manager) ListTrash() ([]*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+
+	trash, _ := m.splitTrash(time.Now())
+	entries := append([]*types.Entry{}, trash...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(*entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// RestoreEntry 将回收站中的条目恢复到密码库
+//
+// dest 为空时条目恢复到原来的路径；否则与 MoveEntry 相同，dest 以 / 结尾或是已存在的文件夹时
+// 条目保留名称恢复到该文件夹，其他情况下 dest 是条目的新路径。删除期间已被其他条目使用的别名
+// 以及与恢复后的路径相同的别名不再保留。
+//
+// 参数:
+//   name - 回收站中条目的路径、ID、ID 前缀或别名
+//   dest - 恢复到的文件夹或路径（可选）
+//
+// 返回:
+//   恢复后的条目和可能的错误，目标路径已被其他条目使用或与其他条目的别名相同时返回 ErrEntryExists
+func (m *Manager) RestoreEntry(name, dest string) (*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+
+	entry, err := findEntryIn(m.vault.Trash, name)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, base := entry.Folder, entry.Name
+	if dest != "" {
+		if m.isFolderTarget(dest) {
+			folder, err = types.CleanFolder(dest)
+		} else {
+			folder, base, err = types.ParseEntryPath(dest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidName, err)
+		}
+	}
+
+	path := types.JoinPath(folder, base)
+	var aliases []string
+	for _, alias := range entry.Aliases {
+		if _, err := m.checkAliases(entry, []string{alias}); err == nil && alias != path {
+			aliases = append(aliases, alias)
+		}
+	}
+	if err := m.checkPath(entry, path, aliases); err != nil {
+		return nil, err
+	}
+
+	trash := make([]*types.Entry, 0, len(m.vault.Trash)-1)
+	for _, e := range m.vault.Trash {
+		if e != entry {
+			trash = append(trash, e)
+		}
+	}
+	m.vault.Trash = trash
+
+	entry.Folder, entry.Name = folder, base
+	entry.Aliases = aliases
+	entry.DeletedAt = nil
+	m.vault.Entries = append(m.vault.Entries, entry)
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// EmptyTrash 永久删除回收站中的所有条目及其附件的数据块
+//
+// 返回:
+//   删除的条目数量和可能的错误
+func (m *Manager) EmptyTrash() (int, error) {
+	if !m.open {
+		return 0, ErrVaultNotOpen
+	}
+
+	removed := m.vault.Trash
+	m.vault.Trash = nil
+	if err := m.save(); err != nil {
+		m.vault.Trash = removed
+		return 0, err
+	}
+	for _, e := range removed {
+		for _, att := range e.Attachments {
+			m.deleteChunks(att)
+		}
+	}
+	return len(removed), nil
+}
+
+// splitTrash 将回收站中的条目分为仍在保留期内的和在 now 时已超过保留期的
+//
+// 保留时长为 0 时回收站已停用，其中的条目不会过期，只能用 EmptyTrash 永久删除。
+func (m *Manager) splitTrash(now time.Time) (kept, expired []*types.Entry) {
+	if m.trashRetention <= 0 {
+		return m.vault.Trash, nil
+	}
+	for _, e := range m.vault.Trash {
+		if e.DeletedAt != nil && now.Before(e.DeletedAt.Add(m.trashRetention)) {
+			kept = append(kept, e)
+		} else {
+			expired = append(expired, e)
+		}
+	}
+	return kept, expired
+}
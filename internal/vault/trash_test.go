@@ -0,0 +1,242 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chunkExists 返回附件的第一个数据块是否仍在 path 所在目录的 attachments 下
+func chunkExists(t *testing.T, path, attachmentID string) bool {
+	t.Helper()
+	_, err := os.Stat(filepath.Join(filepath.Dir(path), "attachments", chunkName(attachmentID, 0)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestDeleteEntry(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		wantTrash int
+		wantChunk bool
+	}{
+		{"moves to trash", 24 * time.Hour, 1, true},
+		{"trash disabled", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, path := newAttachmentManager(t)
+			m.SetTrashRetention(tt.retention)
+			if _, err := m.AddEntry("github", "alice", "hunter2", "", "", nil); err != nil {
+				t.Fatal(err)
+			}
+			att, err := m.AddAttachment("github", "key.txt", strings.NewReader("secret"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := m.DeleteEntry("github"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.GetEntry("github"); !errors.Is(err, ErrEntryNotFound) {
+				t.Fatalf("GetEntry after delete error = %v, want ErrEntryNotFound", err)
+			}
+			trash, err := m.ListTrash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trash) != tt.wantTrash {
+				t.Fatalf("trash = %d entries, want %d", len(trash), tt.wantTrash)
+			}
+			if got := chunkExists(t, path, att.ID); got != tt.wantChunk {
+				t.Fatalf("chunk exists = %t, want %t", got, tt.wantChunk)
+			}
+		})
+	}
+}
+
+func TestRestoreEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		dest     string
+		occupy   string // 恢复前创建的条目
+		wantErr  error
+		wantPath string
+		wantLeft int // 恢复后回收站中的条目数量
+	}{
+		{"original path", "work/github", "", "", nil, "work/github", 0},
+		{"by alias", "gh", "", "", nil, "work/github", 0},
+		{"new path", "work/github", "github-old", "", nil, "github-old", 0},
+		{"into folder", "work/github", "personal/", "", nil, "personal/github", 0},
+		{"into existing folder", "work/github", "archive", "archive/other", nil, "archive/github", 0},
+		{"path taken", "work/github", "", "work/github", ErrEntryExists, "", 1},
+		{"not in trash", "gitlab", "", "", ErrEntryNotFound, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, path := newTestManager(t)
+			if _, err := m.AddEntry("work/github", "alice", "hunter2", "", "", nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.AddAlias("work/github", "gh"); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.DeleteEntry("work/github"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.occupy != "" {
+				if _, err := m.AddEntry(tt.occupy, "bob", "pw", "", "", nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			entry, err := m.RestoreEntry(tt.ref, tt.dest)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RestoreEntry error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if entry.Path() != tt.wantPath || entry.DeletedAt != nil {
+					t.Fatalf("restored %s (deleted at %v), want %s", entry.Path(), entry.DeletedAt, tt.wantPath)
+				}
+			}
+
+			// 检查保存到存储中的结果
+			m.Close()
+			m, err = reopen(t, path, testPassword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantPath != "" {
+				if _, err := m.GetDecryptedPassword(tt.wantPath); err != nil {
+					t.Fatalf("restored entry: %v", err)
+				}
+			}
+			trash, err := m.ListTrash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trash) != tt.wantLeft {
+				t.Fatalf("trash = %d entries, want %d", len(trash), tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestRestoreEntryDropsTakenAlias(t *testing.T) {
+	m, _ := newTestManager(t)
+	if _, err := m.AddEntry("github", "alice", "hunter2", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddAlias("github", "gh"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteEntry("github"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddEntry("github-new", "alice", "pw", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddAlias("github-new", "gh"); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := m.RestoreEntry("github", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Aliases) != 0 {
+		t.Fatalf("aliases = %v, want none", entry.Aliases)
+	}
+}
+
+func TestTrashPurge(t *testing.T) {
+	tests := []struct {
+		name       string
+		deletedAgo time.Duration
+		retention  time.Duration // 删除后、下次保存前设置的保留时长
+		wantKept   bool
+	}{
+		{"within retention", 29 * 24 * time.Hour, 30 * 24 * time.Hour, true},
+		{"past retention", 31 * 24 * time.Hour, 30 * 24 * time.Hour, false},
+		{"retention shortened", 10 * 24 * time.Hour, 7 * 24 * time.Hour, false},
+		{"trash disabled keeps existing entries", 365 * 24 * time.Hour, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, path := newAttachmentManager(t)
+			if _, err := m.AddEntry("github", "alice", "hunter2", "", "", nil); err != nil {
+				t.Fatal(err)
+			}
+			att, err := m.AddAttachment("github", "key.txt", strings.NewReader("secret"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := m.DeleteEntry("github"); err != nil {
+				t.Fatal(err)
+			}
+			deletedAt := time.Now().Add(-tt.deletedAgo)
+			m.vault.Trash[0].DeletedAt = &deletedAt
+			m.SetTrashRetention(tt.retention)
+
+			// 超过保留期的条目在下次保存（与回收站无关的修改）时永久删除
+			if _, err := m.AddEntry("gitlab", "alice", "pw", "", "", nil); err != nil {
+				t.Fatal(err)
+			}
+			m.Close()
+			m, err = reopen(t, path, testPassword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.SetTrashRetention(tt.retention)
+
+			if got := len(m.vault.Trash) == 1; got != tt.wantKept {
+				t.Fatalf("entry kept in trash = %t, want %t", got, tt.wantKept)
+			}
+			trash, err := m.ListTrash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(trash) == 1; got != tt.wantKept {
+				t.Fatalf("entry listed in trash = %t, want %t", got, tt.wantKept)
+			}
+			if got := chunkExists(t, path, att.ID); got != tt.wantKept {
+				t.Fatalf("chunk exists = %t, want %t", got, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	for _, retention := range []time.Duration{24 * time.Hour, 0} {
+		m, path := newAttachmentManager(t)
+		if _, err := m.AddEntry("github", "alice", "hunter2", "", "", nil); err != nil {
+			t.Fatal(err)
+		}
+		att, err := m.AddAttachment("github", "key.txt", strings.NewReader("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.DeleteEntry("github"); err != nil {
+			t.Fatal(err)
+		}
+		m.SetTrashRetention(retention)
+
+		removed, err := m.EmptyTrash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if removed != 1 || len(m.vault.Trash) != 0 {
+			t.Fatalf("retention %s: removed %d, %d left in trash; want 1, 0", retention, removed, len(m.vault.Trash))
+		}
+		if chunkExists(t, path, att.ID) {
+			t.Fatalf("retention %s: attachment chunk was not deleted", retention)
+		}
+	}
+}
@@ -1,14 +1,19 @@
 package vault
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/imerr0rlog/CipherHub/internal/crypto"
+	"github.com/imerr0rlog/CipherHub/internal/query"
 	"github.com/imerr0rlog/CipherHub/internal/storage"
+	"github.com/imerr0rlog/CipherHub/internal/totp"
 	"github.com/imerr0rlog/CipherHub/pkg/types"
 )
 
@@ -27,10 +32,21 @@ var (
 	ErrInvalidPassword   = errors.New("vault: invalid master password")
 	// ErrVaultCorrupted 表示密码库数据已损坏
 	ErrVaultCorrupted    = errors.New("vault: corrupted data")
+	// ErrInvalidName 表示条目名称无效（如为空）
+	ErrInvalidName       = errors.New("vault: invalid entry name")
+	// ErrNoTOTP 表示条目没有配置 TOTP
+	ErrNoTOTP            = errors.New("vault: entry has no TOTP")
+	// ErrNoPasswordVersion 表示条目没有指定版本的历史密码
+	ErrNoPasswordVersion = errors.New("vault: no such password version")
+	// ErrUnknownField 表示请求了条目不支持的字段
+	ErrUnknownField      = errors.New("vault: unknown field")
 	// ErrRandomGenFailed 表示随机数生成失败
 	ErrRandomGenFailed   = errors.New("vault: random generation failed")
 )
 
+// verifierText 是 Verifier 中加密的固定文本
+const verifierText = "cipherhub-verifier"
+
 // Manager 负责密码库的所有操作，包括初始化、打开、关闭密码库，以及密码条目的增删改查
 type Manager struct {
 	storage storage.Storage
@@ -38,6 +54,12 @@ type Manager struct {
 	vault   *types.Vault
 	salt    []byte
 	open    bool
+	ctx     context.Context
+
+	saveHook       func()
+	blobs          BlobStore
+	historyDepth   int
+	trashRetention time.Duration
 }
 
 // NewManager 创建一个新的密码库管理器实例
@@ -49,9 +71,39 @@ type Manager struct {
 //   新的 Manager 实例
 func NewManager(storage storage.Storage) *Manager {
 	return &Manager{
-		storage: storage,
-		open:    false,
+		storage:        storage,
+		open:           false,
+		ctx:            context.Background(),
+		historyDepth:   types.DefaultPasswordHistory,
+		trashRetention: types.DefaultTrashDays * 24 * time.Hour,
+	}
+}
+
+// SetContext 设置后续存储操作使用的 context
+//
+// 参数:
+//   ctx - 用于取消或限制存储操作时间的 context，传入 nil 时使用 context.Background()
+func (m *Manager) SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	m.ctx = ctx
+}
+
+// SetSaveHook 设置每次成功保存密码库后调用的函数
+//
+// 参数:
+//   hook - 保存成功后调用的函数，传入 nil 时取消
+func (m *Manager) SetSaveHook(hook func()) {
+	m.saveHook = hook
+}
+
+// SetPasswordHistory 设置修改密码时每个条目保留的历史密码数量
+//
+// 参数:
+//   depth - 保留的数量，不大于 0 时不再保留历史密码；已有的多余记录在下次修改密码时删除
+func (m *Manager) SetPasswordHistory(depth int) {
+	m.historyDepth = max(depth, 0)
 }
 
 // Init 初始化一个新的密码库
@@ -62,7 +114,11 @@ func NewManager(storage storage.Storage) *Manager {
 // 返回:
 //   成功时返回 nil，失败时返回相应的错误
 func (m *Manager) Init(masterPassword string) error {
-	if m.storage.Exists() {
+	exists, err := m.storage.Exists(m.ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
 		return ErrVaultExists
 	}
 
@@ -74,6 +130,9 @@ func (m *Manager) Init(masterPassword string) error {
 	m.crypto = crypto.NewCrypto(masterPassword, m.salt)
 	m.vault = types.NewVault()
 	m.vault.Salt = base64.StdEncoding.EncodeToString(m.salt)
+	if m.vault.Verifier, err = m.crypto.EncryptString(verifierText); err != nil {
+		return err
+	}
 	m.open = true
 
 	return m.save()
@@ -85,17 +144,35 @@ func (m *Manager) Init(masterPassword string) error {
 //   masterPassword - 主密码，用于解密密码库
 //
 // 返回:
-//   成功时返回 nil，失败时返回相应的错误
+//   成功时返回 nil，主密码错误时返回 ErrInvalidPassword，其他失败返回相应的错误
 func (m *Manager) Open(masterPassword string) error {
+	return m.OpenWithKey(func(salt []byte) ([]byte, error) {
+		return crypto.DeriveKey(masterPassword, salt), nil
+	})
+}
+
+// OpenWithKey 使用已派生的密钥打开现有的密码库
+//
+// 参数:
+//   keyFor - 根据密码库的盐值返回密钥，例如从 agent 中查询缓存的密钥
+//
+// 返回:
+//   成功时返回 nil，keyFor 的错误原样返回，密钥错误时返回 ErrInvalidPassword
+func (m *Manager) OpenWithKey(keyFor func(salt []byte) ([]byte, error)) error {
 	if m.open {
 		return ErrVaultAlreadyOpen
 	}
 
-	data, err := m.storage.Read()
+	data, err := m.storage.Read(m.ctx)
 	if err != nil {
 		return err
 	}
 
+	return m.load(data, keyFor)
+}
+
+// load 解析密码库数据并验证密钥，成功后密码库处于打开状态
+func (m *Manager) load(data []byte, keyFor func(salt []byte) ([]byte, error)) error {
 	var vault types.Vault
 	if err := json.Unmarshal(data, &vault); err != nil {
 		return ErrVaultCorrupted
@@ -106,16 +183,108 @@ func (m *Manager) Open(masterPassword string) error {
 		return ErrVaultCorrupted
 	}
 
+	key, err := keyFor(salt)
+	if err != nil {
+		return err
+	}
+	c, err := crypto.NewCryptoWithKey(key)
+	if err != nil {
+		return err
+	}
+	if err := verifyKey(c, &vault); err != nil {
+		c.Clear()
+		return err
+	}
+
+	if m.open {
+		m.Close()
+	}
+
 	m.salt = salt
-	m.crypto = crypto.NewCrypto(masterPassword, salt)
+	m.crypto = c
 	m.vault = &vault
 	m.open = true
 
 	return nil
 }
 
+// verifyKey 检查密钥能否解密密码库
+//
+// 优先解密 Verifier；旧版密码库没有 Verifier 时尝试解密第一个加密的值（密码、备注、TOTP 或历史密码），
+// 解密成功后才补上 Verifier，在下次保存时写入。没有任何加密的值时无法验证密钥，
+// 此时接受密钥但不生成 Verifier，以免把输错的主密码固定下来。
+func verifyKey(c *crypto.Crypto, vault *types.Vault) error {
+	if vault.Verifier != "" {
+		text, err := c.DecryptString(vault.Verifier)
+		if err != nil || text != verifierText {
+			return ErrInvalidPassword
+		}
+		return nil
+	}
+
+	ciphertext := firstEncryptedValue(vault)
+	if ciphertext == "" {
+		return nil
+	}
+	if _, err := c.DecryptString(ciphertext); err != nil {
+		return ErrInvalidPassword
+	}
+
+	verifier, err := c.EncryptString(verifierText)
+	if err != nil {
+		return err
+	}
+	vault.Verifier = verifier
+	return nil
+}
+
+// firstEncryptedValue 返回密码库（包括回收站）中第一个加密的值，没有时返回空字符串
+func firstEncryptedValue(vault *types.Vault) string {
+	for _, entries := range [][]*types.Entry{vault.Entries, vault.Trash} {
+		for _, entry := range entries {
+			for _, value := range []string{entry.Password, entry.Notes, entry.TOTP} {
+				if value != "" {
+					return value
+				}
+			}
+			for _, version := range entry.History {
+				if version.Password != "" {
+					return version.Password
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Key 返回当前密码库密钥的副本
+//
+// 返回:
+//   密钥副本和可能的错误，调用方使用完毕后应清零
+func (m *Manager) Key() ([]byte, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	return m.crypto.Key(), nil
+}
+
+// Salt 返回当前密码库的盐值
+//
+// 返回:
+//   盐值和可能的错误
+func (m *Manager) Salt() ([]byte, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+	return m.salt, nil
+}
+
 // Close 关闭密码库，清空内存中的敏感数据
+//
+// 如果存储仍有后台任务（如离线副本的刷新），会等待其完成。
 func (m *Manager) Close() {
+	storage.Wait(m.storage)
+
 	if m.crypto != nil {
 		m.crypto.Clear()
 	}
@@ -131,6 +300,17 @@ func (m *Manager) save() error {
 	}
 
 	m.vault.UpdatedAt = time.Now()
+	m.vault.NormalizeFolders()
+	// 回收站中超过保留期的条目永久删除，附件的数据块在密码库保存成功后删除
+	trash := m.vault.Trash
+	var purged []*types.Entry
+	m.vault.Trash, purged = m.splitTrash(m.vault.UpdatedAt)
+	// 旧版本创建的条目没有记录密码的设置时间
+	for _, entry := range m.vault.Entries {
+		if entry.PasswordChangedAt.IsZero() {
+			entry.PasswordChangedAt = entry.PasswordSetAt()
+		}
+	}
 
 	tempChecksum := m.vault.Checksum
 	m.vault.Checksum = ""
@@ -138,6 +318,7 @@ func (m *Manager) save() error {
 	data, err := json.MarshalIndent(m.vault, "", "  ")
 	if err != nil {
 		m.vault.Checksum = tempChecksum
+		m.vault.Trash = trash
 		return err
 	}
 
@@ -145,16 +326,29 @@ func (m *Manager) save() error {
 
 	data, err = json.MarshalIndent(m.vault, "", "  ")
 	if err != nil {
+		m.vault.Trash = trash
 		return err
 	}
 
-	return m.storage.Write(data)
+	if err := m.storage.Write(m.ctx, data); err != nil {
+		m.vault.Trash = trash
+		return err
+	}
+	for _, e := range purged {
+		for _, att := range e.Attachments {
+			m.deleteChunks(att)
+		}
+	}
+	if m.saveHook != nil {
+		m.saveHook()
+	}
+	return nil
 }
 
 // AddEntry 添加新的密码条目
 //
 // 参数:
-//   name - 条目的唯一名称
+//   name - 条目的唯一路径，如 work/aws/prod/root（文件夹不存在时自动创建）
 //   username - 用户名
 //   password - 密码（会被加密存储）
 //   url - 网站地址
@@ -162,20 +356,25 @@ func (m *Manager) save() error {
 //   tags - 标签列表
 //
 // 返回:
-//   新创建的密码条目和可能的错误
+//   新创建的密码条目和可能的错误，路径已被其他条目使用或与某个别名相同时返回 ErrEntryExists
 func (m *Manager) AddEntry(name, username, password, url, notes string, tags []string) (*types.Entry, error) {
 	if !m.open {
 		return nil, ErrVaultNotOpen
 	}
 
-	if m.findEntryByName(name) != nil {
-		return nil, ErrEntryExists
+	folder, base, err := types.ParseEntryPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidName, err)
+	}
+	if err := m.checkPath(nil, types.JoinPath(folder, base), nil); err != nil {
+		return nil, err
 	}
 
-	entry, err := types.NewEntry(name)
+	entry, err := types.NewEntry(base)
 	if err != nil {
 		return nil, ErrRandomGenFailed
 	}
+	entry.Folder = folder
 	entry.Username = username
 
 	encPassword, err := m.crypto.EncryptString(password)
@@ -204,10 +403,13 @@ func (m *Manager) AddEntry(name, username, password, url, notes string, tags []s
 	return entry, nil
 }
 
-// GetEntry 根据名称获取密码条目（密码和备注仍保持加密状态）
+// GetEntry 获取密码条目（密码和备注仍保持加密状态）
+//
+// 所有按 name 查找条目的方法都接受条目路径、ID、至少 MinIDPrefix 个字符的 ID 前缀或别名，
+// 依次匹配，匹配多个条目时返回 *AmbiguousError。
 //
 // 参数:
-//   name - 条目名称
+//   name - 条目路径（如 work/aws/root，位于根目录的条目的路径就是名称）、ID、ID 前缀或别名
 //
 // 返回:
 //   找到的密码条目和可能的错误
@@ -216,9 +418,9 @@ func (m *Manager) GetEntry(name string) (*types.Entry, error) {
 		return nil, ErrVaultNotOpen
 	}
 
-	entry := m.findEntryByName(name)
-	if entry == nil {
-		return nil, ErrEntryNotFound
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return nil, err
 	}
 
 	return entry, nil
@@ -236,9 +438,9 @@ func (m *Manager) GetDecryptedPassword(name string) (string, error) {
 		return "", ErrVaultNotOpen
 	}
 
-	entry := m.findEntryByName(name)
-	if entry == nil {
-		return "", ErrEntryNotFound
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return "", err
 	}
 
 	return m.crypto.DecryptString(entry.Password)
@@ -256,9 +458,9 @@ func (m *Manager) GetDecryptedNotes(name string) (string, error) {
 		return "", ErrVaultNotOpen
 	}
 
-	entry := m.findEntryByName(name)
-	if entry == nil {
-		return "", ErrEntryNotFound
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return "", err
 	}
 
 	if entry.Notes == "" {
@@ -268,6 +470,216 @@ func (m *Manager) GetDecryptedNotes(name string) (string, error) {
 	return m.crypto.DecryptString(entry.Notes)
 }
 
+// GetField 获取条目指定字段的明文值
+//
+// 参数:
+//   name - 条目名称
+//   field - 字段名称，支持 username, password, url, notes, totp（当前验证码）和自定义字段名称
+//
+// 返回:
+//   字段的明文值和可能的错误，条目中不存在的字段返回 ErrUnknownField
+func (m *Manager) GetField(name, field string) (string, error) {
+	entry, err := m.GetEntry(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch field {
+	case "username":
+		return entry.Username, nil
+	case "password":
+		return m.GetDecryptedPassword(name)
+	case "url":
+		return entry.URL, nil
+	case "notes":
+		return m.GetDecryptedNotes(name)
+	case "totp":
+		config, err := m.GetTOTP(name)
+		if err != nil {
+			return "", err
+		}
+		return config.Code(time.Now())
+	default:
+		for _, f := range entry.Fields {
+			if f.Name == field {
+				return m.decryptField(f)
+			}
+		}
+		return "", fmt.Errorf("%w: %s", ErrUnknownField, field)
+	}
+}
+
+// GetFields 获取条目解密后的自定义字段
+//
+// 参数:
+//   name - 条目名称
+//
+// 返回:
+//   按顺序排列的自定义字段（hidden 类型已解密）和可能的错误
+func (m *Manager) GetFields(name string) ([]types.Field, error) {
+	entry, err := m.GetEntry(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]types.Field, 0, len(entry.Fields))
+	for _, f := range entry.Fields {
+		value, err := m.decryptField(f)
+		if err != nil {
+			return nil, err
+		}
+		f.Value = value
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// SetFields 添加、修改或删除条目的自定义字段
+//
+// 参数:
+//   name - 条目名称
+//   fields - 明文字段。已存在的同名字段原地修改，其余追加到末尾；
+//            值为空表示删除该字段，类型为空时保留原类型（新字段为 text）
+//
+// 返回:
+//   更新后的密码条目和可能的错误
+func (m *Manager) SetFields(name string, fields []types.Field) (*types.Entry, error) {
+	merged, err := m.MergeFields(name, fields)
+	if err != nil {
+		return nil, err
+	}
+	return m.ReplaceFields(name, merged)
+}
+
+// MergeFields 返回按 SetFields 的规则修改后的全部自定义字段，不保存密码库
+//
+// 参数:
+//   name - 条目名称
+//   fields - 明文字段，规则与 SetFields 相同
+//
+// 返回:
+//   按顺序排列的明文字段，可以传给 ReplaceFields 或 EditEntry
+func (m *Manager) MergeFields(name string, fields []types.Field) ([]types.Field, error) {
+	current, err := m.GetFields(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		idx := -1
+		for i := range current {
+			if current[i].Name == f.Name {
+				idx = i
+				break
+			}
+		}
+
+		switch {
+		case f.Value == "" && idx >= 0:
+			current = append(current[:idx], current[idx+1:]...)
+		case f.Value == "":
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, f.Name)
+		case idx >= 0:
+			if f.Type == "" {
+				f.Type = current[idx].Type
+			}
+			current[idx] = f
+		default:
+			if f.Type == "" {
+				f.Type = types.FieldTypeText
+			}
+			current = append(current, f)
+		}
+	}
+	return current, nil
+}
+
+// ReplaceFields 用 fields 替换条目的全部自定义字段
+//
+// 参数:
+//   name - 条目名称
+//   fields - 按顺序排列的明文字段，hidden 类型的值会被加密
+//
+// 返回:
+//   更新后的密码条目和可能的错误
+func (m *Manager) ReplaceFields(name string, fields []types.Field) (*types.Entry, error) {
+	entry, err := m.GetEntry(name)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := m.prepareFields(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Fields = stored
+	entry.UpdatedAt = time.Now()
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// prepareFields 检查自定义字段并加密 hidden 类型的值，返回保存到条目中的字段
+func (m *Manager) prepareFields(fields []types.Field) ([]types.Field, error) {
+	seen := make(map[string]bool, len(fields))
+	stored := make([]types.Field, 0, len(fields))
+	for _, f := range fields {
+		if err := types.ValidateField(f); err != nil {
+			return nil, err
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("duplicate field %q", f.Name)
+		}
+		seen[f.Name] = true
+
+		if f.Type == types.FieldTypeHidden && f.Value != "" {
+			var err error
+			if f.Value, err = m.crypto.EncryptString(f.Value); err != nil {
+				return nil, err
+			}
+		}
+		stored = append(stored, f)
+	}
+
+	if len(stored) == 0 {
+		return nil, nil
+	}
+	return stored, nil
+}
+
+// decryptField 返回自定义字段的明文值
+func (m *Manager) decryptField(f types.Field) (string, error) {
+	if f.Type != types.FieldTypeHidden || f.Value == "" {
+		return f.Value, nil
+	}
+	return m.crypto.DecryptString(f.Value)
+}
+
+// GetTOTP 获取条目解密后的 TOTP 配置
+//
+// 参数:
+//   name - 条目名称
+//
+// 返回:
+//   TOTP 配置和可能的错误，条目没有配置 TOTP 时返回 ErrNoTOTP
+func (m *Manager) GetTOTP(name string) (*totp.Config, error) {
+	entry, err := m.GetEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.TOTP == "" {
+		return nil, ErrNoTOTP
+	}
+
+	uri, err := m.crypto.DecryptString(entry.TOTP)
+	if err != nil {
+		return nil, err
+	}
+	return totp.Parse(uri)
+}
+
 // ListEntries 列出所有密码条目
 //
 // 返回:
@@ -283,27 +695,152 @@ func (m *Manager) ListEntries() ([]*types.Entry, error) {
 //
 // 参数:
 //   name - 要更新的条目名称
-//   updates - 包含要更新字段的映射，支持的键: username, password, url, notes
+//   updates - 包含要更新字段的映射，支持的键: name（新路径，可移动到其他文件夹）, username, password（旧密码保存到历史）, url, notes, tags（逗号分隔）,
+//             totp（otpauth:// URI 或 base32 密钥，空字符串表示删除）, type（条目类型）, aliases（逗号分隔，替换全部别名）,
+//             rotation（密码轮换周期天数，空字符串或 0 表示不轮换）, expires（过期日期 YYYY-MM-DD，空字符串表示不过期）
 //
 // 返回:
-//   更新后的密码条目和可能的错误
+//   更新后的密码条目和可能的错误，新名称已被其他条目使用时返回 ErrEntryExists
 func (m *Manager) UpdateEntry(name string, updates map[string]string) (*types.Entry, error) {
 	if !m.open {
 		return nil, ErrVaultNotOpen
 	}
 
-	entry := m.findEntryByName(name)
-	if entry == nil {
-		return nil, ErrEntryNotFound
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.applyUpdates(entry, updates); err != nil {
+		return nil, err
+	}
+
+	entry.UpdatedAt = time.Now()
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// EditEntry 在一次保存中更新条目并替换它的全部自定义字段
+//
+// 任何一项检查失败时都不会保存，避免只应用了部分修改。
+//
+// 参数:
+//   name - 要更新的条目名称
+//   updates - 要更新的字段，与 UpdateEntry 相同
+//   fields - 按顺序排列的明文自定义字段，与 ReplaceFields 相同；为 nil 时不修改自定义字段，
+//            非 nil 的空切片删除全部自定义字段
+//
+// 返回:
+//   更新后的密码条目和可能的错误
+func (m *Manager) EditEntry(name string, updates map[string]string, fields []types.Field) (*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []types.Field
+	if fields != nil {
+		if stored, err = m.prepareFields(fields); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.applyUpdates(entry, updates); err != nil {
+		return nil, err
+	}
+	if fields != nil {
+		entry.Fields = stored
+	}
+
+	entry.UpdatedAt = time.Now()
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// applyUpdates 将 updates 应用到条目，键与 UpdateEntry 相同，不保存密码库
+//
+// 所有值先检查完毕再修改条目。
+func (m *Manager) applyUpdates(entry *types.Entry, updates map[string]string) error {
+	var err error
+
+	// 先检查新路径和其他值，避免部分字段已修改后才失败
+	newName, rename := updates["name"]
+	var newFolder string
+	if rename {
+		folder, base, err := types.ParseEntryPath(newName)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidName, err)
+		}
+		newFolder, newName = folder, base
+	}
+
+	var entryType types.EntryType
+	if value, ok := updates["type"]; ok {
+		t, err := types.ParseEntryType(value)
+		if err != nil {
+			return err
+		}
+		entryType = t
+	}
+
+	var rotationDays int
+	if value, ok := updates["rotation"]; ok {
+		if rotationDays, err = types.ParseRotationDays(value); err != nil {
+			return err
+		}
+	}
+
+	var expiresAt *time.Time
+	if value, ok := updates["expires"]; ok {
+		if expiresAt, err = types.ParseExpiry(value); err != nil {
+			return err
+		}
+	}
+
+	aliases := entry.Aliases
+	if value, ok := updates["aliases"]; ok {
+		if aliases, err = m.checkAliases(entry, strings.Split(value, ",")); err != nil {
+			return err
+		}
+	}
+	if rename {
+		if err := m.checkPath(entry, types.JoinPath(newFolder, newName), aliases); err != nil {
+			return err
+		}
+	}
+
+	var totpURI string
+	if value, ok := updates["totp"]; ok && value != "" {
+		config, err := totp.Parse(value)
+		if err != nil {
+			return err
+		}
+		totpURI = config.URI()
+	}
+
+	if rename {
+		entry.Folder, entry.Name = newFolder, newName
 	}
 
 	if username, ok := updates["username"]; ok {
 		entry.Username = username
 	}
 	if password, ok := updates["password"]; ok {
+		if err := m.recordPassword(entry, password); err != nil {
+			return err
+		}
 		encPassword, err := m.crypto.EncryptString(password)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		entry.Password = encPassword
 	}
@@ -316,21 +853,119 @@ func (m *Manager) UpdateEntry(name string, updates map[string]string) (*types.En
 		} else {
 			encNotes, err := m.crypto.EncryptString(notes)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			entry.Notes = encNotes
 		}
 	}
+	if tags, ok := updates["tags"]; ok {
+		entry.Tags = types.ParseTags(tags)
+	}
+	if _, ok := updates["aliases"]; ok {
+		entry.Aliases = aliases
+	}
+	if _, ok := updates["rotation"]; ok {
+		entry.RotationDays = rotationDays
+	}
+	if _, ok := updates["expires"]; ok {
+		entry.ExpiresAt = expiresAt
+	}
+	if _, ok := updates["type"]; ok {
+		entry.Type = entryType
+		if entryType == types.EntryTypeLogin {
+			entry.Type = ""
+		}
+	}
+	if _, ok := updates["totp"]; ok {
+		if totpURI == "" {
+			entry.TOTP = ""
+		} else {
+			encTOTP, err := m.crypto.EncryptString(totpURI)
+			if err != nil {
+				return err
+			}
+			entry.TOTP = encTOTP
+		}
+	}
 
-	entry.UpdatedAt = time.Now()
-	if err := m.save(); err != nil {
+	return nil
+}
+
+// recordPassword 在条目的密码改为 password 之前，把当前密码加入历史并更新密码的设置时间
+//
+// 与新密码相同时不做任何记录，当前密码为空时只更新设置时间。历史最多保留 historyDepth 个，最近的在前。
+func (m *Manager) recordPassword(entry *types.Entry, password string) error {
+	var current string
+	if entry.Password != "" {
+		var err error
+		if current, err = m.crypto.DecryptString(entry.Password); err != nil {
+			return err
+		}
+	}
+	if current != password {
+		now := time.Now()
+		if current != "" {
+			version := types.PasswordVersion{Password: entry.Password, ReplacedAt: now}
+			entry.History = append([]types.PasswordVersion{version}, entry.History...)
+		}
+		entry.PasswordChangedAt = now
+	}
+	if len(entry.History) > m.historyDepth {
+		entry.History = entry.History[:m.historyDepth]
+	}
+	if len(entry.History) == 0 {
+		entry.History = nil
+	}
+	return nil
+}
+
+// GetPasswordHistory 获取条目解密后的历史密码
+//
+// 参数:
+//   name - 条目名称
+//
+// 返回:
+//   历史密码列表（最近的在前，第 1 个为版本 1）和可能的错误
+func (m *Manager) GetPasswordHistory(name string) ([]types.PasswordVersion, error) {
+	entry, err := m.GetEntry(name)
+	if err != nil {
 		return nil, err
 	}
 
-	return entry, nil
+	history := make([]types.PasswordVersion, 0, len(entry.History))
+	for _, v := range entry.History {
+		password, err := m.crypto.DecryptString(v.Password)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, types.PasswordVersion{Password: password, ReplacedAt: v.ReplacedAt})
+	}
+	return history, nil
+}
+
+// GetPasswordVersion 获取条目第 version 个历史密码的明文
+//
+// 参数:
+//   name - 条目名称
+//   version - 历史版本号，1 表示上一个密码
+//
+// 返回:
+//   历史密码和可能的错误，版本不存在时返回 ErrNoPasswordVersion
+func (m *Manager) GetPasswordVersion(name string, version int) (string, error) {
+	entry, err := m.GetEntry(name)
+	if err != nil {
+		return "", err
+	}
+	if version < 1 || version > len(entry.History) {
+		return "", fmt.Errorf("%w: %d (entry has %d)", ErrNoPasswordVersion, version, len(entry.History))
+	}
+	return m.crypto.DecryptString(entry.History[version-1].Password)
 }
 
-// DeleteEntry 删除密码条目
+// DeleteEntry 将密码条目移入回收站
+//
+// 条目（包括附件的数据块）在回收站中保留 SetTrashRetention 设置的时长，期间可以用 RestoreEntry 恢复；
+// 保留时长为 0 时直接永久删除条目及其附件的数据块。
 //
 // 参数:
 //   name - 要删除的条目名称
@@ -342,16 +977,34 @@ func (m *Manager) DeleteEntry(name string) error {
 		return ErrVaultNotOpen
 	}
 
-	idx := m.findEntryIndexByName(name)
-	if idx == -1 {
-		return ErrEntryNotFound
+	entry, err := m.findEntry(name)
+	if err != nil {
+		return err
 	}
 
-	m.vault.Entries = append(m.vault.Entries[:idx], m.vault.Entries[idx+1:]...)
-	return m.save()
+	entries := make([]*types.Entry, 0, len(m.vault.Entries)-1)
+	for _, e := range m.vault.Entries {
+		if e != entry {
+			entries = append(entries, e)
+		}
+	}
+	m.vault.Entries = entries
+	if m.trashRetention > 0 {
+		now := time.Now()
+		entry.DeletedAt = &now
+		m.vault.Trash = append(m.vault.Trash, entry)
+		return m.save()
+	}
+	if err := m.save(); err != nil {
+		return err
+	}
+	for _, att := range entry.Attachments {
+		m.deleteChunks(att)
+	}
+	return nil
 }
 
-// SearchEntries 搜索密码条目，按名称、用户名、URL或标签进行搜索
+// SearchEntries 搜索密码条目，按路径（含文件夹）、用户名、URL或标签进行搜索
 //
 // 参数:
 //   query - 搜索关键词（不区分大小写）
@@ -371,7 +1024,7 @@ func (m *Manager) SearchEntries(query string) ([]*types.Entry, error) {
 		if seen[entry.ID] {
 			continue
 		}
-		if strings.Contains(strings.ToLower(entry.Name), query) ||
+		if strings.Contains(strings.ToLower(entry.Path()), query) ||
 			strings.Contains(strings.ToLower(entry.Username), query) ||
 			strings.Contains(strings.ToLower(entry.URL), query) {
 			results = append(results, entry)
@@ -390,22 +1043,70 @@ func (m *Manager) SearchEntries(query string) ([]*types.Entry, error) {
 	return results, nil
 }
 
-func (m *Manager) findEntryByName(name string) *types.Entry {
+// Query 按查询语言搜索密码条目，语法见 query 包
+//
+// 参数:
+//   expr - 查询语句，如 tag:prod user:admin updated:<90d
+//   opts - 匹配选项，Fuzzy 为 true 时结果按匹配得分排序
+//
+// 返回:
+//   匹配的密码条目列表和可能的错误，语句无效时返回的错误满足 errors.Is(err, query.ErrSyntax)
+func (m *Manager) Query(expr string, opts query.Options) ([]*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+
+	q, err := query.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Filter(m.vault.Entries, opts), nil
+}
+
+// DueEntries 列出已到期或将在 within 内到期的条目，按到期时间排序
+//
+// 到期时间由条目的轮换周期（从密码最后一次改变时算起，而不是 UpdatedAt）和过期时间中较早的一个决定，
+// 没有设置两者的条目和已归档的条目不会到期。
+//
+// 参数:
+//   within - 提前提醒的时长，0 表示只列出已到期的条目
+//
+// 返回:
+//   到期的条目列表和可能的错误
+func (m *Manager) DueEntries(within time.Duration) ([]*types.Entry, error) {
+	if !m.open {
+		return nil, ErrVaultNotOpen
+	}
+
+	limit := time.Now().Add(within)
+	var results []*types.Entry
 	for _, entry := range m.vault.Entries {
-		if entry.Name == name {
-			return entry
+		if entry.Archived {
+			continue
+		}
+		if due, ok := entry.DueAt(); ok && !due.After(limit) {
+			results = append(results, entry)
 		}
 	}
-	return nil
+	sort.SliceStable(results, func(i, j int) bool {
+		a, _ := results[i].DueAt()
+		b, _ := results[j].DueAt()
+		return a.Before(b)
+	})
+	return results, nil
 }
 
-func (m *Manager) findEntryIndexByName(name string) int {
-	for i, entry := range m.vault.Entries {
-		if entry.Name == name {
-			return i
+// findEntryByPath 按完整路径查找条目，位于根目录的条目的路径就是名称
+//
+// 只比较路径，用于检查名称冲突；查找用户指定的条目应使用 findEntry。
+func (m *Manager) findEntryByPath(path string) *types.Entry {
+	path = strings.TrimPrefix(path, types.PathSeparator)
+	for _, entry := range m.vault.Entries {
+		if entry.Path() == path {
+			return entry
 		}
 	}
-	return -1
+	return nil
 }
 
 // IsOpen 检查密码库是否已打开
@@ -419,7 +1120,7 @@ func (m *Manager) IsOpen() bool {
 // VaultInfo 获取密码库的基本信息
 //
 // 返回:
-//   包含密码库信息的映射，包括 open（是否打开）、version（版本）、entries（条目数量）、created_at（创建时间）、updated_at（更新时间）
+//   包含密码库信息的映射，包括 open（是否打开）、version（版本）、entries（条目数量）、trash（回收站中的条目数量）、created_at（创建时间）、updated_at（更新时间）
 func (m *Manager) VaultInfo() map[string]interface{} {
 	if !m.open {
 		return map[string]interface{}{"open": false}
@@ -429,6 +1130,7 @@ func (m *Manager) VaultInfo() map[string]interface{} {
 		"open":       true,
 		"version":    m.vault.Version,
 		"entries":    len(m.vault.Entries),
+		"trash":      len(m.vault.Trash),
 		"created_at": m.vault.CreatedAt,
 		"updated_at": m.vault.UpdatedAt,
 	}
@@ -442,6 +1144,20 @@ func (m *Manager) VaultInfo() map[string]interface{} {
 // 返回:
 //   可能的错误
 func (m *Manager) Sync(remote storage.Storage) error {
+	return m.SyncContext(m.ctx, remote)
+}
+
+// SyncContext 与 Sync 相同，但使用指定的 context 访问远程存储
+//
+// 只写入密码库文件，附件的数据块需要先用 PushAttachments 复制。
+//
+// 参数:
+//   ctx - 用于取消或限制同步时间的 context
+//   remote - 远程存储接口
+//
+// 返回:
+//   可能的错误
+func (m *Manager) SyncContext(ctx context.Context, remote storage.Storage) error {
 	if !m.open {
 		return ErrVaultNotOpen
 	}
@@ -451,7 +1167,7 @@ func (m *Manager) Sync(remote storage.Storage) error {
 		return err
 	}
 
-	return remote.Write(data)
+	return remote.Write(ctx, data)
 }
 
 // Pull 从远程存储拉取密码库并替换本地密码库
@@ -463,31 +1179,27 @@ func (m *Manager) Sync(remote storage.Storage) error {
 // 返回:
 //   可能的错误
 func (m *Manager) Pull(remote storage.Storage, masterPassword string) error {
-	data, err := remote.Read()
-	if err != nil {
-		return err
-	}
-
-	var vault types.Vault
-	if err := json.Unmarshal(data, &vault); err != nil {
-		return ErrVaultCorrupted
-	}
+	return m.PullContext(m.ctx, remote, masterPassword)
+}
 
-	salt, err := base64.StdEncoding.DecodeString(vault.Salt)
+// PullContext 与 Pull 相同，但使用指定的 context 访问远程存储
+//
+// 参数:
+//   ctx - 用于取消或限制拉取时间的 context
+//   remote - 远程存储接口
+//   masterPassword - 主密码，用于解密拉取的密码库
+//
+// 返回:
+//   可能的错误
+func (m *Manager) PullContext(ctx context.Context, remote storage.Storage, masterPassword string) error {
+	data, err := remote.Read(ctx)
 	if err != nil {
-		return ErrVaultCorrupted
-	}
-
-	if m.open {
-		m.Close()
+		return err
 	}
 
-	m.salt = salt
-	m.crypto = crypto.NewCrypto(masterPassword, salt)
-	m.vault = &vault
-	m.open = true
-
-	return nil
+	return m.load(data, func(salt []byte) ([]byte, error) {
+		return crypto.DeriveKey(masterPassword, salt), nil
+	})
 }
 
 // GeneratePassword 生成安全的随机密码
@@ -0,0 +1,242 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/imerr0rlog/CipherHub/internal/storage"
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+const testPassword = "correct horse battery staple"
+
+// newTestManager 在临时目录中初始化一个本地密码库
+func newTestManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vault.json")
+	m := NewManager(storage.NewLocalStorage(path))
+	if err := m.Init(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+	return m, path
+}
+
+// reopen 使用 password 重新打开 path 上的密码库
+func reopen(t *testing.T, path, password string) (*Manager, error) {
+	t.Helper()
+	m := NewManager(storage.NewLocalStorage(path))
+	err := m.Open(password)
+	if err == nil {
+		t.Cleanup(m.Close)
+	}
+	return m, err
+}
+
+// removeVerifier 删除密码库文件中的 Verifier，模拟旧版本创建的密码库
+func removeVerifier(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	delete(raw, "verifier")
+	if data, err = json.Marshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenLegacyVault(t *testing.T) {
+	tests := []struct {
+		name         string
+		password     string
+		notes        string // 条目只有备注时没有加密的密码
+		withEntry    bool
+		wantErr      error
+		wantVerifier bool
+	}{
+		{"correct password", testPassword, "", true, nil, true},
+		{"wrong password", "wrong", "", true, ErrInvalidPassword, false},
+		{"notes only, wrong password", "wrong", "secret", true, ErrInvalidPassword, false},
+		{"nothing encrypted", "wrong", "", false, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, path := newTestManager(t)
+			if tt.withEntry {
+				password := "hunter2"
+				if tt.notes != "" {
+					password = ""
+				}
+				if _, err := m.AddEntry("github", "alice", password, "", tt.notes, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			m.Close()
+			removeVerifier(t, path)
+
+			m, err := reopen(t, path, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Open error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := m.vault.Verifier != ""; got != tt.wantVerifier {
+				t.Fatalf("Verifier set = %t, want %t", got, tt.wantVerifier)
+			}
+		})
+	}
+}
+
+func TestOpenWrongPassword(t *testing.T) {
+	m, path := newTestManager(t)
+	m.Close()
+	if _, err := reopen(t, path, "wrong"); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("Open error = %v, want ErrInvalidPassword", err)
+	}
+}
+
+func TestEditEntry(t *testing.T) {
+	tests := []struct {
+		name      string
+		updates   map[string]string
+		fields    []types.Field
+		wantErr   bool
+		wantPath  string
+		wantField int
+	}{
+		{"updates and fields", map[string]string{"name": "work/github"}, []types.Field{{Name: "pin", Type: types.FieldTypeHidden, Value: "1234"}}, false, "work/github", 1},
+		{"fields only", nil, []types.Field{{Name: "pin", Type: types.FieldTypeText, Value: "1234"}}, false, "github", 1},
+		{"nil fields keep existing", map[string]string{"username": "bob"}, nil, false, "github", 1},
+		{"empty fields remove all", nil, []types.Field{}, false, "github", 0},
+		{"invalid field", map[string]string{"name": "work/github"}, []types.Field{{Name: "a", Type: types.FieldTypeText, Value: "1"}, {Name: "a", Type: types.FieldTypeText, Value: "2"}}, true, "github", 1},
+		{"invalid update", map[string]string{"name": "work/github", "expires": "someday"}, []types.Field{}, true, "github", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, path := newTestManager(t)
+			if _, err := m.AddEntry("github", "alice", "hunter2", "", "", nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.SetFields("github", []types.Field{{Name: "recovery", Value: "code"}}); err != nil {
+				t.Fatal(err)
+			}
+			saves := 0
+			m.SetSaveHook(func() { saves++ })
+
+			_, err := m.EditEntry("github", tt.updates, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EditEntry error = %v, wantErr %t", err, tt.wantErr)
+			}
+			wantSaves := 1
+			if tt.wantErr {
+				wantSaves = 0
+			}
+			if saves != wantSaves {
+				t.Fatalf("saves = %d, want %d", saves, wantSaves)
+			}
+
+			// 检查保存到存储中的结果
+			m.Close()
+			m, err = reopen(t, path, testPassword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry, err := m.GetEntry(tt.wantPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entry.Fields) != tt.wantField {
+				t.Fatalf("fields = %d, want %d", len(entry.Fields), tt.wantField)
+			}
+		})
+	}
+}
+
+func TestDueEntries(t *testing.T) {
+	today := time.Now().Format(types.DateLayout)
+	nextWeek := time.Now().AddDate(0, 0, 7).Format(types.DateLayout)
+	tests := []struct {
+		name    string
+		updates map[string]string
+		archive bool
+		within  time.Duration
+		wantDue bool
+	}{
+		{"no policy", nil, false, 30 * 24 * time.Hour, false},
+		{"expires today", map[string]string{"expires": today}, false, 0, true},
+		{"expires next week", map[string]string{"expires": nextWeek}, false, 0, false},
+		{"expires within warning", map[string]string{"expires": nextWeek}, false, 8 * 24 * time.Hour, true},
+		{"rotation not due", map[string]string{"rotation": "30"}, false, 0, false},
+		{"rotation within warning", map[string]string{"rotation": "30d"}, false, 31 * 24 * time.Hour, true},
+		{"rotation cleared", map[string]string{"rotation": "0"}, false, 31 * 24 * time.Hour, false},
+		{"archived", map[string]string{"expires": today}, true, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t)
+			if _, err := m.AddEntry("github", "alice", "hunter2", "", "", nil); err != nil {
+				t.Fatal(err)
+			}
+			if tt.updates != nil {
+				if _, err := m.UpdateEntry("github", tt.updates); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.archive {
+				if _, err := m.SetArchived("github", true); err != nil {
+					t.Fatal(err)
+				}
+			}
+			due, err := m.DueEntries(tt.within)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(due) == 1; got != tt.wantDue {
+				t.Fatalf("due = %t, want %t", got, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestPasswordChangeRestartsRotation(t *testing.T) {
+	m, _ := newTestManager(t)
+	entry, err := m.AddEntry("github", "alice", "hunter2", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().AddDate(0, 0, -60)
+	entry.CreatedAt, entry.PasswordChangedAt = old, old
+	if _, err := m.UpdateEntry("github", map[string]string{"rotation": "30"}); err != nil {
+		t.Fatal(err)
+	}
+	if !entry.Expired(time.Now()) {
+		t.Fatal("entry is not due 60 days after the password was set")
+	}
+
+	// 其他修改不会重新开始轮换周期，修改密码才会
+	if _, err := m.UpdateEntry("github", map[string]string{"username": "bob", "password": "hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+	if !entry.Expired(time.Now()) {
+		t.Fatal("updating the username or setting the same password restarted the rotation period")
+	}
+	if _, err := m.UpdateEntry("github", map[string]string{"password": "correct-battery"}); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Expired(time.Now()) {
+		t.Fatal("changing the password did not restart the rotation period")
+	}
+}
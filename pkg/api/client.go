@@ -24,10 +24,17 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"time"
 
+	"github.com/imerr0rlog/CipherHub/internal/agent"
 	"github.com/imerr0rlog/CipherHub/internal/crypto"
+	"github.com/imerr0rlog/CipherHub/internal/query"
 	"github.com/imerr0rlog/CipherHub/internal/storage"
 	"github.com/imerr0rlog/CipherHub/internal/vault"
 	"github.com/imerr0rlog/CipherHub/pkg/types"
@@ -42,15 +49,19 @@ type Client struct {
 	storage    storage.Storage
 	config     *types.Config
 	configPath string
+	ctx        context.Context
+	useAgent   bool
 }
 
 // ClientOptions 用于配置 NewClientWithOptions 函数的选项。
 //
 // VaultPath 指定密码库文件的路径，ConfigPath 指定配置文件的路径。
 // 如果未指定，将使用默认值。
+// UseAgent 为 true 时，OpenVault 优先使用 cipherhub agent 中缓存的密钥。
 type ClientOptions struct {
 	VaultPath  string
 	ConfigPath string
+	UseAgent   bool
 }
 
 // NewClient 使用给定的配置创建一个新的 Client 实例。
@@ -63,10 +74,18 @@ func NewClient(cfg *types.Config) (*Client, error) {
 		return nil, err
 	}
 
+	manager := vault.NewManager(st)
+	manager.SetPasswordHistory(cfg.PasswordHistoryDepth())
+	manager.SetTrashRetention(cfg.TrashRetention())
+	manager.SetBlobStore(func(name string) (storage.Storage, error) {
+		return storage.NewBlobStorage(cfg, name)
+	})
+
 	return &Client{
 		storage: st,
 		config:  cfg,
-		manager: vault.NewManager(st),
+		manager: manager,
+		ctx:     context.Background(),
 	}, nil
 }
 
@@ -106,6 +125,7 @@ func NewClientWithOptions(opts *ClientOptions) (*Client, error) {
 		return nil, err
 	}
 	client.configPath = configPath
+	client.useAgent = opts.UseAgent
 	return client, nil
 }
 
@@ -137,6 +157,18 @@ func (c *Client) Config() *types.Config {
 	return c.config
 }
 
+// SetContext 设置后续存储操作使用的 context。
+//
+// ctx 参数用于取消或限制密码库读写的时间，传入 nil 时使用 context.Background()。
+// 超时时间由配置中的 WebDAV timeout 控制，ctx 可以进一步缩短。
+func (c *Client) SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c.ctx = ctx
+	c.manager.SetContext(ctx)
+}
+
 // ConfigPath 返回当前配置文件的路径。
 func (c *Client) ConfigPath() string {
 	return c.configPath
@@ -146,12 +178,52 @@ func (c *Client) ConfigPath() string {
 //
 // path 参数是新的密码库文件路径。
 // 设置后会重新初始化存储和密码库管理器。
+// 配置的存储中间件管道无效时，之后的密码库操作返回该错误；需要立即得到错误时使用 UseVaultPath。
 func (c *Client) SetVaultPath(path string) {
+	if err := c.UseVaultPath(path); err != nil {
+		c.config.VaultPath = path
+		c.setStorage(failedStorage{err})
+	}
+}
+
+// UseVaultPath 与 SetVaultPath 相同，但在配置的存储中间件管道无效时返回错误，
+// 此时 Client 仍使用原来的密码库。
+func (c *Client) UseVaultPath(path string) error {
+	st, err := storage.BuildPipeline(storage.NewLocalStorage(path), c.config.StoragePipeline)
+	if err != nil {
+		return err
+	}
 	c.config.VaultPath = path
-	c.storage = storage.NewLocalStorage(path)
+	c.setStorage(st)
+	return nil
+}
+
+// setStorage 使用 st 重新创建密码库管理器
+func (c *Client) setStorage(st storage.Storage) {
+	c.storage = st
 	c.manager = vault.NewManager(c.storage)
+	c.manager.SetContext(c.ctx)
+	c.manager.SetPasswordHistory(c.config.PasswordHistoryDepth())
+	c.manager.SetTrashRetention(c.config.TrashRetention())
+	// 附件数据块与密码库文件一样保存在本地
+	local := *c.config
+	local.DefaultStorage = types.StorageTypeLocal
+	c.manager.SetBlobStore(func(name string) (storage.Storage, error) {
+		return storage.NewBlobStorage(&local, name)
+	})
+}
+
+// failedStorage 是无法创建的存储，所有操作都返回创建时的错误
+type failedStorage struct {
+	err error
 }
 
+func (s failedStorage) Read(ctx context.Context) ([]byte, error)     { return nil, s.err }
+func (s failedStorage) Write(ctx context.Context, data []byte) error { return s.err }
+func (s failedStorage) Exists(ctx context.Context) (bool, error)     { return false, s.err }
+func (s failedStorage) Delete(ctx context.Context) error             { return s.err }
+func (s failedStorage) Type() types.StorageType                      { return types.StorageTypeLocal }
+
 // InitVault 使用主密码初始化一个新的密码库。
 //
 // masterPassword 参数是用于加密密码库的主密码。
@@ -163,9 +235,52 @@ func (c *Client) InitVault(masterPassword string) error {
 // OpenVault 使用主密码打开已存在的密码库。
 //
 // masterPassword 参数是用于解密密码库的主密码。
-// 返回打开成功时为 nil，否则返回错误。
+// 启用 UseAgent 时优先使用 agent 中缓存的密钥，此时 masterPassword 可以为空；
+// agent 没有密钥时使用 masterPassword 打开，并把密钥交给正在运行的 agent 缓存。
+// 返回打开成功时为 nil，主密码错误时返回 ErrInvalidPassword，
+// 未提供主密码且 agent 无法提供密钥时返回 ErrAgentNotRunning 或 ErrAgentLocked。
 func (c *Client) OpenVault(masterPassword string) error {
-	return c.manager.Open(masterPassword)
+	if !c.useAgent {
+		return c.manager.Open(masterPassword)
+	}
+
+	client := agent.NewClient("")
+	var agentErr error
+	err := c.manager.OpenWithKey(func(salt []byte) ([]byte, error) {
+		key, err := client.Key(salt)
+		if err == nil || masterPassword == "" {
+			return key, err
+		}
+		agentErr = err
+		return crypto.DeriveKey(masterPassword, salt), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 通过主密码打开时，如果 agent 正在运行则缓存密钥
+	if errors.Is(agentErr, agent.ErrLocked) {
+		c.cacheKey(client)
+	}
+	return nil
+}
+
+// cacheKey 将当前密码库的密钥交给 agent 缓存
+func (c *Client) cacheKey(client *agent.Client) error {
+	key, err := c.manager.Key()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range key {
+			key[i] = 0
+		}
+	}()
+	salt, err := c.manager.Salt()
+	if err != nil {
+		return err
+	}
+	return client.Add(salt, key)
 }
 
 // CloseVault 关闭当前打开的密码库。
@@ -184,9 +299,10 @@ func (c *Client) IsVaultOpen() bool {
 
 // VaultExists 检查密码库文件是否存在。
 //
-// 返回 true 表示密码库文件存在，false 表示不存在。
-func (c *Client) VaultExists() bool {
-	return c.storage.Exists()
+// 返回 true 表示密码库文件存在，false 表示不存在；
+// 无法确定时（如认证失败、连接超时）返回错误。
+func (c *Client) VaultExists() (bool, error) {
+	return c.storage.Exists(c.ctx)
 }
 
 // AddEntry 向密码库添加一个新的密码条目。
@@ -200,8 +316,10 @@ func (c *Client) AddEntry(name, username, password, url, notes string, tags []st
 
 // GetEntry 从密码库获取指定名称的条目。
 //
-// name 参数是要获取的条目的名称。
-// 返回找到的条目，或者在未找到或获取失败时返回错误。
+// name 参数是要获取的条目的路径、ID、ID 前缀（至少 4 个字符）或别名，
+// 所有按名称操作条目的方法都接受这几种形式。
+// 返回找到的条目，或者在未找到或获取失败时返回错误；匹配多个条目时返回的错误满足
+// errors.Is(err, ErrAmbiguousEntry)，可以用 errors.As 取得 *AmbiguousError 查看候选条目。
 func (c *Client) GetEntry(name string) (*types.Entry, error) {
 	return c.manager.GetEntry(name)
 }
@@ -237,22 +355,205 @@ func (c *Client) SearchEntries(query string) ([]*types.Entry, error) {
 	return c.manager.SearchEntries(query)
 }
 
+// QueryOptions 控制 Query 的匹配方式。
+//
+// Fuzzy 为 true 时普通文本条件按子序列模糊匹配，结果按匹配得分从高到低排序；
+// Now 是日期条件使用的当前时间，零值表示 time.Now()。
+type QueryOptions = query.Options
+
+// Query 使用查询语言搜索条目。
+//
+// expr 参数是查询语句，由空格分隔的条件组成，例如：
+//
+//	tag:prod user:admin url:*.example.com
+//	(tag:prod OR tag:staging) NOT type:note
+//	name:/^db-[0-9]+$/ updated:<90d
+//
+// 支持的限定词有 name、path、folder、user、url、tag、type、field、has、is、created 和 updated。
+// 返回匹配的条目列表；语句无效时返回的错误满足 errors.Is(err, ErrInvalidQuery)。
+func (c *Client) Query(expr string, opts QueryOptions) ([]*types.Entry, error) {
+	return c.manager.Query(expr, opts)
+}
+
+// DueEntries 返回已到期或将在 within 内到期的条目，按到期时间排序。
+//
+// 条目的到期时间由轮换周期（Entry.RotationDays，从密码最后一次改变时算起）和过期时间
+// （Entry.ExpiresAt）中较早的一个决定，可以用 Entry.DueAt 和 Entry.Expired 查看。
+// 通过 UpdateEntry 的 rotation 和 expires 键设置。
+func (c *Client) DueEntries(within time.Duration) ([]*types.Entry, error) {
+	return c.manager.DueEntries(within)
+}
+
+// GetTOTP 返回条目当前的 TOTP 验证码及其剩余有效时间。
+//
+// 条目没有配置 TOTP 时返回 ErrNoTOTP。
+func (c *Client) GetTOTP(name string) (string, time.Duration, error) {
+	config, err := c.manager.GetTOTP(name)
+	if err != nil {
+		return "", 0, err
+	}
+	now := time.Now()
+	code, err := config.Code(now)
+	if err != nil {
+		return "", 0, err
+	}
+	return code, config.Remaining(now), nil
+}
+
+// GetFields 返回条目的自定义字段，hidden 类型的值已解密。
+func (c *Client) GetFields(name string) ([]types.Field, error) {
+	return c.manager.GetFields(name)
+}
+
+// SetFields 添加、修改或删除条目的自定义字段。
+//
+// 已存在的同名字段原地修改，其余追加到末尾；值为空表示删除该字段。
+// hidden 类型的值会被加密保存。
+func (c *Client) SetFields(name string, fields []types.Field) (*types.Entry, error) {
+	return c.manager.SetFields(name, fields)
+}
+
+// AddAttachment 读取 r 的全部内容，加密后作为条目的附件 filename 保存。
+//
+// 内容按块流式加密，作为独立的数据块保存在密码库旁的 attachments 目录中，
+// 因此可以添加大于内存的文件。条目已有同名附件时返回 ErrAttachmentExists。
+func (c *Client) AddAttachment(name, filename string, r io.Reader) (*types.Attachment, error) {
+	return c.manager.AddAttachment(name, filename, r)
+}
+
+// ReadAttachment 解密条目的附件 filename 并写入 w。
+//
+// 数据块缺失或被篡改时返回 ErrAttachmentCorrupted，此时 w 中可能已写入部分内容。
+func (c *Client) ReadAttachment(name, filename string, w io.Writer) (*types.Attachment, error) {
+	return c.manager.ReadAttachment(name, filename, w)
+}
+
+// RemoveAttachment 删除条目的附件 filename 及其数据块。
+func (c *Client) RemoveAttachment(name, filename string) error {
+	return c.manager.RemoveAttachment(name, filename)
+}
+
 // UpdateEntry 更新密码库中指定条目的信息。
 //
-// name 参数是要更新的条目的名称，updates 参数是要更新的字段和值的映射。
+// name 参数是要更新的条目的名称，updates 参数是要更新的字段和值的映射，
+// 支持的键为 name（重命名）、username、password、url、notes、tags（逗号分隔）
+// 和 totp（otpauth:// URI 或 base32 密钥，空字符串表示删除）。
 // 返回更新后的条目，或者在更新失败时返回错误。
 func (c *Client) UpdateEntry(name string, updates map[string]string) (*types.Entry, error) {
 	return c.manager.UpdateEntry(name, updates)
 }
 
+// MoveEntry 移动或重命名条目。
+//
+// dest 以 / 结尾、为空或是已存在的文件夹时，条目保留名称移动到该文件夹；
+// 否则 dest 是条目的新路径，如 work/aws/root。
+func (c *Client) MoveEntry(name, dest string) (*types.Entry, error) {
+	return c.manager.MoveEntry(name, dest)
+}
+
+// ListFolders 返回所有文件夹的路径，按字典序排列。
+func (c *Client) ListFolders() ([]string, error) {
+	return c.manager.ListFolders()
+}
+
+// AddFolder 创建文件夹，上级文件夹不存在时一并创建。
+func (c *Client) AddFolder(path string) (string, error) {
+	return c.manager.AddFolder(path)
+}
+
+// MoveFolder 移动或重命名文件夹，其中的条目和子文件夹一起移动。
+//
+// 返回移动后的文件夹路径和移动的条目数量。
+func (c *Client) MoveFolder(src, dest string) (string, int, error) {
+	return c.manager.MoveFolder(src, dest)
+}
+
+// RemoveFolder 删除空文件夹，文件夹不为空时返回 ErrFolderNotEmpty。
+func (c *Client) RemoveFolder(path string) error {
+	return c.manager.RemoveFolder(path)
+}
+
+// AddAlias 为条目添加别名，之后可以用别名代替路径访问条目。
+//
+// 别名不区分大小写地在密码库内唯一，不能包含空白、逗号和 /；
+// 别名已被使用或与条目路径相同时返回 ErrAliasExists。
+func (c *Client) AddAlias(name, alias string) (*types.Entry, error) {
+	return c.manager.AddAlias(name, alias)
+}
+
+// RemoveAlias 删除别名，返回原来使用该别名的条目。
+//
+// 没有条目使用该别名时返回 ErrAliasNotFound。
+func (c *Client) RemoveAlias(alias string) (*types.Entry, error) {
+	return c.manager.RemoveAlias(alias)
+}
+
+// ListAliases 返回所有别名到条目的映射。
+func (c *Client) ListAliases() (map[string]*types.Entry, error) {
+	return c.manager.ListAliases()
+}
+
+// GetPasswordHistory 返回条目以前的密码，最近的在前，密码已解密。
+//
+// 第 1 个元素对应版本 1，即上一个密码。
+func (c *Client) GetPasswordHistory(name string) ([]types.PasswordVersion, error) {
+	return c.manager.GetPasswordHistory(name)
+}
+
+// RestorePassword 把条目第 version 个历史密码恢复为当前密码。
+//
+// 当前密码会进入历史。版本不存在时返回 ErrNoPasswordVersion。
+func (c *Client) RestorePassword(name string, version int) (*types.Entry, error) {
+	password, err := c.manager.GetPasswordVersion(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return c.manager.UpdateEntry(name, map[string]string{"password": password})
+}
+
 // DeleteEntry 从密码库中删除指定名称的条目。
 //
-// name 参数是要删除的条目的名称。
+// name 参数是要删除的条目的名称。条目移入回收站，在配置的保留期（Config.TrashDays）内
+// 可以用 RestoreEntry 恢复；保留期为 0 时直接永久删除。
 // 返回删除成功时为 nil，否则返回错误。
 func (c *Client) DeleteEntry(name string) error {
 	return c.manager.DeleteEntry(name)
 }
 
+// ListTrash 返回回收站中的条目，最近删除的在前。
+//
+// 条目的 DeletedAt 是删除时间，加上保留期即为永久删除的时间；
+// 保留期为 0 时回收站中的条目不会过期，只能用 EmptyTrash 永久删除。
+func (c *Client) ListTrash() ([]*types.Entry, error) {
+	return c.manager.ListTrash()
+}
+
+// RestoreEntry 将回收站中的条目恢复到密码库。
+//
+// dest 为空时恢复到原来的路径，否则与 MoveEntry 的 dest 相同；
+// 目标路径已被其他条目使用或与其他条目的别名相同时返回 ErrEntryExists。
+func (c *Client) RestoreEntry(name, dest string) (*types.Entry, error) {
+	return c.manager.RestoreEntry(name, dest)
+}
+
+// EmptyTrash 永久删除回收站中的所有条目及其附件，返回删除的条目数量。
+func (c *Client) EmptyTrash() (int, error) {
+	return c.manager.EmptyTrash()
+}
+
+// SetFavorite 收藏条目或取消收藏。
+func (c *Client) SetFavorite(name string, favorite bool) (*types.Entry, error) {
+	return c.manager.SetFavorite(name, favorite)
+}
+
+// SetArchived 归档条目或取消归档。
+//
+// 归档的条目仍可以按名称访问，但不会出现在 DueEntries 的结果中；
+// ListEntries 返回所有条目，调用者可以根据 Entry.Archived 过滤。
+func (c *Client) SetArchived(name string, archived bool) (*types.Entry, error) {
+	return c.manager.SetArchived(name, archived)
+}
+
 // GeneratePassword 生成一个指定长度的随机安全密码。
 //
 // length 参数是要生成的密码的长度。
@@ -297,12 +598,22 @@ func (c *Client) Pull(remote storage.Storage, masterPassword string) error {
 // opts 参数控制同步哪些内容，默认为同步密码库和配置。
 // 返回同步成功时为 nil，否则返回错误。
 func (c *Client) SyncToWebDAV(opts *SyncOptions) error {
+	return c.SyncToWebDAVContext(c.ctx, opts)
+}
+
+// SyncToWebDAVContext 与 SyncToWebDAV 相同，但使用指定的 context。
+//
+// ctx 取消后会中断正在进行的请求并返回 ctx.Err()。
+func (c *Client) SyncToWebDAVContext(ctx context.Context, opts *SyncOptions) error {
 	if c.config.WebDAV == nil || c.config.WebDAV.URL == "" {
 		return ErrWebDAVNotConfigured
 	}
 
-	webdavStorage := storage.NewWebDAVStorage(c.config.WebDAV)
-	if err := webdavStorage.Connect(); err != nil {
+	webdavStorage, err := storage.NewWebDAVStorage(c.config.WebDAV)
+	if err != nil {
+		return err
+	}
+	if err := webdavStorage.Connect(ctx); err != nil {
 		return err
 	}
 
@@ -310,7 +621,11 @@ func (c *Client) SyncToWebDAV(opts *SyncOptions) error {
 	syncConfig := opts == nil || opts.SyncConfig
 
 	if syncVault {
-		if err := c.manager.Sync(webdavStorage); err != nil {
+		remote, err := storage.BuildPipeline(storage.WithConfiguredRetry(c.config.WebDAV)(webdavStorage), c.config.StoragePipeline)
+		if err != nil {
+			return err
+		}
+		if err := c.manager.SyncContext(ctx, remote); err != nil {
 			return err
 		}
 	}
@@ -320,17 +635,14 @@ func (c *Client) SyncToWebDAV(opts *SyncOptions) error {
 		if err != nil {
 			return err
 		}
-		configStorage := storage.NewWebDAVStorage(&types.WebDAVConfig{
-			URL:                c.config.WebDAV.URL,
-			Username:           c.config.WebDAV.Username,
-			Password:           c.config.WebDAV.Password,
-			RemotePath:         c.config.WebDAV.ConfigRemotePath,
-			InsecureSkipVerify: c.config.WebDAV.InsecureSkipVerify,
-		})
-		if err := configStorage.Connect(); err != nil {
+		configStorage, err := storage.NewWebDAVStorage(c.config.WebDAV.WithRemotePath(c.config.WebDAV.ConfigRemotePath))
+		if err != nil {
+			return err
+		}
+		if err := configStorage.Connect(ctx); err != nil {
 			return err
 		}
-		if err := configStorage.Write(configData); err != nil {
+		if err := configStorage.Write(ctx, configData); err != nil {
 			return err
 		}
 	}
@@ -343,6 +655,13 @@ func (c *Client) SyncToWebDAV(opts *SyncOptions) error {
 // opts 参数控制拉取哪些内容，默认为拉取密码库和配置。
 // 返回拉取成功时为 nil，否则返回错误。
 func (c *Client) PullFromWebDAV(opts *SyncOptions) error {
+	return c.PullFromWebDAVContext(c.ctx, opts)
+}
+
+// PullFromWebDAVContext 与 PullFromWebDAV 相同，但使用指定的 context。
+//
+// ctx 取消后会中断正在进行的请求并返回 ctx.Err()。
+func (c *Client) PullFromWebDAVContext(ctx context.Context, opts *SyncOptions) error {
 	if c.config.WebDAV == nil || c.config.WebDAV.URL == "" {
 		return ErrWebDAVNotConfigured
 	}
@@ -351,37 +670,49 @@ func (c *Client) PullFromWebDAV(opts *SyncOptions) error {
 	syncConfig := opts == nil || opts.SyncConfig
 
 	if syncVault {
-		webdavStorage := storage.NewWebDAVStorage(c.config.WebDAV)
-		if err := webdavStorage.Connect(); err != nil {
+		webdavStorage, err := storage.NewWebDAVStorage(c.config.WebDAV)
+		if err != nil {
 			return err
 		}
-		if !webdavStorage.Exists() {
+		if err := webdavStorage.Connect(ctx); err != nil {
+			return err
+		}
+		exists, err := webdavStorage.Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
 			return ErrRemoteVaultNotFound
 		}
-		data, err := webdavStorage.Read()
+		remote, err := storage.BuildPipeline(storage.WithConfiguredRetry(c.config.WebDAV)(webdavStorage), c.config.StoragePipeline)
+		if err != nil {
+			return err
+		}
+		data, err := remote.Read(ctx)
 		if err != nil {
 			return err
 		}
-		if err := c.storage.Write(data); err != nil {
+		if err := c.storage.Write(ctx, data); err != nil {
 			return err
 		}
 	}
 
 	if syncConfig && c.config.WebDAV.ConfigRemotePath != "" && c.configPath != "" {
-		configStorage := storage.NewWebDAVStorage(&types.WebDAVConfig{
-			URL:                c.config.WebDAV.URL,
-			Username:           c.config.WebDAV.Username,
-			Password:           c.config.WebDAV.Password,
-			RemotePath:         c.config.WebDAV.ConfigRemotePath,
-			InsecureSkipVerify: c.config.WebDAV.InsecureSkipVerify,
-		})
-		if err := configStorage.Connect(); err != nil {
+		configStorage, err := storage.NewWebDAVStorage(c.config.WebDAV.WithRemotePath(c.config.WebDAV.ConfigRemotePath))
+		if err != nil {
+			return err
+		}
+		if err := configStorage.Connect(ctx); err != nil {
+			return err
+		}
+		exists, err := configStorage.Exists(ctx)
+		if err != nil {
 			return err
 		}
-		if !configStorage.Exists() {
+		if !exists {
 			return ErrRemoteConfigNotFound
 		}
-		data, err := configStorage.Read()
+		data, err := configStorage.Read(ctx)
 		if err != nil {
 			return err
 		}
@@ -396,20 +727,100 @@ func (c *Client) PullFromWebDAV(opts *SyncOptions) error {
 // NewWebDAVStorage 创建一个新的 WebDAV 存储实例。
 //
 // cfg 参数是 WebDAV 配置。
-// 返回初始化后的 WebDAV 存储实例。
-func (c *Client) NewWebDAVStorage(cfg *types.WebDAVConfig) *storage.WebDAVStorage {
+// 返回初始化后的 WebDAV 存储实例，或者在证书等配置无法加载时返回错误。
+func (c *Client) NewWebDAVStorage(cfg *types.WebDAVConfig) (*storage.WebDAVStorage, error) {
 	return storage.NewWebDAVStorage(cfg)
 }
 
 var (
 	// ErrWebDAVNotConfigured 表示 WebDAV 配置未设置或不完整的错误。
-	ErrWebDAVNotConfigured  = storage.ErrStorageConnection
+	ErrWebDAVNotConfigured = errors.New("api: webdav not configured")
 	// ErrRemoteVaultNotFound 表示远程密码库未找到的错误。
-	ErrRemoteVaultNotFound  = storage.ErrStorageNotFound
+	// 同时满足 errors.Is(err, ErrNotFound)。
+	ErrRemoteVaultNotFound = fmt.Errorf("api: remote vault not found: %w", storage.ErrStorageNotFound)
 	// ErrRemoteConfigNotFound 表示远程配置未找到的错误。
-	ErrRemoteConfigNotFound = storage.ErrStorageNotFound
+	// 同时满足 errors.Is(err, ErrNotFound)。
+	ErrRemoteConfigNotFound = fmt.Errorf("api: remote config not found: %w", storage.ErrStorageNotFound)
+)
+
+// 打开密码库相关的错误，可以用 errors.Is 判断 OpenVault 返回的错误。
+var (
+	// ErrInvalidPassword 表示主密码不正确。
+	ErrInvalidPassword = vault.ErrInvalidPassword
+	// ErrAgentNotRunning 表示 cipherhub agent 未运行。
+	ErrAgentNotRunning = agent.ErrNotRunning
+	// ErrAgentLocked 表示 agent 中没有该密码库的密钥。
+	ErrAgentLocked = agent.ErrLocked
 )
 
+// 条目相关的错误，可以用 errors.Is 判断条目操作返回的错误。
+var (
+	// ErrNoTOTP 表示条目没有配置 TOTP，由 GetTOTP 返回。
+	ErrNoTOTP = vault.ErrNoTOTP
+	// ErrEntryExists 表示路径已被其他条目使用或与某个别名相同，由 AddEntry、MoveEntry 和 RestoreEntry 等返回。
+	ErrEntryExists = vault.ErrEntryExists
+	// ErrFolderNotFound 表示文件夹不存在。
+	ErrFolderNotFound = vault.ErrFolderNotFound
+	// ErrFolderNotEmpty 表示文件夹中还有条目或子文件夹，由 RemoveFolder 返回。
+	ErrFolderNotEmpty = vault.ErrFolderNotEmpty
+	// ErrNoPasswordVersion 表示条目没有指定版本的历史密码，由 RestorePassword 返回。
+	ErrNoPasswordVersion = vault.ErrNoPasswordVersion
+	// ErrAttachmentNotFound 表示条目没有指定名称的附件。
+	ErrAttachmentNotFound = vault.ErrAttachmentNotFound
+	// ErrAttachmentExists 表示条目已有同名附件，由 AddAttachment 返回。
+	ErrAttachmentExists = vault.ErrAttachmentExists
+	// ErrAttachmentCorrupted 表示附件数据块缺失、被篡改或被截断，由 ReadAttachment 返回。
+	ErrAttachmentCorrupted = vault.ErrAttachmentCorrupted
+	// ErrInvalidQuery 表示查询语句无效，由 Query 返回。
+	ErrInvalidQuery = query.ErrSyntax
+	// ErrAmbiguousEntry 表示名称、别名或 ID 前缀匹配了多个条目。
+	ErrAmbiguousEntry = vault.ErrAmbiguousEntry
+	// ErrAliasExists 表示别名已被使用或与条目路径相同，由 AddAlias 返回。
+	ErrAliasExists = vault.ErrAliasExists
+	// ErrAliasNotFound 表示没有条目使用该别名，由 RemoveAlias 返回。
+	ErrAliasNotFound = vault.ErrAliasNotFound
+)
+
+// AmbiguousError 表示一个条目引用匹配了多个条目，Candidates 包含所有候选条目。
+//
+// 可以使用 errors.As 获取：
+//
+//	var ae *api.AmbiguousError
+//	if errors.As(err, &ae) {
+//		for _, e := range ae.Candidates {
+//			fmt.Println(e.ID, e.Path())
+//		}
+//	}
+type AmbiguousError = vault.AmbiguousError
+
+// 存储错误的类别，可以用 errors.Is 判断 API 返回的错误。
+var (
+	// ErrNotFound 表示存储资源不存在。
+	ErrNotFound = storage.ErrStorageNotFound
+	// ErrUnauthorized 表示存储认证失败。
+	ErrUnauthorized = storage.ErrStorageUnauthorized
+	// ErrPermission 表示没有访问存储资源的权限。
+	ErrPermission = storage.ErrStoragePermission
+	// ErrConnection 表示无法连接到存储服务器。
+	ErrConnection = storage.ErrStorageConnection
+	// ErrTimeout 表示存储请求超时。
+	ErrTimeout = storage.ErrStorageTimeout
+	// ErrConflict 表示远程资源已被其他设备修改。
+	ErrConflict = storage.ErrStorageConflict
+	// ErrCertificate 表示服务器证书未通过校验（包括固定公钥不匹配）。
+	ErrCertificate = storage.ErrStorageCertificate
+)
+
+// StorageError 是存储层返回的结构化错误，包含操作、后端、路径和 HTTP 状态码。
+//
+// 可以使用 errors.As 获取：
+//
+//	var se *api.StorageError
+//	if errors.As(err, &se) {
+//		fmt.Println(se.Op, se.StatusCode)
+//	}
+type StorageError = storage.Error
+
 // Encrypt 使用主密码和盐值加密明文。
 //
 // masterPassword 参数是主密码，salt 参数是盐值，plaintext 参数是要加密的明文。
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/imerr0rlog/CipherHub/pkg/types"
+)
+
+// newTestClient 创建使用临时目录中本地密码库的 Client
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	cfg := types.DefaultConfig()
+	cfg.DefaultStorage = types.StorageTypeLocal
+	cfg.VaultPath = filepath.Join(t.TempDir(), "vault.json")
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestSetVaultPath(t *testing.T) {
+	client := newTestClient(t)
+	path := filepath.Join(t.TempDir(), "other.json")
+	client.SetVaultPath(path)
+	if err := client.InitVault("master-password"); err != nil {
+		t.Fatal(err)
+	}
+	if client.Config().VaultPath != path {
+		t.Fatalf("VaultPath = %q, want %q", client.Config().VaultPath, path)
+	}
+	if exists, err := client.VaultExists(); err != nil || !exists {
+		t.Fatalf("VaultExists = %t, %v", exists, err)
+	}
+}
+
+func TestSetVaultPathInvalidPipeline(t *testing.T) {
+	client := newTestClient(t)
+	client.Config().StoragePipeline = []types.MiddlewareConfig{{Name: "bogus"}}
+	path := filepath.Join(t.TempDir(), "other.json")
+
+	if err := client.UseVaultPath(path); err == nil {
+		t.Fatal("UseVaultPath with an invalid pipeline succeeded")
+	}
+
+	client.SetVaultPath(path)
+	if err := client.InitVault("master-password"); err == nil {
+		t.Fatal("InitVault after SetVaultPath with an invalid pipeline succeeded")
+	}
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout 是过期日期的格式
+const DateLayout = "2006-01-02"
+
+// PasswordSetAt 返回当前密码的设置时间
+//
+// 旧版本创建的条目没有记录 PasswordChangedAt，依次使用最近一个历史密码被替换的时间和创建时间。
+func (e *Entry) PasswordSetAt() time.Time {
+	if !e.PasswordChangedAt.IsZero() {
+		return e.PasswordChangedAt
+	}
+	if len(e.History) > 0 {
+		return e.History[0].ReplacedAt
+	}
+	return e.CreatedAt
+}
+
+// RotationDueAt 返回按轮换周期需要更换密码的时间，没有设置轮换周期时返回 false
+func (e *Entry) RotationDueAt() (time.Time, bool) {
+	if e.RotationDays <= 0 {
+		return time.Time{}, false
+	}
+	return e.PasswordSetAt().AddDate(0, 0, e.RotationDays), true
+}
+
+// DueAt 返回条目到期的时间：轮换周期到期和过期时间中较早的一个，两者都没有设置时返回 false
+func (e *Entry) DueAt() (time.Time, bool) {
+	due, ok := e.RotationDueAt()
+	if e.ExpiresAt != nil && (!ok || e.ExpiresAt.Before(due)) {
+		return *e.ExpiresAt, true
+	}
+	return due, ok
+}
+
+// Expired 返回条目在 now 时是否已经到期
+func (e *Entry) Expired(now time.Time) bool {
+	due, ok := e.DueAt()
+	return ok && !now.Before(due)
+}
+
+// ParseRotationDays 解析轮换周期，接受天数（如 90）或带 d 后缀的天数（如 90d），
+// 空字符串和 0 表示不轮换
+func ParseRotationDays(s string) (int, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "d")
+	if s == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(s)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("invalid rotation period %q: use a number of days such as 90", s)
+	}
+	return days, nil
+}
+
+// ParseExpiry 解析过期日期（YYYY-MM-DD，本地时间的零点），空字符串表示不过期
+func ParseExpiry(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation(DateLayout, s, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiry date %q: use YYYY-MM-DD", s)
+	}
+	return &t, nil
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+func TestParseRotationDays(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"90", 90, false},
+		{"90d", 90, false},
+		{" 30d ", 30, false},
+		{"-1", 0, true},
+		{"90w", 0, true},
+		{"ninety", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRotationDays(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRotationDays(%q) = %d, %v; want %d, wantErr %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time // 零值表示不过期
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"  ", time.Time{}, false},
+		{"2025-03-31", date(2025, 3, 31), false},
+		{"2025-02-30", time.Time{}, true},
+		{"31/03/2025", time.Time{}, true},
+		{"tomorrow", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseExpiry(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseExpiry(%q) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if tt.want.IsZero() {
+			if got != nil {
+				t.Errorf("ParseExpiry(%q) = %v, want nil", tt.in, got)
+			}
+			continue
+		}
+		if got == nil || !got.Equal(tt.want) {
+			t.Errorf("ParseExpiry(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDueAt(t *testing.T) {
+	created := date(2024, 1, 1)
+	changed := date(2024, 3, 1)
+	replaced := date(2024, 2, 1)
+	expires := date(2024, 4, 1)
+	early := date(2024, 2, 15)
+
+	tests := []struct {
+		name   string
+		entry  Entry
+		want   time.Time // 零值表示不会到期
+		now    time.Time
+		expire bool
+	}{
+		{"no policy", Entry{CreatedAt: created}, time.Time{}, date(2030, 1, 1), false},
+		{"rotation from creation", Entry{CreatedAt: created, RotationDays: 30}, date(2024, 1, 31), date(2024, 1, 31), true},
+		{"rotation from last replacement", Entry{CreatedAt: created, RotationDays: 30, History: []PasswordVersion{{ReplacedAt: replaced}}}, date(2024, 3, 2), date(2024, 3, 1), false},
+		{"rotation from password change", Entry{CreatedAt: created, PasswordChangedAt: changed, RotationDays: 30, History: []PasswordVersion{{ReplacedAt: replaced}}}, date(2024, 3, 31), date(2024, 3, 31), true},
+		{"expiry only", Entry{CreatedAt: created, ExpiresAt: &expires}, expires, date(2024, 3, 31), false},
+		{"expiry before rotation", Entry{CreatedAt: created, PasswordChangedAt: changed, RotationDays: 90, ExpiresAt: &expires}, expires, date(2024, 4, 1), true},
+		{"rotation before expiry", Entry{CreatedAt: created, RotationDays: 30, ExpiresAt: &expires}, date(2024, 1, 31), date(2024, 2, 1), true},
+		{"day before expiry", Entry{CreatedAt: created, ExpiresAt: &early}, early, date(2024, 2, 14), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.entry.DueAt()
+			if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+				t.Fatalf("DueAt = %v, %t; want %v", got, ok, tt.want)
+			}
+			if expired := tt.entry.Expired(tt.now); expired != tt.expire {
+				t.Fatalf("Expired(%v) = %t, want %t", tt.now, expired, tt.expire)
+			}
+		})
+	}
+}
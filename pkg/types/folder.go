@@ -0,0 +1,110 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PathSeparator 分隔文件夹路径中的各级文件夹以及文件夹与条目名称
+const PathSeparator = "/"
+
+// ErrInvalidPath 表示条目或文件夹路径无效
+var ErrInvalidPath = errors.New("invalid path")
+
+// Path 返回条目的完整路径，如 work/aws/prod/root；位于根目录时就是条目名称
+func (e *Entry) Path() string {
+	return JoinPath(e.Folder, e.Name)
+}
+
+// JoinPath 将文件夹路径与名称连接为完整路径
+func JoinPath(folder, name string) string {
+	if folder == "" {
+		return name
+	}
+	return folder + PathSeparator + name
+}
+
+// SplitPath 将完整路径拆分为文件夹路径和最后一级名称
+func SplitPath(path string) (folder, name string) {
+	i := strings.LastIndex(path, PathSeparator)
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
+
+// CleanFolder 规范化文件夹路径：去掉首尾的分隔符，检查每一级名称
+//
+// 空字符串和 "/" 表示根目录，返回空字符串。
+// 各级名称不能为空，首尾不能有空白，不能是 . 或 ..。
+func CleanFolder(path string) (string, error) {
+	path = strings.Trim(path, PathSeparator)
+	if path == "" {
+		return "", nil
+	}
+	for _, part := range strings.Split(path, PathSeparator) {
+		if part == "" || part == "." || part == ".." || strings.TrimSpace(part) != part {
+			return "", fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+	}
+	return path, nil
+}
+
+// ParseEntryPath 解析条目路径，返回规范化的文件夹路径和条目名称
+//
+// 开头的分隔符可以省略，末尾不能有分隔符（那表示文件夹）。
+func ParseEntryPath(path string) (folder, name string, err error) {
+	folder, name = SplitPath(strings.TrimPrefix(path, PathSeparator))
+	if strings.TrimSpace(name) == "" {
+		return "", "", fmt.Errorf("%w: %q has no entry name", ErrInvalidPath, path)
+	}
+	if folder, err = CleanFolder(folder); err != nil {
+		return "", "", err
+	}
+	return folder, name, nil
+}
+
+// InFolder 返回 path 是否是文件夹 folder 本身或位于其中（包括子文件夹），folder 为空表示根目录
+func InFolder(path, folder string) bool {
+	return folder == "" || path == folder || strings.HasPrefix(path, folder+PathSeparator)
+}
+
+// FolderAncestors 返回文件夹及其所有上级文件夹，如 a/b/c 返回 a、a/b、a/b/c
+func FolderAncestors(folder string) []string {
+	if folder == "" {
+		return nil
+	}
+	parts := strings.Split(folder, PathSeparator)
+	out := make([]string, len(parts))
+	for i := range parts {
+		out[i] = strings.Join(parts[:i+1], PathSeparator)
+	}
+	return out
+}
+
+// NormalizeFolders 补全条目所在文件夹及所有上级文件夹，去重并排序
+func (v *Vault) NormalizeFolders() {
+	set := make(map[string]bool, len(v.Folders))
+	for _, f := range v.Folders {
+		for _, a := range FolderAncestors(f) {
+			set[a] = true
+		}
+	}
+	for _, e := range v.Entries {
+		for _, a := range FolderAncestors(e.Folder) {
+			set[a] = true
+		}
+	}
+
+	folders := make([]string, 0, len(set))
+	for f := range set {
+		folders = append(folders, f)
+	}
+	sort.Strings(folders)
+	if len(folders) == 0 {
+		folders = nil
+	}
+	v.Folders = folders
+}
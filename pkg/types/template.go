@@ -0,0 +1,194 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntryType 定义条目的类型，决定添加时提示哪些字段以及如何显示
+type EntryType string
+
+const (
+	EntryTypeLogin    EntryType = "login"    // 网站或应用登录（默认）
+	EntryTypeNote     EntryType = "note"     // 安全笔记
+	EntryTypeCard     EntryType = "card"     // 支付卡
+	EntryTypeIdentity EntryType = "identity" // 身份信息
+	EntryTypeSSHKey   EntryType = "ssh-key"  // SSH 密钥
+	EntryTypeDatabase EntryType = "database" // 数据库凭据
+	EntryTypeAPIToken EntryType = "api"      // API 凭据
+	EntryTypeWiFi     EntryType = "wifi"     // Wi-Fi 网络
+)
+
+// TemplateField 是类型模板中的一个自定义字段
+type TemplateField struct {
+	Name      string    // 字段名称
+	Type      FieldType // 字段类型
+	Label     string    // 显示名称
+	Hint      string    // 交互式输入时的格式提示（可选）
+	Required  bool      // 添加条目时是否必须填写
+	Multiline bool      // 多行内容（如私钥），交互式添加时从文件读取
+}
+
+// EntryTemplate 描述一种条目类型使用的字段
+type EntryTemplate struct {
+	Type    EntryType
+	Title   string          // 类型的显示名称
+	Builtin []string        // 使用的内置字段：username、password、url、notes
+	Fields  []TemplateField // 预定义的自定义字段，按显示顺序排列
+	Summary []string        // list 中显示的摘要字段（内置或非 hidden 的自定义字段）
+}
+
+// Templates 按显示顺序列出所有条目类型的模板
+var Templates = []*EntryTemplate{
+	{
+		Type:    EntryTypeLogin,
+		Title:   "Login",
+		Builtin: []string{"username", "password", "url"},
+		Summary: []string{"username", "url"},
+	},
+	{
+		Type:    EntryTypeNote,
+		Title:   "Secure note",
+		Builtin: []string{"notes"},
+	},
+	{
+		Type:  EntryTypeCard,
+		Title: "Payment card",
+		Fields: []TemplateField{
+			{Name: "cardholder", Type: FieldTypeText, Label: "Cardholder"},
+			{Name: "number", Type: FieldTypeHidden, Label: "Number", Required: true},
+			{Name: "expiry", Type: FieldTypeText, Label: "Expiry", Hint: "MM/YY"},
+			{Name: "cvv", Type: FieldTypeHidden, Label: "CVV"},
+			{Name: "pin", Type: FieldTypeHidden, Label: "PIN"},
+		},
+		Summary: []string{"cardholder", "expiry"},
+	},
+	{
+		Type:  EntryTypeIdentity,
+		Title: "Identity",
+		Fields: []TemplateField{
+			{Name: "full_name", Type: FieldTypeText, Label: "Full name", Required: true},
+			{Name: "email", Type: FieldTypeEmail, Label: "Email"},
+			{Name: "phone", Type: FieldTypeText, Label: "Phone"},
+			{Name: "address", Type: FieldTypeText, Label: "Address"},
+			{Name: "birthday", Type: FieldTypeDate, Label: "Birthday", Hint: "YYYY-MM-DD"},
+			{Name: "id_number", Type: FieldTypeHidden, Label: "ID number"},
+		},
+		Summary: []string{"full_name", "email"},
+	},
+	{
+		Type:    EntryTypeSSHKey,
+		Title:   "SSH key",
+		Builtin: []string{"username"},
+		Fields: []TemplateField{
+			{Name: "host", Type: FieldTypeText, Label: "Host"},
+			{Name: "private_key", Type: FieldTypeHidden, Label: "Private key", Required: true, Multiline: true},
+			{Name: "public_key", Type: FieldTypeText, Label: "Public key", Multiline: true},
+			{Name: "passphrase", Type: FieldTypeHidden, Label: "Passphrase"},
+		},
+		Summary: []string{"username", "host"},
+	},
+	{
+		Type:    EntryTypeDatabase,
+		Title:   "Database",
+		Builtin: []string{"username", "password"},
+		Fields: []TemplateField{
+			{Name: "host", Type: FieldTypeText, Label: "Host", Required: true},
+			{Name: "port", Type: FieldTypeText, Label: "Port"},
+			{Name: "database", Type: FieldTypeText, Label: "Database"},
+			{Name: "connection_string", Type: FieldTypeHidden, Label: "Connection string"},
+		},
+		Summary: []string{"username", "host", "database"},
+	},
+	{
+		Type:    EntryTypeAPIToken,
+		Title:   "API credential",
+		Builtin: []string{"url"},
+		Fields: []TemplateField{
+			{Name: "key_id", Type: FieldTypeText, Label: "Key ID"},
+			{Name: "secret", Type: FieldTypeHidden, Label: "Secret", Required: true},
+		},
+		Summary: []string{"key_id", "url"},
+	},
+	{
+		Type:    EntryTypeWiFi,
+		Title:   "Wi-Fi network",
+		Builtin: []string{"password"},
+		Fields: []TemplateField{
+			{Name: "ssid", Type: FieldTypeText, Label: "SSID", Required: true},
+			{Name: "security", Type: FieldTypeText, Label: "Security", Hint: "e.g. WPA2"},
+		},
+		Summary: []string{"ssid", "security"},
+	},
+}
+
+// entryTypeAliases 是类型名称的别名
+var entryTypeAliases = map[string]EntryType{
+	"secure-note":  EntryTypeNote,
+	"payment-card": EntryTypeCard,
+	"credit-card":  EntryTypeCard,
+	"ssh":          EntryTypeSSHKey,
+	"db":           EntryTypeDatabase,
+	"api-token":    EntryTypeAPIToken,
+	"wi-fi":        EntryTypeWiFi,
+}
+
+// ParseEntryType 解析条目类型名称，空字符串表示 login
+func ParseEntryType(s string) (EntryType, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return EntryTypeLogin, nil
+	}
+	if t, ok := entryTypeAliases[s]; ok {
+		return t, nil
+	}
+	for _, tmpl := range Templates {
+		if string(tmpl.Type) == s {
+			return tmpl.Type, nil
+		}
+	}
+
+	names := make([]string, 0, len(Templates))
+	for _, tmpl := range Templates {
+		names = append(names, string(tmpl.Type))
+	}
+	return "", fmt.Errorf("unknown entry type %q (expected %s)", s, strings.Join(names, ", "))
+}
+
+// TemplateFor 返回类型对应的模板，未知类型和空类型返回 login 模板
+func TemplateFor(t EntryType) *EntryTemplate {
+	for _, tmpl := range Templates {
+		if tmpl.Type == t {
+			return tmpl
+		}
+	}
+	return Templates[0]
+}
+
+// Field 返回模板中名为 name 的字段，不存在时返回 nil
+func (t *EntryTemplate) Field(name string) *TemplateField {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// UsesBuiltin 返回模板是否使用内置字段 name
+func (t *EntryTemplate) UsesBuiltin(name string) bool {
+	for _, b := range t.Builtin {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Kind 返回条目的类型，旧条目没有类型时为 login
+func (e *Entry) Kind() EntryType {
+	if e.Type == "" {
+		return EntryTypeLogin
+	}
+	return e.Type
+}
@@ -3,32 +3,141 @@ package types
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 )
 
 // Entry 表示密码库中的单个密码条目
 type Entry struct {
-	ID        string    `json:"id"`         // 唯一标识符
-	Name      string    `json:"name"`       // 条目名称
-	Username  string    `json:"username"`   // 用户名
-	Password  string    `json:"password"`   // AES-256-GCM 加密，base64 编码
-	URL       string    `json:"url,omitempty"` // 网站地址（可选）
-	Notes     string    `json:"notes,omitempty"` // 备注（加密，base64 编码，可选）
-	CreatedAt time.Time `json:"created_at"` // 创建时间
-	UpdatedAt time.Time `json:"updated_at"` // 更新时间
-	Tags      []string  `json:"tags,omitempty"` // 标签（可选）
+	ID                string            `json:"id"`                         // 唯一标识符
+	Name              string            `json:"name"`                       // 条目名称
+	Folder            string            `json:"folder,omitempty"`           // 所在文件夹的路径，如 work/aws/prod（空表示根目录）
+	Aliases           []string          `json:"aliases,omitempty"`          // 别名，可以代替路径查找条目（可选）
+	Type              EntryType         `json:"type,omitempty"`             // 条目类型（空表示 login）
+	Username          string            `json:"username"`                   // 用户名
+	Password          string            `json:"password"`                   // AES-256-GCM 加密，base64 编码
+	URL               string            `json:"url,omitempty"`              // 网站地址（可选）
+	Notes             string            `json:"notes,omitempty"`            // 备注（加密，base64 编码，可选）
+	CreatedAt         time.Time         `json:"created_at"`                 // 创建时间
+	UpdatedAt         time.Time         `json:"updated_at"`                 // 更新时间
+	PasswordChangedAt time.Time         `json:"password_changed_at"`        // 当前密码的设置时间，只在密码改变时更新（与 UpdatedAt 不同）
+	Tags              []string          `json:"tags,omitempty"`             // 标签（可选）
+	TOTP              string            `json:"totp,omitempty"`             // otpauth:// URI（加密，base64 编码，可选）
+	Fields            []Field           `json:"fields,omitempty"`           // 自定义字段，按添加顺序排列（可选）
+	Attachments       []Attachment      `json:"attachments,omitempty"`      // 附件，内容作为独立的数据块保存（可选）
+	History           []PasswordVersion `json:"password_history,omitempty"` // 以前的密码，最近的在前（可选）
+	RotationDays      int               `json:"rotation_days,omitempty"`    // 密码轮换周期（天），0 表示不需要轮换（可选）
+	ExpiresAt         *time.Time        `json:"expires_at,omitempty"`       // 过期时间（可选）
+	Favorite          bool              `json:"favorite,omitempty"`         // 是否收藏，列表中排在前面
+	Archived          bool              `json:"archived,omitempty"`         // 是否已归档，默认不在列表中显示
+	DeletedAt         *time.Time        `json:"deleted_at,omitempty"`       // 移入回收站的时间（只有回收站中的条目设置）
+}
+
+// PasswordVersion 是条目的一个历史密码
+type PasswordVersion struct {
+	Password   string    `json:"password"`    // 以前的密码（加密，base64 编码）
+	ReplacedAt time.Time `json:"replaced_at"` // 被替换的时间
+}
+
+// Attachment 是条目的一个附件
+//
+// 附件内容按块加密后作为独立的数据块保存在存储中，密码库只记录元数据。
+// 第 i 块的名称为 ID.i。
+type Attachment struct {
+	ID        string    `json:"id"`         // 唯一标识符，用于数据块名称和加密的附加数据
+	Name      string    `json:"name"`       // 文件名，在条目内唯一
+	Size      int64     `json:"size"`       // 明文大小（字节）
+	Chunks    int       `json:"chunks"`     // 数据块数量
+	CreatedAt time.Time `json:"created_at"` // 添加时间
+}
+
+// FieldType 定义自定义字段的类型
+type FieldType string
+
+const (
+	FieldTypeText   FieldType = "text"   // 普通文本
+	FieldTypeHidden FieldType = "hidden" // 秘密值，加密存储且默认不显示
+	FieldTypeURL    FieldType = "url"    // 网址
+	FieldTypeEmail  FieldType = "email"  // 电子邮件地址
+	FieldTypeDate   FieldType = "date"   // 日期，格式为 YYYY-MM-DD
+)
+
+// Field 是条目的一个自定义字段
+type Field struct {
+	Name  string    `json:"name"`  // 字段名称，在条目内唯一
+	Type  FieldType `json:"type"`  // 字段类型
+	Value string    `json:"value"` // 字段值（hidden 类型加密，base64 编码）
+}
+
+// fieldNamePattern 限制自定义字段名称，使其可以用于 chub:// 引用和 run --env
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// reservedFieldNames 是条目内置字段的名称，不能用作自定义字段名称
+var reservedFieldNames = map[string]bool{
+	"name": true, "username": true, "password": true, "url": true,
+	"notes": true, "tags": true, "totp": true,
+}
+
+// ParseFieldType 解析字段类型名称，secret 是 hidden 的别名
+func ParseFieldType(s string) (FieldType, error) {
+	switch t := FieldType(strings.ToLower(strings.TrimSpace(s))); t {
+	case FieldTypeText, FieldTypeHidden, FieldTypeURL, FieldTypeEmail, FieldTypeDate:
+		return t, nil
+	case "secret":
+		return FieldTypeHidden, nil
+	}
+	return "", fmt.Errorf("unknown field type %q (expected text, hidden, url, email or date)", s)
+}
+
+// ValidateField 检查自定义字段的名称、类型和明文值
+func ValidateField(f Field) error {
+	if !fieldNamePattern.MatchString(f.Name) {
+		return fmt.Errorf("invalid field name %q: use letters, digits, '_' and '-', starting with a letter", f.Name)
+	}
+	if reservedFieldNames[strings.ToLower(f.Name)] {
+		return fmt.Errorf("field name %q is reserved for a built-in field", f.Name)
+	}
+	if _, err := ParseFieldType(string(f.Type)); err != nil {
+		return err
+	}
+	if f.Value == "" {
+		return nil
+	}
+
+	switch f.Type {
+	case FieldTypeURL:
+		if u, err := url.Parse(f.Value); err != nil || u.Scheme == "" {
+			return fmt.Errorf("field %s: %q is not an absolute URL", f.Name, f.Value)
+		}
+	case FieldTypeEmail:
+		if addr, err := mail.ParseAddress(f.Value); err != nil || addr.Address != f.Value {
+			return fmt.Errorf("field %s: %q is not an email address", f.Name, f.Value)
+		}
+	case FieldTypeDate:
+		if _, err := time.Parse("2006-01-02", f.Value); err != nil {
+			return fmt.Errorf("field %s: %q is not a date in YYYY-MM-DD format", f.Name, f.Value)
+		}
+	}
+	return nil
 }
 
 // Vault 表示整个密码库结构
 type Vault struct {
-	Version   string            `json:"version"`   // 版本号
-	Salt      string            `json:"salt"`      // Argon2 盐值，base64 编码
-	Checksum  string            `json:"checksum"`  // SHA-256 完整性校验和
-	Entries   []*Entry          `json:"entries"`   // 密码条目列表
-	CreatedAt time.Time         `json:"created_at"` // 创建时间
-	UpdatedAt time.Time         `json:"updated_at"` // 更新时间
+	Version   string            `json:"version"`            // 版本号
+	Salt      string            `json:"salt"`               // Argon2 盐值，base64 编码
+	Checksum  string            `json:"checksum"`           // SHA-256 完整性校验和
+	Verifier  string            `json:"verifier,omitempty"` // 用主密钥加密的固定文本，用于验证主密码
+	Entries   []*Entry          `json:"entries"`            // 密码条目列表
+	Folders   []string          `json:"folders,omitempty"`  // 所有文件夹的路径（包括空文件夹），按字典序排列
+	Trash     []*Entry          `json:"trash,omitempty"`    // 回收站中的条目，保留期过后永久删除
+	CreatedAt time.Time         `json:"created_at"`         // 创建时间
+	UpdatedAt time.Time         `json:"updated_at"`         // 更新时间
 	Metadata  map[string]string `json:"metadata,omitempty"` // 附加元数据（可选）
 }
 
@@ -42,21 +151,121 @@ const (
 
 // Config 保存应用程序的配置信息
 type Config struct {
-	DefaultStorage   StorageType   `json:"default_storage" yaml:"default_storage"` // 默认存储类型
-	VaultPath        string        `json:"vault_path" yaml:"vault_path"`         // 密码库文件路径
-	WebDAV           *WebDAVConfig `json:"webdav,omitempty" yaml:"webdav,omitempty"` // WebDAV 配置（可选）
-	AutoSync         bool          `json:"auto_sync" yaml:"auto_sync"`           // 是否自动同步
-	ClipboardTimeout int           `json:"clipboard_timeout" yaml:"clipboard_timeout"` // 剪贴板超时时间（秒）
+	DefaultStorage   StorageType         `json:"default_storage" yaml:"default_storage"`                       // 默认存储类型
+	VaultPath        string              `json:"vault_path" yaml:"vault_path"`                                 // 密码库文件路径
+	WebDAV           *WebDAVConfig       `json:"webdav,omitempty" yaml:"webdav,omitempty"`                     // WebDAV 配置（可选）
+	AutoSync         bool                `json:"auto_sync" yaml:"auto_sync"`                                   // 是否自动同步
+	ClipboardTimeout int                 `json:"clipboard_timeout" yaml:"clipboard_timeout"`                   // 剪贴板超时时间（秒）
+	AgentTimeout     int                 `json:"agent_timeout,omitempty" yaml:"agent_timeout,omitempty"`       // agent 空闲多久后自动锁定（秒，默认 900）
+	PasswordHistory  *int                `json:"password_history,omitempty" yaml:"password_history,omitempty"` // 每个条目保留的历史密码数量（默认 10，0 表示不保留）
+	TrashDays        *int                `json:"trash_days,omitempty" yaml:"trash_days,omitempty"`             // 删除的条目在回收站中保留的天数（默认 30，0 表示直接永久删除）
+	CompletionIndex  bool                `json:"completion_index,omitempty" yaml:"completion_index,omitempty"` // 是否在本地保存条目名称索引用于 shell 补全
+	StoragePipeline  []MiddlewareConfig  `json:"storage_pipeline,omitempty" yaml:"storage_pipeline,omitempty"` // 存储中间件管道（可选，第一个位于最外层）
+	OfflineCache     *OfflineCacheConfig `json:"offline_cache,omitempty" yaml:"offline_cache,omitempty"`       // 远程密码库的本地副本（可选）
+}
+
+// DefaultAgentTimeout 是 agent 默认的空闲超时时间
+const DefaultAgentTimeout = 15 * time.Minute
+
+// AgentIdleTimeout 返回 agent 空闲多久后自动锁定
+//
+// 未配置时返回 DefaultAgentTimeout。
+func (c *Config) AgentIdleTimeout() time.Duration {
+	if c.AgentTimeout <= 0 {
+		return DefaultAgentTimeout
+	}
+	return time.Duration(c.AgentTimeout) * time.Second
+}
+
+// DefaultPasswordHistory 是每个条目默认保留的历史密码数量
+const DefaultPasswordHistory = 10
+
+// PasswordHistoryDepth 返回每个条目保留的历史密码数量
+//
+// 未配置时返回 DefaultPasswordHistory。
+func (c *Config) PasswordHistoryDepth() int {
+	if c.PasswordHistory == nil {
+		return DefaultPasswordHistory
+	}
+	return max(*c.PasswordHistory, 0)
+}
+
+// DefaultTrashDays 是删除的条目默认在回收站中保留的天数
+const DefaultTrashDays = 30
+
+// TrashRetention 返回删除的条目在回收站中保留的时长
+//
+// 未配置时返回 DefaultTrashDays 天，返回 0 表示删除的条目不进入回收站。
+func (c *Config) TrashRetention() time.Duration {
+	days := DefaultTrashDays
+	if c.TrashDays != nil {
+		days = max(*c.TrashDays, 0)
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// OfflineCacheConfig 定义远程密码库本地副本的配置
+type OfflineCacheConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`                       // 是否启用本地副本
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`         // 本地副本路径（可选，默认位于密码库同目录）
+	KeyFile string `json:"key_file,omitempty" yaml:"key_file,omitempty"` // 本地副本加密密钥文件（可选，默认为副本路径加 .key，不存在时自动生成）
+}
+
+// MiddlewareConfig 定义存储中间件管道中的一个环节
+type MiddlewareConfig struct {
+	Name    string            `json:"name" yaml:"name"`                           // 中间件名称：cache、compression、retry、logging、encryption
+	Options map[string]string `json:"options,omitempty" yaml:"options,omitempty"` // 中间件参数（可选）
 }
 
 // WebDAVConfig 定义 WebDAV 连接配置
 type WebDAVConfig struct {
-	URL                string `json:"url" yaml:"url"`                                   // WebDAV 服务器地址
-	Username           string `json:"username" yaml:"username"`                         // 用户名
-	Password           string `json:"password" yaml:"password"`                         // 密码
-	RemotePath         string `json:"remote_path" yaml:"remote_path"`                   // 密码库在服务器上的路径
-	ConfigRemotePath   string `json:"config_remote_path,omitempty" yaml:"config_remote_path,omitempty"` // 配置文件在服务器上的路径（可选）
-	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"` // 是否跳过 TLS 证书验证
+	URL                string   `json:"url" yaml:"url"`                                                   // WebDAV 服务器地址
+	Username           string   `json:"username" yaml:"username"`                                         // 用户名
+	Password           string   `json:"password" yaml:"password"`                                         // 密码
+	RemotePath         string   `json:"remote_path" yaml:"remote_path"`                                   // 密码库在服务器上的路径
+	ConfigRemotePath   string   `json:"config_remote_path,omitempty" yaml:"config_remote_path,omitempty"` // 配置文件在服务器上的路径（可选）
+	InsecureSkipVerify bool     `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`                 // 是否跳过 TLS 证书验证
+	Timeout            int      `json:"timeout,omitempty" yaml:"timeout,omitempty"`                       // 单次操作超时时间（秒，默认 30）
+	Retries            int      `json:"retries,omitempty" yaml:"retries,omitempty"`                       // 失败后的重试次数（默认不重试）
+	RetryBackoff       int      `json:"retry_backoff,omitempty" yaml:"retry_backoff,omitempty"`           // 首次重试前的等待时间（毫秒，默认 500，之后每次翻倍）
+	Token              string   `json:"token,omitempty" yaml:"token,omitempty"`                           // Bearer 令牌，设置后代替用户名和密码
+	CAFile             string   `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`                       // 额外信任的 CA 证书文件（PEM）
+	ClientCertFile     string   `json:"client_cert_file,omitempty" yaml:"client_cert_file,omitempty"`     // 双向 TLS 客户端证书文件（PEM）
+	ClientKeyFile      string   `json:"client_key_file,omitempty" yaml:"client_key_file,omitempty"`       // 双向 TLS 客户端私钥文件（PEM）
+	PinnedPublicKeys   []string `json:"pinned_public_keys,omitempty" yaml:"pinned_public_keys,omitempty"` // 固定的服务器公钥，格式为 sha256/<base64 SPKI 哈希>
+	Proxy              string   `json:"proxy,omitempty" yaml:"proxy,omitempty"`                           // 代理地址，为空时使用 HTTPS_PROXY 等环境变量，"direct" 表示不使用代理
+}
+
+// WithRemotePath 返回使用另一个远程路径的配置副本
+//
+// 连接、认证和超时等设置保持不变，常用于访问服务器上的配置文件。
+func (c *WebDAVConfig) WithRemotePath(remotePath string) *WebDAVConfig {
+	cp := *c
+	cp.RemotePath = remotePath
+	return &cp
+}
+
+// DefaultWebDAVTimeout 是 WebDAV 单次操作的默认超时时间
+const DefaultWebDAVTimeout = 30 * time.Second
+
+// TimeoutDuration 返回单次操作的超时时间
+//
+// 未配置时返回 DefaultWebDAVTimeout。
+func (c *WebDAVConfig) TimeoutDuration() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultWebDAVTimeout
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// RetryBackoffDuration 返回首次重试前的等待时间
+//
+// 未配置时返回 500 毫秒。
+func (c *WebDAVConfig) RetryBackoffDuration() time.Duration {
+	if c.RetryBackoff <= 0 {
+		return 500 * time.Millisecond
+	}
+	return time.Duration(c.RetryBackoff) * time.Millisecond
 }
 
 // NewVault 创建一个新的空密码库
@@ -78,14 +287,36 @@ func NewEntry(name string) (*Entry, error) {
 	}
 	now := time.Now()
 	return &Entry{
-		ID:        id,
-		Name:      name,
-		CreatedAt: now,
-		UpdatedAt: now,
-		Tags:      make([]string, 0),
+		ID:                id,
+		Name:              name,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		PasswordChangedAt: now,
+		Tags:              make([]string, 0),
 	}, nil
 }
 
+// ShortIDLength 是 ShortID 返回的 ID 前缀长度
+const ShortIDLength = 8
+
+// ShortID 返回 ID 的前 ShortIDLength 个字符，用于显示和按前缀查找条目
+func (e *Entry) ShortID() string {
+	if len(e.ID) <= ShortIDLength {
+		return e.ID
+	}
+	return e.ID[:ShortIDLength]
+}
+
+// HasAlias 返回条目是否有别名 alias（不区分大小写）
+func (e *Entry) HasAlias(alias string) bool {
+	for _, a := range e.Aliases {
+		if strings.EqualFold(a, alias) {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateUUID 生成符合 RFC4122 标准的 UUID v4
 func GenerateUUID() (string, error) {
 	b := make([]byte, 16)
@@ -114,6 +345,17 @@ func SecureRandomString(length int) (string, error) {
 	return string(b), nil
 }
 
+// ParseTags 将逗号分隔的标签字符串拆分为标签列表，忽略空白和空标签
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 // getExeDir 获取程序可执行文件所在的目录
 func getExeDir() string {
 	exePath, err := os.Executable()